@@ -0,0 +1,140 @@
+package lxd
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/hashcode"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func dataSourceLxdExpandedProfiles() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceLxdExpandedProfilesRead,
+
+		Schema: map[string]*schema.Schema{
+			"profiles": {
+				Type:     schema.TypeList,
+				Required: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
+			"remote": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "",
+			},
+
+			"project": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			// Computed attributes
+
+			"expanded_config": {
+				Type:     schema.TypeMap,
+				Computed: true,
+			},
+
+			"expanded_devices": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"type": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"properties": {
+							Type:     schema.TypeMap,
+							Computed: true,
+						},
+
+						"profile": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceLxdExpandedProfilesRead(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	remote := p.selectRemote(d)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	var profiles []string
+	for _, v := range d.Get("profiles").([]interface{}) {
+		profiles = append(profiles, v.(string))
+	}
+
+	// LXD applies profiles in order: a config key of a profile overrides
+	// the same key of the profiles before it, and a device replaces the
+	// whole device of the same name.
+	config := make(map[string]string)
+	devices := make(map[string]map[string]string)
+	deviceProfiles := make(map[string]string)
+	for _, name := range profiles {
+		profile, _, err := server.GetProfile(name)
+		if err != nil {
+			return fmt.Errorf("Unable to retrieve profile (%s): %s", name, err)
+		}
+
+		for k, v := range profile.Config {
+			config[k] = v
+		}
+
+		for n, device := range profile.Devices {
+			devices[n] = device
+			deviceProfiles[n] = name
+		}
+	}
+
+	log.Printf("[DEBUG] Expanded profiles %v: %#v, %#v", profiles, config, devices)
+
+	deviceNames := make([]string, 0, len(devices))
+	for name := range devices {
+		deviceNames = append(deviceNames, name)
+	}
+	sort.Strings(deviceNames)
+
+	expandedDevices := make([]map[string]interface{}, 0, len(deviceNames))
+	for _, name := range deviceNames {
+		properties := make(map[string]string)
+		for k, v := range devices[name] {
+			if k != "type" {
+				properties[k] = v
+			}
+		}
+
+		expandedDevices = append(expandedDevices, map[string]interface{}{
+			"name":       name,
+			"type":       devices[name]["type"],
+			"properties": properties,
+			"profile":    deviceProfiles[name],
+		})
+	}
+
+	d.SetId(fmt.Sprintf("%s/%d", remote, hashcode.String(strings.Join(profiles, ","))))
+	d.Set("expanded_config", config)
+	if err := d.Set("expanded_devices", expandedDevices); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package lxd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dustinkirkland/golang-petname"
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccExpandedProfilesDataSource_order(t *testing.T) {
+	profileName1 := strings.ToLower(petname.Generate(2, "-"))
+	profileName2 := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccExpandedProfilesDataSource_order(profileName1, profileName2),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.lxd_expanded_profiles.profiles1", "expanded_config.limits.cpu", "4"),
+					resource.TestCheckResourceAttr("data.lxd_expanded_profiles.profiles1", "expanded_config.limits.memory", "128MB"),
+					resource.TestCheckResourceAttr("data.lxd_expanded_profiles.profiles1", "expanded_devices.#", "1"),
+					resource.TestCheckResourceAttr("data.lxd_expanded_profiles.profiles1", "expanded_devices.0.name", "shared"),
+					resource.TestCheckResourceAttr("data.lxd_expanded_profiles.profiles1", "expanded_devices.0.properties.path", "/tmp/shared2"),
+					resource.TestCheckResourceAttr("data.lxd_expanded_profiles.profiles1", "expanded_devices.0.profile", profileName2),
+				),
+			},
+		},
+	})
+}
+
+func testAccExpandedProfilesDataSource_order(profileName1, profileName2 string) string {
+	return fmt.Sprintf(`
+resource "lxd_profile" "profile1" {
+  name = "%s"
+
+  config {
+    limits.cpu    = 2
+    limits.memory = "128MB"
+  }
+
+  device {
+    name = "shared"
+    type = "disk"
+
+    properties {
+      source = "/tmp"
+      path   = "/tmp/shared"
+    }
+  }
+}
+
+resource "lxd_profile" "profile2" {
+  name = "%s"
+
+  config {
+    limits.cpu = 4
+  }
+
+  device {
+    name = "shared"
+    type = "disk"
+
+    properties {
+      source = "/tmp"
+      path   = "/tmp/shared2"
+    }
+  }
+}
+
+data "lxd_expanded_profiles" "profiles1" {
+  profiles = ["${lxd_profile.profile1.name}", "${lxd_profile.profile2.name}"]
+}
+	`, profileName1, profileName2)
+}
@@ -0,0 +1,138 @@
+package lxd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func dataSourceLxdImage() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceLxdImageRead,
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"type": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "container",
+				ValidateFunc: resourceLxdValidateImageType,
+			},
+
+			"architecture": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Computed:         true,
+				ValidateFunc:     resourceLxdValidateArchitecture,
+				DiffSuppressFunc: suppressArchitectureDifferences,
+			},
+
+			"remote": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "",
+			},
+
+			"project": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			// Computed attributes
+
+			"fingerprint": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"size": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"public": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+
+			"created_at": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"properties": {
+				Type:     schema.TypeMap,
+				Computed: true,
+			},
+
+			"aliases": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func dataSourceLxdImageRead(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectImageServer(d)
+	if err != nil {
+		return err
+	}
+
+	name := d.Get("name").(string)
+	imgType := d.Get("type").(string)
+	arch := d.Get("architecture").(string)
+
+	// has the user provided an fingerprint or alias?
+	image := name
+	if arch != "" {
+		targets, _ := server.GetImageAliasArchitectures(imgType, name)
+		if len(targets) > 0 {
+			target, ok := targets[normalizeArchitecture(arch)]
+			if !ok {
+				return fmt.Errorf("Image %s is not available for architecture %s", name, arch)
+			}
+			image = target.Target
+		}
+	} else {
+		aliasTarget, _, _ := server.GetImageAliasType(imgType, name)
+		if aliasTarget != nil {
+			image = aliasTarget.Target
+		}
+	}
+
+	img, _, err := server.GetImage(image)
+	if err != nil {
+		return fmt.Errorf("Unable to find image %s: %s", name, err)
+	}
+
+	log.Printf("[DEBUG] Retrieved image %s: %#v", name, img)
+
+	if arch != "" && normalizeArchitecture(img.Architecture) != normalizeArchitecture(arch) {
+		return fmt.Errorf("Image %s has architecture %s, not %s", name, img.Architecture, arch)
+	}
+
+	d.SetId(img.Fingerprint)
+	d.Set("fingerprint", img.Fingerprint)
+	d.Set("size", img.Size)
+	d.Set("architecture", img.Architecture)
+	d.Set("type", img.Type)
+	d.Set("public", img.Public)
+	d.Set("created_at", img.CreatedAt.Unix())
+	d.Set("properties", img.Properties)
+
+	var aliases []string
+	for _, a := range img.Aliases {
+		aliases = append(aliases, a.Name)
+	}
+	d.Set("aliases", aliases)
+
+	return nil
+}
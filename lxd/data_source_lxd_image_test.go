@@ -0,0 +1,79 @@
+package lxd
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccImageDataSource_alias(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccImageDataSource_basic("alpine/3.9/amd64"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.lxd_image.img1", "architecture", "x86_64"),
+					resource.TestCheckResourceAttr("data.lxd_image.img1", "type", "container"),
+					resource.TestMatchResourceAttr("data.lxd_image.img1", "fingerprint", regexp.MustCompile("^[0-9a-f]{64}$")),
+					resource.TestCheckResourceAttrSet("data.lxd_image.img1", "size"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccImageDataSource_cachedImage(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccImageDataSource_cachedImage(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair(
+						"data.lxd_image.img2", "fingerprint",
+						"lxd_cached_image.img2", "fingerprint"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccImageDataSource_notFound(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccImageDataSource_basic("does-not-exist"),
+				ExpectError: regexp.MustCompile(`Unable to find image`),
+			},
+		},
+	})
+}
+
+func testAccImageDataSource_basic(name string) string {
+	return fmt.Sprintf(`
+data "lxd_image" "img1" {
+  remote = "images"
+  name = "%s"
+}
+	`, name)
+}
+
+func testAccImageDataSource_cachedImage() string {
+	return fmt.Sprintf(`
+resource "lxd_cached_image" "img2" {
+  source_remote = "images"
+  source_image = "alpine/3.9/amd64"
+}
+
+data "lxd_image" "img2" {
+  name = "${substr(lxd_cached_image.img2.fingerprint, 0, 12)}"
+}
+	`)
+}
@@ -0,0 +1,230 @@
+package lxd
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/hashcode"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/lxc/lxd/shared/api"
+)
+
+func dataSourceLxdImages() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceLxdImagesRead,
+
+		Schema: map[string]*schema.Schema{
+			"properties": {
+				Type:     schema.TypeMap,
+				Optional: true,
+			},
+
+			"architecture": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: resourceLxdValidateArchitecture,
+			},
+
+			"type": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: resourceLxdValidateImageType,
+			},
+
+			"public": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+
+			"alias_regex": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: resourceLxdValidateRegexp,
+			},
+
+			"remote": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "",
+			},
+
+			"project": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			// Computed attributes
+
+			"fingerprints": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
+			"images": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"fingerprint": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"architecture": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"type": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"public": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+
+						"size": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+
+						"created_at": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+
+						"last_used_at": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+
+						"properties": {
+							Type:     schema.TypeMap,
+							Computed: true,
+						},
+
+						"aliases": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceLxdImagesRead(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	remote := p.selectRemote(d)
+	server, err := p.selectImageServer(d)
+	if err != nil {
+		return err
+	}
+
+	images, err := server.GetImages()
+	if err != nil {
+		return err
+	}
+
+	var aliasRegex *regexp.Regexp
+	if v := d.Get("alias_regex").(string); v != "" {
+		aliasRegex = regexp.MustCompile(v)
+	}
+
+	properties := resourceLxdConfigMap(d.Get("properties"))
+	arch := d.Get("architecture").(string)
+	imgType := d.Get("type").(string)
+	public, filterPublic := d.GetOkExists("public")
+
+	var matches []api.Image
+	for _, img := range images {
+		if arch != "" && normalizeArchitecture(img.Architecture) != normalizeArchitecture(arch) {
+			continue
+		}
+
+		if imgType != "" && img.Type != imgType {
+			continue
+		}
+
+		if filterPublic && img.Public != public.(bool) {
+			continue
+		}
+
+		if !dataSourceLxdImagesMatchProperties(img, properties) {
+			continue
+		}
+
+		if aliasRegex != nil && !dataSourceLxdImagesMatchAlias(img, aliasRegex) {
+			continue
+		}
+
+		matches = append(matches, img)
+	}
+
+	// Keep the result stable between runs.
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Fingerprint < matches[j].Fingerprint
+	})
+
+	log.Printf("[DEBUG] Found %d matching images out of %d on %s", len(matches), len(images), remote)
+
+	fingerprints := make([]string, 0, len(matches))
+	result := make([]map[string]interface{}, 0, len(matches))
+	for _, img := range matches {
+		var aliases []string
+		for _, a := range img.Aliases {
+			aliases = append(aliases, a.Name)
+		}
+
+		fingerprints = append(fingerprints, img.Fingerprint)
+		result = append(result, map[string]interface{}{
+			"fingerprint":  img.Fingerprint,
+			"architecture": img.Architecture,
+			"type":         img.Type,
+			"public":       img.Public,
+			"size":         int(img.Size),
+			"created_at":   int(img.CreatedAt.Unix()),
+			"last_used_at": int(img.LastUsedAt.Unix()),
+			"properties":   img.Properties,
+			"aliases":      aliases,
+		})
+	}
+
+	d.SetId(fmt.Sprintf("%s/%d", remote, hashcode.String(strings.Join(fingerprints, ","))))
+	d.Set("fingerprints", fingerprints)
+	if err := d.Set("images", result); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// dataSourceLxdImagesMatchProperties reports whether the image
+// has all of the given properties.
+func dataSourceLxdImagesMatchProperties(img api.Image, properties map[string]string) bool {
+	for k, v := range properties {
+		if img.Properties[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// dataSourceLxdImagesMatchAlias reports whether any alias
+// of the image matches the regular expression.
+func dataSourceLxdImagesMatchAlias(img api.Image, re *regexp.Regexp) bool {
+	for _, a := range img.Aliases {
+		if re.MatchString(a.Name) {
+			return true
+		}
+	}
+	return false
+}
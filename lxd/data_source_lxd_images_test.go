@@ -0,0 +1,49 @@
+package lxd
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccImagesDataSource_filters(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccImagesDataSource_filters(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.lxd_images.imgs1", "images.#", "1"),
+					resource.TestCheckResourceAttrPair(
+						"data.lxd_images.imgs1", "fingerprints.0",
+						"lxd_cached_image.imgs1", "fingerprint"),
+					resource.TestCheckResourceAttr("data.lxd_images.imgs1", "images.0.architecture", "x86_64"),
+					resource.TestCheckResourceAttr("data.lxd_images.imgs1", "images.0.properties.os", "Alpine"),
+				),
+			},
+		},
+	})
+}
+
+func testAccImagesDataSource_filters() string {
+	return fmt.Sprintf(`
+resource "lxd_cached_image" "imgs1" {
+  source_remote = "images"
+  source_image = "alpine/3.9/amd64"
+  aliases = ["tf-images-datasource"]
+}
+
+data "lxd_images" "imgs1" {
+  architecture = "x86_64"
+  alias_regex = "^tf-images-datasource$"
+
+  properties {
+    os = "Alpine"
+  }
+
+  depends_on = ["lxd_cached_image.imgs1"]
+}
+	`)
+}
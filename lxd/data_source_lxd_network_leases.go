@@ -0,0 +1,112 @@
+package lxd
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func dataSourceLxdNetworkLeases() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceLxdNetworkLeasesRead,
+
+		Schema: map[string]*schema.Schema{
+			"network": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"remote": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "",
+			},
+
+			"project": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			// Computed attributes
+
+			"leases": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"hostname": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"mac": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"address": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"type": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"location": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceLxdNetworkLeasesRead(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	remote := p.selectRemote(d)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	network := d.Get("network").(string)
+	leases, err := server.GetNetworkLeases(network)
+	if err != nil {
+		return fmt.Errorf("Unable to retrieve leases of network (%s): %s", network, err)
+	}
+
+	log.Printf("[DEBUG] Retrieved leases of network %s: %#v", network, leases)
+
+	// LXD lists the leases in no particular order, sort them to keep the
+	// data source from changing between runs.
+	sort.Slice(leases, func(i, j int) bool {
+		if leases[i].Hostname != leases[j].Hostname {
+			return leases[i].Hostname < leases[j].Hostname
+		}
+		return leases[i].Address < leases[j].Address
+	})
+
+	result := make([]map[string]interface{}, 0, len(leases))
+	for _, lease := range leases {
+		result = append(result, map[string]interface{}{
+			"hostname": lease.Hostname,
+			"mac":      lease.Hwaddr,
+			"address":  lease.Address,
+			"type":     lease.Type,
+			"location": lease.Location,
+		})
+	}
+
+	d.SetId(fmt.Sprintf("%s/%s", remote, network))
+	if err := d.Set("leases", result); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package lxd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/dustinkirkland/golang-petname"
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccNetworkLeasesDataSource_basic(t *testing.T) {
+	networkName := strings.ToLower(petname.Generate(1, "-"))
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccNetworkLeasesDataSource_basic(networkName, instanceName),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.lxd_network_leases.leases1", "leases.#", "1"),
+					resource.TestCheckResourceAttr("data.lxd_network_leases.leases1", "leases.0.hostname", instanceName),
+					resource.TestCheckResourceAttr("data.lxd_network_leases.leases1", "leases.0.address", "10.150.25.10"),
+					resource.TestCheckResourceAttr("data.lxd_network_leases.leases1", "leases.0.mac", "00:16:3e:00:25:10"),
+					resource.TestCheckResourceAttr("data.lxd_network_leases.leases1", "leases.0.type", "static"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccNetworkLeasesDataSource_notFound(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccNetworkLeasesDataSource_notFound(),
+				ExpectError: regexp.MustCompile(`Unable to retrieve leases of network`),
+			},
+		},
+	})
+}
+
+func testAccNetworkLeasesDataSource_basic(networkName, instanceName string) string {
+	return fmt.Sprintf(`
+resource "lxd_network" "net1" {
+  name = "%s"
+
+  config {
+    ipv4.address = "10.150.25.1/24"
+    ipv6.address = "none"
+  }
+}
+
+resource "lxd_instance" "instance1" {
+  name  = "%s"
+  image = "images:alpine/3.9/amd64"
+
+  device {
+    name = "eth0"
+    type = "nic"
+
+    properties {
+      network      = "${lxd_network.net1.name}"
+      hwaddr       = "00:16:3e:00:25:10"
+      ipv4.address = "10.150.25.10"
+    }
+  }
+}
+
+data "lxd_network_leases" "leases1" {
+  network = "${lxd_instance.instance1.device.0.properties.network}"
+}
+	`, networkName, instanceName)
+}
+
+func testAccNetworkLeasesDataSource_notFound() string {
+	return fmt.Sprintf(`
+data "lxd_network_leases" "leases1" {
+  network = "tf-no-such-network"
+}
+	`)
+}
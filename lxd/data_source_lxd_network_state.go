@@ -0,0 +1,310 @@
+package lxd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/lxc/lxd/shared/api"
+)
+
+func dataSourceLxdNetworkState() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceLxdNetworkStateRead,
+
+		Schema: map[string]*schema.Schema{
+			"network": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"target": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"remote": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "",
+			},
+
+			"project": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			// Computed attributes
+
+			"type": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"state": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"hwaddr": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"mtu": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"addresses": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"family": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"address": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"netmask": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"scope": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+
+			"counters": {
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeInt},
+			},
+
+			"bond": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"mode": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"mii_state": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"lower_devices": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+
+			"bridge": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"stp": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+
+						"upper_devices": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+
+			"vlan": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"lower_device": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"vid": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+					},
+				},
+			},
+
+			"members": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"state": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"hwaddr": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"mtu": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceLxdNetworkStateRead(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	remote := p.selectRemote(d)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	network := d.Get("network").(string)
+	target := d.Get("target").(string)
+	if target != "" {
+		server = server.UseTarget(target)
+	}
+
+	state, err := server.GetNetworkState(network)
+	if err != nil {
+		return fmt.Errorf("Unable to retrieve state of network (%s): %s", network, err)
+	}
+
+	log.Printf("[DEBUG] Retrieved state of network %s: %#v", network, state)
+
+	addresses := make([]map[string]interface{}, 0, len(state.Addresses))
+	for _, address := range state.Addresses {
+		addresses = append(addresses, map[string]interface{}{
+			"family":  address.Family,
+			"address": address.Address,
+			"netmask": address.Netmask,
+			"scope":   address.Scope,
+		})
+	}
+
+	counters := map[string]interface{}{
+		"bytes_received":   int(state.Counters.BytesReceived),
+		"bytes_sent":       int(state.Counters.BytesSent),
+		"packets_received": int(state.Counters.PacketsReceived),
+		"packets_sent":     int(state.Counters.PacketsSent),
+	}
+
+	bond := []map[string]interface{}{}
+	if state.Bond != nil {
+		bond = append(bond, map[string]interface{}{
+			"mode":          state.Bond.Mode,
+			"mii_state":     state.Bond.MIIState,
+			"lower_devices": state.Bond.LowerDevices,
+		})
+	}
+
+	bridge := []map[string]interface{}{}
+	if state.Bridge != nil {
+		bridge = append(bridge, map[string]interface{}{
+			"id":            state.Bridge.ID,
+			"stp":           state.Bridge.STP,
+			"upper_devices": state.Bridge.UpperDevices,
+		})
+	}
+
+	vlan := []map[string]interface{}{}
+	if state.VLAN != nil {
+		vlan = append(vlan, map[string]interface{}{
+			"lower_device": state.VLAN.LowerDevice,
+			"vid":          int(state.VLAN.VID),
+		})
+	}
+
+	// On a cluster the interface of the network is a different one on
+	// each member, which may be up on some and down on others.
+	members := []map[string]interface{}{}
+	if target == "" && server.IsClustered() {
+		clusterMembers, err := server.GetClusterMembers()
+		if err != nil {
+			return fmt.Errorf("Unable to retrieve cluster members: %s", err)
+		}
+
+		for _, member := range clusterMembers {
+			memberState, err := server.UseTarget(member.ServerName).GetNetworkState(network)
+			if err != nil {
+				return fmt.Errorf("Unable to retrieve state of network (%s) on cluster member %s: %s", network, member.ServerName, err)
+			}
+
+			members = append(members, dataSourceLxdNetworkStateMember(member.ServerName, memberState))
+		}
+	}
+
+	d.SetId(fmt.Sprintf("%s/%s", remote, network))
+	d.Set("type", state.Type)
+	d.Set("state", state.State)
+	d.Set("hwaddr", state.Hwaddr)
+	d.Set("mtu", state.Mtu)
+	d.Set("counters", counters)
+
+	if err := d.Set("addresses", addresses); err != nil {
+		return err
+	}
+
+	if err := d.Set("bond", bond); err != nil {
+		return err
+	}
+
+	if err := d.Set("bridge", bridge); err != nil {
+		return err
+	}
+
+	if err := d.Set("vlan", vlan); err != nil {
+		return err
+	}
+
+	if err := d.Set("members", members); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func dataSourceLxdNetworkStateMember(name string, state *api.NetworkState) map[string]interface{} {
+	return map[string]interface{}{
+		"name":   name,
+		"state":  state.State,
+		"hwaddr": state.Hwaddr,
+		"mtu":    state.Mtu,
+	}
+}
@@ -0,0 +1,110 @@
+package lxd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/dustinkirkland/golang-petname"
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccNetworkStateDataSource_basic(t *testing.T) {
+	networkName := strings.ToLower(petname.Generate(1, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccNetworkStateDataSource_basic(networkName),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.lxd_network_state.state1", "type", "broadcast"),
+					resource.TestCheckResourceAttr("data.lxd_network_state.state1", "state", "up"),
+					resource.TestCheckResourceAttr("data.lxd_network_state.state1", "mtu", "1500"),
+					resource.TestCheckResourceAttr("data.lxd_network_state.state1", "addresses.0.family", "inet"),
+					resource.TestCheckResourceAttr("data.lxd_network_state.state1", "addresses.0.address", "10.150.26.1"),
+					resource.TestCheckResourceAttr("data.lxd_network_state.state1", "addresses.0.netmask", "24"),
+					resource.TestCheckResourceAttr("data.lxd_network_state.state1", "bridge.#", "1"),
+					resource.TestCheckResourceAttrSet("data.lxd_network_state.state1", "counters.bytes_sent"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccNetworkStateDataSource_cluster(t *testing.T) {
+	members := strings.Split(os.Getenv("LXD_CLUSTER_MEMBERS"), ",")
+	if len(members) < 2 {
+		t.Skip("LXD_CLUSTER_MEMBERS must list at least two cluster members")
+	}
+
+	// The state of host interfaces is available too, their name being
+	// the same on all members.
+	parent := os.Getenv("LXD_NETWORK_PARENT")
+	if parent == "" {
+		t.Skip("LXD_NETWORK_PARENT must name a host interface of the cluster members")
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccNetworkStateDataSource_interface(parent),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.lxd_network_state.state1", "members.#", fmt.Sprintf("%d", len(members))),
+					resource.TestCheckResourceAttr("data.lxd_network_state.state1", "members.0.state", "up"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccNetworkStateDataSource_notFound(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccNetworkStateDataSource_notFound(),
+				ExpectError: regexp.MustCompile(`Unable to retrieve state of network`),
+			},
+		},
+	})
+}
+
+func testAccNetworkStateDataSource_basic(networkName string) string {
+	return fmt.Sprintf(`
+resource "lxd_network" "net1" {
+  name = "%s"
+
+  config {
+    ipv4.address = "10.150.26.1/24"
+    ipv6.address = "none"
+  }
+}
+
+data "lxd_network_state" "state1" {
+  network = "${lxd_network.net1.name}"
+}
+	`, networkName)
+}
+
+func testAccNetworkStateDataSource_interface(name string) string {
+	return fmt.Sprintf(`
+data "lxd_network_state" "state1" {
+  network = "%s"
+}
+	`, name)
+}
+
+func testAccNetworkStateDataSource_notFound() string {
+	return fmt.Sprintf(`
+data "lxd_network_state" "state1" {
+  network = "tf-no-such-network"
+}
+	`)
+}
@@ -0,0 +1,123 @@
+package lxd
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func dataSourceLxdProfile() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceLxdProfileRead,
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"remote": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "",
+			},
+
+			"project": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			// Computed attributes
+
+			"description": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"config": {
+				Type:     schema.TypeMap,
+				Computed: true,
+			},
+
+			"devices": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"type": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"properties": {
+							Type:     schema.TypeMap,
+							Computed: true,
+						},
+					},
+				},
+			},
+
+			"used_by": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func dataSourceLxdProfileRead(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	remote := p.selectRemote(d)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	name := d.Get("name").(string)
+	profile, _, err := server.GetProfile(name)
+	if err != nil {
+		return fmt.Errorf("Unable to retrieve profile (%s): %s", name, err)
+	}
+
+	log.Printf("[DEBUG] Retrieved profile %s: %#v", name, profile)
+
+	deviceNames := make([]string, 0, len(profile.Devices))
+	for n := range profile.Devices {
+		deviceNames = append(deviceNames, n)
+	}
+	sort.Strings(deviceNames)
+
+	devices := make([]map[string]interface{}, 0, len(deviceNames))
+	for _, n := range deviceNames {
+		properties := make(map[string]string)
+		for k, v := range profile.Devices[n] {
+			if k != "type" {
+				properties[k] = v
+			}
+		}
+
+		devices = append(devices, map[string]interface{}{
+			"name":       n,
+			"type":       profile.Devices[n]["type"],
+			"properties": properties,
+		})
+	}
+
+	d.SetId(fmt.Sprintf("%s/%s", remote, name))
+	d.Set("description", profile.Description)
+	d.Set("config", profile.Config)
+	d.Set("used_by", profile.UsedBy)
+	if err := d.Set("devices", devices); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package lxd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/dustinkirkland/golang-petname"
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccProfileDataSource_basic(t *testing.T) {
+	profileName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccProfileDataSource_basic(profileName),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.lxd_profile.profile1", "description", "Shared /tmp"),
+					resource.TestCheckResourceAttr("data.lxd_profile.profile1", "config.limits.cpu", "2"),
+					resource.TestCheckResourceAttr("data.lxd_profile.profile1", "devices.#", "1"),
+					resource.TestCheckResourceAttr("data.lxd_profile.profile1", "devices.0.name", "shared"),
+					resource.TestCheckResourceAttr("data.lxd_profile.profile1", "devices.0.type", "disk"),
+					resource.TestCheckResourceAttr("data.lxd_profile.profile1", "devices.0.properties.path", "/tmp/shared"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccProfileDataSource_notFound(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccProfileDataSource_notFound(),
+				ExpectError: regexp.MustCompile(`Unable to retrieve profile`),
+			},
+		},
+	})
+}
+
+func testAccProfileDataSource_basic(name string) string {
+	return fmt.Sprintf(`
+resource "lxd_profile" "profile1" {
+  name        = "%s"
+  description = "Shared /tmp"
+
+  config {
+    limits.cpu = 2
+  }
+
+  device {
+    name = "shared"
+    type = "disk"
+
+    properties {
+      source = "/tmp"
+      path   = "/tmp/shared"
+    }
+  }
+}
+
+data "lxd_profile" "profile1" {
+  name = "${lxd_profile.profile1.name}"
+}
+	`, name)
+}
+
+func testAccProfileDataSource_notFound() string {
+	return fmt.Sprintf(`
+data "lxd_profile" "profile1" {
+  name = "tf-no-such-profile"
+}
+	`)
+}
@@ -0,0 +1,33 @@
+package lxd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dustinkirkland/golang-petname"
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccInstanceBackup_importBasic(t *testing.T) {
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+	backupName := strings.ToLower(petname.Generate(2, "-"))
+	resourceName := "lxd_instance_backup.backup1"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstanceBackup_basic(instanceName, backupName),
+			},
+
+			resource.TestStep{
+				ResourceName:            resourceName,
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateId:           instanceName + "/" + backupName,
+				ImportStateVerifyIgnore: []string{"expiry"},
+			},
+		},
+	})
+}
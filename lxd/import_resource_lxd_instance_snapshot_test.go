@@ -0,0 +1,32 @@
+package lxd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dustinkirkland/golang-petname"
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccInstanceSnapshot_importBasic(t *testing.T) {
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+	snapshotName := strings.ToLower(petname.Generate(2, "-"))
+	resourceName := "lxd_instance_snapshot.snapshot1"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstanceSnapshot_basic(instanceName, "container", snapshotName),
+			},
+
+			resource.TestStep{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+				ImportStateId:     instanceName + "/" + snapshotName,
+			},
+		},
+	})
+}
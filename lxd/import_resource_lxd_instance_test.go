@@ -0,0 +1,68 @@
+package lxd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dustinkirkland/golang-petname"
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccInstance_importBasic(t *testing.T) {
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+	resourceName := "lxd_instance.instance1"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstance_basic(instanceName),
+			},
+
+			resource.TestStep{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+				ImportStateVerifyIgnore: []string{
+					"image",
+					"stateful",
+					"restart_on_change",
+					"shutdown_timeout",
+					"wait_for_network",
+					"console_output_size",
+				},
+			},
+		},
+	})
+}
+
+func TestAccInstance_importConfig(t *testing.T) {
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+	resourceName := "lxd_instance.instance1"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstance_device(instanceName, "/tmp"),
+			},
+
+			resource.TestStep{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+				ImportStateVerifyIgnore: []string{
+					"image",
+					"stateful",
+					"restart_on_change",
+					"shutdown_timeout",
+					"wait_for_network",
+					"console_output_size",
+				},
+				ImportStateId: "default/" + instanceName,
+			},
+		},
+	})
+}
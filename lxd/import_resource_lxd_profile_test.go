@@ -1,6 +1,7 @@
 package lxd
 
 import (
+	"os"
 	"strings"
 	"testing"
 
@@ -68,3 +69,69 @@ func TestLXDProfile_importDevice(t *testing.T) {
 		},
 	})
 }
+
+func TestLXDProfile_importDescription(t *testing.T) {
+	profileName := strings.ToLower(petname.Generate(2, "-"))
+	resourceName := "lxd_profile.profile1"
+
+	resource.Test(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccProfile_description(profileName, "Web servers"),
+			},
+
+			resource.TestStep{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestLXDProfile_importAddDevice(t *testing.T) {
+	profileName := strings.ToLower(petname.Generate(2, "-"))
+	resourceName := "lxd_profile.profile1"
+
+	resource.Test(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccProfile_addDevice_3(profileName),
+			},
+
+			resource.TestStep{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestLXDProfile_importProject(t *testing.T) {
+	profileName := strings.ToLower(petname.Generate(2, "-"))
+	resourceName := "lxd_profile.profile1"
+
+	project := os.Getenv("LXD_TEST_PROJECT")
+	if project == "" {
+		t.Skip("LXD_TEST_PROJECT must name a project with features.profiles")
+	}
+
+	resource.Test(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccProfile_project(profileName, project),
+			},
+
+			resource.TestStep{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+				ImportStateId:     project + "/" + profileName,
+			},
+		},
+	})
+}
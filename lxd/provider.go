@@ -3,6 +3,7 @@ package lxd
 import (
 	"encoding/pem"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
 	"path"
@@ -43,6 +44,16 @@ type lxdProvider struct {
 	// LXD server/remote.
 	lxdClientMap map[string]lxd.Server
 
+	// configKeysMap caches the config keys documented by each LXD
+	// remote for each kind of entity, with their types, as used to
+	// validate plans.
+	configKeysMap map[string]map[string]string
+
+	// plannedNetworkACLs records the network ACLs planned for creation,
+	// by remote and project, so that the rules of other ACLs planned
+	// after them can refer to them before they exist.
+	plannedNetworkACLs map[string]bool
+
 	// acceptRemoteCertificates toggles if an LXD remote SSL
 	// certificate should be accepted.
 	acceptRemoteCertificate bool
@@ -63,6 +74,10 @@ type terraformLXDConfig struct {
 	port         string
 	password     string
 	scheme       string
+	protocol     string
+	public       bool
+	certificate  string
+	project      string
 	isDefault    bool
 	bootstrapped bool
 }
@@ -87,6 +102,13 @@ func Provider() terraform.ResourceProvider {
 							Default:     "",
 						},
 
+						"certificate": &schema.Schema{
+							Type:         schema.TypeString,
+							Optional:     true,
+							Description:  descriptions["lxd_remote_certificate"],
+							ValidateFunc: validateLxdRemoteCertificate,
+						},
+
 						"default": &schema.Schema{
 							Type:        schema.TypeBool,
 							Optional:    true,
@@ -113,6 +135,27 @@ func Provider() terraform.ResourceProvider {
 							Default:     "8443",
 						},
 
+						"project": &schema.Schema{
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: descriptions["lxd_remote_project"],
+						},
+
+						"protocol": &schema.Schema{
+							Type:         schema.TypeString,
+							Optional:     true,
+							Description:  descriptions["lxd_remote_protocol"],
+							ValidateFunc: validateLxdRemoteProtocol,
+							Default:      "lxd",
+						},
+
+						"public": &schema.Schema{
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Description: descriptions["lxd_remote_public"],
+							Default:     false,
+						},
+
 						"scheme": &schema.Schema{
 							Type:         schema.TypeString,
 							Optional:     true,
@@ -186,16 +229,43 @@ func Provider() terraform.ResourceProvider {
 			},
 		},
 
+		DataSourcesMap: map[string]*schema.Resource{
+			"lxd_expanded_profiles": dataSourceLxdExpandedProfiles(),
+			"lxd_image":             dataSourceLxdImage(),
+			"lxd_images":            dataSourceLxdImages(),
+			"lxd_network_leases":    dataSourceLxdNetworkLeases(),
+			"lxd_network_state":     dataSourceLxdNetworkState(),
+			"lxd_profile":           dataSourceLxdProfile(),
+		},
+
 		ResourcesMap: map[string]*schema.Resource{
 			"lxd_cached_image":            resourceLxdCachedImage(),
 			"lxd_container":               resourceLxdContainer(),
 			"lxd_container_file":          resourceLxdContainerFile(),
+			"lxd_image_export":            resourceLxdImageExport(),
+			"lxd_image_from_file":         resourceLxdImageFromFile(),
+			"lxd_image_from_url":          resourceLxdImageFromURL(),
+			"lxd_image_secret":            resourceLxdImageSecret(),
+			"lxd_instance":                resourceLxdInstance(),
+			"lxd_instance_backup":         resourceLxdInstanceBackup(),
+			"lxd_instance_dns_record":     resourceLxdInstanceDNSRecord(),
+			"lxd_instance_move":           resourceLxdInstanceMove(),
+			"lxd_instance_restore":        resourceLxdInstanceRestore(),
+			"lxd_instance_snapshot":       resourceLxdInstanceSnapshot(),
 			"lxd_network":                 resourceLxdNetwork(),
+			"lxd_network_acl":             resourceLxdNetworkACL(),
+			"lxd_network_peer":            resourceLxdNetworkPeer(),
+			"lxd_network_zone":            resourceLxdNetworkZone(),
+			"lxd_network_zone_record":     resourceLxdNetworkZoneRecord(),
 			"lxd_profile":                 resourceLxdProfile(),
+			"lxd_publish_image":           resourceLxdPublishImage(),
 			"lxd_snapshot":                resourceLxdSnapshot(),
 			"lxd_storage_pool":            resourceLxdStoragePool(),
 			"lxd_volume":                  resourceLxdVolume(),
+			"lxd_volume_attach":           resourceLxdVolumeAttach(),
 			"lxd_volume_container_attach": resourceLxdVolumeContainerAttach(),
+			"lxd_volume_copy":             resourceLxdVolumeCopy(),
+			"lxd_volume_restore":          resourceLxdVolumeRestore(),
 		},
 
 		ConfigureFunc: providerConfigure,
@@ -211,10 +281,14 @@ func init() {
 		"lxd_generate_client_certificates": "Automatically generate the LXD client certificates if they don't exist.",
 		"lxd_refresh_interval":             "How often to poll during state changes (default 10s)",
 		"lxd_remote_address":               "The FQDN or IP where the LXD daemon can be contacted. default = empty (read from lxc config)",
+		"lxd_remote_certificate":           "The PEM encoded certificate of the remote or of the CA that signed it.",
 		"lxd_remote_scheme":                "unix or https. default = unix",
 		"lxd_remote_port":                  "Port LXD Daemon API is listening on. default = 8443.",
 		"lxd_remote_name":                  "Name of the LXD remote. Required when lxd_scheme set to https, to enable locating server certificate.",
 		"lxd_remote_password":              "The password for the remote.",
+		"lxd_remote_project":               "The project of the remote resources are managed in, unless they set their own.",
+		"lxd_remote_protocol":              "lxd or simplestreams. default = lxd",
+		"lxd_remote_public":                "Whether the remote is a public image server. default = false",
 	}
 }
 
@@ -275,6 +349,8 @@ func providerConfigure(d *schema.ResourceData) (interface{}, error) {
 		RefreshInterval:         refreshIntervalParsed,
 		acceptRemoteCertificate: acceptRemoteCertificate,
 		lxdClientMap:            make(map[string]lxd.Server),
+		configKeysMap:           make(map[string]map[string]string),
+		plannedNetworkACLs:      make(map[string]bool),
 		terraformLXDConfigMap:   make(map[string]terraformLXDConfig),
 	}
 
@@ -293,6 +369,8 @@ func providerConfigure(d *schema.ResourceData) (interface{}, error) {
 		port:     os.Getenv("LXD_PORT"),
 		password: os.Getenv("LXD_PASSWORD"),
 		scheme:   os.Getenv("LXD_SCHEME"),
+		project:  os.Getenv("LXD_PROJECT"),
+		protocol: "lxd",
 	}
 
 	// Build an LXD client from the environment-driven remote.
@@ -316,12 +394,16 @@ func providerConfigure(d *schema.ResourceData) (interface{}, error) {
 	for _, v := range d.Get("lxd_remote").([]interface{}) {
 		remote := v.(map[string]interface{})
 		lxdRemote := terraformLXDConfig{
-			name:      remote["name"].(string),
-			address:   remote["address"].(string),
-			port:      remote["port"].(string),
-			password:  remote["password"].(string),
-			scheme:    remote["scheme"].(string),
-			isDefault: remote["default"].(bool),
+			name:        remote["name"].(string),
+			address:     remote["address"].(string),
+			port:        remote["port"].(string),
+			password:    remote["password"].(string),
+			scheme:      remote["scheme"].(string),
+			protocol:    remote["protocol"].(string),
+			public:      remote["public"].(bool),
+			certificate: remote["certificate"].(string),
+			project:     remote["project"].(string),
+			isDefault:   remote["default"].(bool),
 		}
 
 		lxdProv.setTerraformLXDConfig(lxdRemote.name, lxdRemote)
@@ -360,7 +442,27 @@ func (p *lxdProvider) createClient(remoteName string) error {
 				lxdRemote.name, err)
 		}
 
-		p.setLXDRemoteConfig(name, lxd_config.Remote{Addr: daemonAddr})
+		p.setLXDRemoteConfig(name, lxd_config.Remote{
+			Addr:     daemonAddr,
+			Protocol: lxdRemote.protocol,
+			Public:   lxdRemote.isImageServer(),
+		})
+
+		// A certificate in the configuration takes the place of
+		// accepting or exchanging the remote's certificate.
+		if lxdRemote.certificate != "" {
+			if err := p.writeRemoteCertificate(name, []byte(lxdRemote.certificate)); err != nil {
+				return fmt.Errorf("Could not save remote certificate: %s", err)
+			}
+		}
+
+		// Image servers are used anonymously,
+		// so there is nothing to authenticate.
+		if lxdRemote.isImageServer() {
+			lxdRemote.bootstrapped = true
+			p.setTerraformLXDConfig(remoteName, lxdRemote)
+			return nil
+		}
 
 		if scheme == "https" {
 			// If the LXD remote's certificate does not exist on the client...
@@ -418,21 +520,20 @@ func (p *lxdProvider) getRemoteCertificate(remoteName string) error {
 		return err
 	}
 
+	return p.writeRemoteCertificate(remoteName,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw}))
+}
+
+// writeRemoteCertificate saves a PEM encoded certificate for a remote
+// to the servercerts path, where the LXD client looks for it.
+func (p *lxdProvider) writeRemoteCertificate(remoteName string, certificate []byte) error {
 	serverCertDir := p.LXDConfig.ConfigPath("servercerts")
 	if err := os.MkdirAll(serverCertDir, 0750); err != nil {
 		return fmt.Errorf("Could not create server cert dir: %s", err)
 	}
 
 	certf := fmt.Sprintf("%s/%s.crt", serverCertDir, remoteName)
-	certOut, err := os.Create(certf)
-	if err != nil {
-		return err
-	}
-
-	pem.Encode(certOut, &pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw})
-	certOut.Close()
-
-	return nil
+	return ioutil.WriteFile(certf, certificate, 0644)
 }
 
 // GetContainerServer returns a client for the named remote.
@@ -449,7 +550,7 @@ func (p *lxdProvider) GetContainerServer(remoteName string) (lxd.ContainerServer
 	}
 
 	if ci.Protocol == "lxd" {
-		return s.(lxd.ContainerServer), nil
+		return p.useRemoteProject(remoteName, s.(lxd.ContainerServer)), nil
 	}
 
 	err = fmt.Errorf("remote (%s / %s) is not a ContainerServer", remoteName, ci.Protocol)
@@ -469,7 +570,11 @@ func (p *lxdProvider) GetImageServer(remoteName string) (lxd.ImageServer, error)
 		return nil, err
 	}
 
-	if ci.Protocol == "simplestreams" || ci.Protocol == "lxd" {
+	if ci.Protocol == "lxd" {
+		return p.useRemoteProject(remoteName, s.(lxd.ContainerServer)), nil
+	}
+
+	if ci.Protocol == "simplestreams" {
 		return s.(lxd.ImageServer), nil
 	}
 
@@ -505,8 +610,8 @@ func (p *lxdProvider) GetServer(remoteName string) (lxd.Server, error) {
 	var err error
 
 	remoteConfig := p.getRemoteConfig(remoteName)
-	switch remoteConfig.Protocol {
-	case "simplestreams":
+	switch {
+	case remoteConfig.Protocol == "simplestreams", remoteConfig.Public:
 		client, err = p.getLXDImageClient(remoteName)
 	default:
 		client, err = p.getLXDContainerClient(remoteName)
@@ -541,6 +646,53 @@ func (p *lxdProvider) GetServer(remoteName string) (lxd.Server, error) {
 	return client, nil
 }
 
+// useRemoteProject returns a client using the project set on a remote,
+// if there is one.
+func (p *lxdProvider) useRemoteProject(remoteName string, server lxd.ContainerServer) lxd.ContainerServer {
+	if remoteName == "" {
+		remoteName = p.LXDConfig.DefaultRemote
+	}
+
+	if lxdRemote, ok := p.getTerraformLXDConfig(remoteName); ok && lxdRemote.project != "" {
+		return server.UseProject(lxdRemote.project)
+	}
+
+	return server
+}
+
+// selectServer returns a client for the remote of a resource, using the
+// project of the resource if one is set, or else the one of the remote.
+func (p *lxdProvider) selectServer(d *schema.ResourceData) (lxd.ContainerServer, error) {
+	server, err := p.GetContainerServer(p.selectRemote(d))
+	if err != nil {
+		return nil, err
+	}
+
+	if project, ok := d.GetOk("project"); ok && project != "" {
+		server = server.UseProject(project.(string))
+	}
+
+	return server, nil
+}
+
+// selectImageServer returns a client for the image remote of a resource,
+// using its project like selectServer for LXD servers. Other image servers
+// have no projects.
+func (p *lxdProvider) selectImageServer(d *schema.ResourceData) (lxd.ImageServer, error) {
+	server, err := p.GetImageServer(p.selectRemote(d))
+	if err != nil {
+		return nil, err
+	}
+
+	if project, ok := d.GetOk("project"); ok && project != "" {
+		if cs, ok := server.(lxd.ContainerServer); ok {
+			server = cs.UseProject(project.(string))
+		}
+	}
+
+	return server, nil
+}
+
 // selectRemote is a convenience method that returns the 'remote' set
 // in the LXD resource or the default remote configured on the Provider.
 func (p *lxdProvider) selectRemote(d *schema.ResourceData) string {
@@ -625,6 +777,52 @@ func (p *lxdProvider) getLXDClient(remoteName string) (lxd.Server, bool) {
 	return lxdClient, ok
 }
 
+// getConfigKeys returns the config keys an LXD remote documents for an
+// entity, such as instance or network-bridge, fetching them from the
+// server the first time.
+func (p *lxdProvider) getConfigKeys(remoteName, entity string) (map[string]string, error) {
+	p.RLock()
+	keys, ok := p.configKeysMap[remoteName+"/"+entity]
+	p.RUnlock()
+	if ok {
+		return keys, nil
+	}
+
+	server, err := p.GetContainerServer(remoteName)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err = serverConfigKeys(server, entity)
+	if err != nil {
+		return nil, err
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	p.configKeysMap[remoteName+"/"+entity] = keys
+	return keys, nil
+}
+
+// planNetworkACL records a network ACL, as "remote/project/name", as
+// planned for creation in a concurrent-safe way.
+func (p *lxdProvider) planNetworkACL(key string) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.plannedNetworkACLs[key] = true
+}
+
+// networkACLPlanned tells whether a network ACL, as "remote/project/name",
+// was planned for creation.
+func (p *lxdProvider) networkACLPlanned(key string) bool {
+	p.RLock()
+	defer p.RUnlock()
+
+	return p.plannedNetworkACLs[key]
+}
+
 // getLXDServerConnectionInfo returns an LXD server's connection info in a
 // concurrent-safe way.
 func getLXDServerConnectionInfo(server lxd.Server) (*lxd.ConnectionInfo, error) {
@@ -693,9 +891,41 @@ func validateLxdRemoteScheme(v interface{}, k string) ([]string, []error) {
 	return nil, nil
 }
 
+// validateLxdRemoteProtocol validates the `lxd_remote.protocol` configuration
+// value at parse time.
+func validateLxdRemoteProtocol(v interface{}, k string) ([]string, []error) {
+	protocol := v.(string)
+	if protocol != "lxd" && protocol != "simplestreams" {
+		return nil, []error{fmt.Errorf("Invalid LXD Remote protocol: %s", protocol)}
+	}
+	return nil, nil
+}
+
+// validateLxdRemoteCertificate validates the `lxd_remote.certificate`
+// configuration value at parse time.
+func validateLxdRemoteCertificate(v interface{}, k string) ([]string, []error) {
+	if block, _ := pem.Decode([]byte(v.(string))); block == nil || block.Type != "CERTIFICATE" {
+		return nil, []error{fmt.Errorf("Invalid LXD Remote certificate: not a PEM encoded certificate")}
+	}
+	return nil, nil
+}
+
+// isImageServer reports whether the remote only serves images,
+// i.e. it is a simplestreams server or a public LXD server.
+func (c terraformLXDConfig) isImageServer() bool {
+	return c.protocol == "simplestreams" || c.public
+}
+
 // determineDaemonAddr helps determine the daemon addr of the remote.
 func determineDaemonAddr(lxdRemote terraformLXDConfig) (string, error) {
 	var daemonAddr string
+
+	// Image servers are often addressed by URL, e.g. a mirror
+	// living under a path of a web server.
+	if strings.HasPrefix(lxdRemote.address, "https://") || strings.HasPrefix(lxdRemote.address, "http://") {
+		return lxdRemote.address, nil
+	}
+
 	if lxdRemote.address != "" {
 		switch lxdRemote.scheme {
 		case "unix", "":
@@ -109,6 +109,22 @@ func TestAccLxdProvider_imageRemotes(t *testing.T) {
 	})
 }
 
+func TestAccLxdProvider_simplestreamsRemote(t *testing.T) {
+	remoteName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccLxdProvider_simplestreamsRemote(remoteName, "https://images.linuxcontainers.org"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("lxd_noop.noop1", "remote", remoteName),
+				),
+			},
+		},
+	})
+}
+
 func TestAccLxdProvider_socketRemote(t *testing.T) {
 	remoteName := strings.ToLower(petname.Generate(2, "-"))
 	socketAddr := "/var/snap/lxd/common/lxd/unix.socket"
@@ -283,6 +299,24 @@ resource "lxd_noop" "noop1" {
 `, remote, socketAddr, remote)
 }
 
+func testAccLxdProvider_simplestreamsRemote(remote, addr string) string {
+	return fmt.Sprintf(`
+provider "lxd" {
+	lxd_remote {
+		name     = "%s"
+		address  = "%s"
+		scheme   = "https"
+		protocol = "simplestreams"
+	}
+}
+
+resource "lxd_noop" "noop1" {
+	name = "noop1"
+	remote = "%s"
+}
+`, remote, addr, remote)
+}
+
 func testAccLxdProvider_lxcConfig1(confDir, remote, addr, port, password string) string {
 	return fmt.Sprintf(`
 provider "lxd" {
@@ -28,6 +28,32 @@ func resourceLxdCachedImage() *schema.Resource {
 				Elem:     &schema.Schema{Type: schema.TypeString},
 			},
 
+			"alias_descriptions": {
+				Type:     schema.TypeMap,
+				Optional: true,
+			},
+
+			"adopt_existing_aliases": {
+				Type:     schema.TypeBool,
+				Default:  false,
+				Optional: true,
+			},
+
+			"expires_at": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Computed:         true,
+				ValidateFunc:     resourceLxdValidateTimestamp,
+				DiffSuppressFunc: suppressTimestampDifferences,
+			},
+
+			"profiles": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
 			"copy_aliases": {
 				Type:     schema.TypeBool,
 				Default:  false,
@@ -41,12 +67,42 @@ func resourceLxdCachedImage() *schema.Resource {
 				Required: true,
 			},
 
+			"expected_fingerprint": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ValidateFunc:  resourceLxdValidateFingerprint,
+				ConflictsWith: []string{"auto_update"},
+			},
+
+			"source_secret": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				Sensitive:     true,
+				ConflictsWith: []string{"architecture", "auto_update", "copy_aliases"},
+			},
+
 			"source_remote": {
 				Type:     schema.TypeString,
 				Required: true,
 				ForceNew: true,
 			},
 
+			"source_project": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"target_project": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"project"},
+				Deprecated:    "Use project instead",
+			},
+
 			"remote": &schema.Schema{
 				Type:     schema.TypeString,
 				ForceNew: true,
@@ -54,13 +110,39 @@ func resourceLxdCachedImage() *schema.Resource {
 				Default:  "",
 			},
 
-			// Computed attributes
+			"project": &schema.Schema{
+				Type:          schema.TypeString,
+				ForceNew:      true,
+				Optional:      true,
+				ConflictsWith: []string{"target_project"},
+			},
 
 			"architecture": {
-				Type:     schema.TypeString,
-				Computed: true,
+				Type:             schema.TypeString,
+				Optional:         true,
+				Computed:         true,
+				ForceNew:         true,
+				ValidateFunc:     resourceLxdValidateArchitecture,
+				DiffSuppressFunc: suppressArchitectureDifferences,
+			},
+
+			"type": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      "container",
+				ValidateFunc: resourceLxdValidateImageType,
+			},
+
+			"auto_update": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				ForceNew: true,
 			},
 
+			// Computed attributes
+
 			"created_at": {
 				Type:     schema.TypeInt,
 				Computed: true,
@@ -84,7 +166,7 @@ func resourceLxdCachedImageCreate(d *schema.ResourceData, meta interface{}) erro
 	p := meta.(*lxdProvider)
 
 	dstName := p.selectRemote(d)
-	dstServer, err := p.GetContainerServer(dstName)
+	dstServer, err := resourceLxdCachedImageServer(d, p)
 	if err != nil {
 		return err
 	}
@@ -95,20 +177,56 @@ func resourceLxdCachedImageCreate(d *schema.ResourceData, meta interface{}) erro
 		return err
 	}
 
+	if project := d.Get("source_project").(string); project != "" {
+		// Only LXD servers have projects, image servers don't.
+		srcServer, ok := imgServer.(lxd.ContainerServer)
+		if !ok {
+			return fmt.Errorf("source_project can't be used with image server %s", srcName)
+		}
+		imgServer = srcServer.UseProject(project)
+	}
+
 	image := d.Get("source_image").(string)
+	imgType := d.Get("type").(string)
+	arch := d.Get("architecture").(string)
+
 	// has the user provided an fingerprint or alias?
-	aliasTarget, _, _ := imgServer.GetImageAlias(image)
-	if aliasTarget != nil {
-		image = aliasTarget.Target
+	if arch != "" {
+		// Pick the alias target matching the requested architecture.
+		targets, _ := imgServer.GetImageAliasArchitectures(imgType, image)
+		if len(targets) > 0 {
+			target, ok := targets[normalizeArchitecture(arch)]
+			if !ok {
+				return fmt.Errorf("Image %s is not available for architecture %s", image, arch)
+			}
+			image = target.Target
+		}
+	} else {
+		aliasTarget, _, _ := imgServer.GetImageAliasType(imgType, image)
+		if aliasTarget != nil {
+			image = aliasTarget.Target
+		}
 	}
 
+	adoptAliases := d.Get("adopt_existing_aliases").(bool)
+	aliasDescriptions := resourceLxdConfigMap(d.Get("alias_descriptions"))
+
+	// Aliases that don't exist yet are created as part of the copy.
+	// Existing aliases are either adopted after the copy or cause
+	// an error, depending on adopt_existing_aliases.
 	aliases := make([]api.ImageAlias, 0)
+	adopted := make([]string, 0)
 	if v, ok := d.GetOk("aliases"); ok {
 		for _, alias := range v.([]interface{}) {
 			// Check image alias doesn't already exist on destination
 			dstAliasTarget, _, _ := dstServer.GetImageAlias(alias.(string))
 			if dstAliasTarget != nil {
-				return fmt.Errorf("Image alias already exists on destination: %s", alias.(string))
+				if !adoptAliases {
+					return fmt.Errorf("Image alias already exists on destination: %s", alias.(string))
+				}
+
+				adopted = append(adopted, alias.(string))
+				continue
 			}
 
 			ia := api.ImageAlias{
@@ -120,21 +238,65 @@ func resourceLxdCachedImageCreate(d *schema.ResourceData, meta interface{}) erro
 	}
 
 	// Get data about remote image, also checks it exists
-	imgInfo, _, err := imgServer.GetImage(image)
-	if err != nil {
-		return err
+	var imgInfo *api.Image
+	secret := d.Get("source_secret").(string)
+	if secret != "" {
+		// A private image on a remote that doesn't trust us can't be
+		// looked up, all there is to go by is its fingerprint.
+		if len(image) != 64 {
+			return fmt.Errorf("source_image must be a full fingerprint when source_secret is set")
+		}
+		imgInfo = &api.Image{Fingerprint: image}
+	} else {
+		imgInfo, _, err = imgServer.GetImage(image)
+		if err != nil {
+			return err
+		}
+	}
+
+	if arch != "" && normalizeArchitecture(imgInfo.Architecture) != normalizeArchitecture(arch) {
+		return fmt.Errorf("Image %s has architecture %s, not %s", image, imgInfo.Architecture, arch)
+	}
+
+	// Protect against the upstream alias being pointed at another image.
+	expected := strings.ToLower(d.Get("expected_fingerprint").(string))
+	if expected != "" && !strings.HasPrefix(imgInfo.Fingerprint, expected) {
+		return fmt.Errorf("Image %s resolved to fingerprint %s, expected %s",
+			d.Get("source_image").(string), imgInfo.Fingerprint, expected)
 	}
 
 	copyAliases := d.Get("copy_aliases").(bool)
+	autoUpdate := d.Get("auto_update").(bool)
+
+	// Keep the real fingerprint, imgInfo may be pointed at the alias below.
+	fingerprint := imgInfo.Fingerprint
+
+	// LXD records what was copied as the update source of the image,
+	// so copy by alias for the server to be able to refresh it later.
+	// An alias resolves to the server's own architecture though, so an
+	// image of another architecture is copied by fingerprint.
+	sourceImage := d.Get("source_image").(string)
+	if autoUpdate && arch == "" && imgInfo.Public && !strings.HasPrefix(fingerprint, sourceImage) {
+		imgInfo.Fingerprint = sourceImage
+	}
 
 	// Execute the copy
 	// Image copy arguments
 	args := lxd.ImageCopyArgs{
-		Aliases: aliases,
-		Public:  false,
+		Aliases:    aliases,
+		AutoUpdate: autoUpdate,
+		Public:     false,
+		Type:       imgType,
 	}
 
-	op, err := dstServer.CopyImage(imgServer, *imgInfo, &args)
+	var op interface {
+		Wait() error
+	}
+	if secret != "" {
+		op, err = resourceLxdCachedImagePullWithSecret(dstServer, imgServer, fingerprint, secret, args)
+	} else {
+		op, err = dstServer.CopyImage(imgServer, *imgInfo, &args)
+	}
 	if err != nil {
 		return err
 	}
@@ -146,9 +308,45 @@ func resourceLxdCachedImageCreate(d *schema.ResourceData, meta interface{}) erro
 	}
 
 	// Image was successfully copied, set resource ID
-	id := newCachedImageID(dstName, imgInfo.Fingerprint)
+	id := newCachedImageID(dstName, fingerprint)
 	d.SetId(id.resourceID())
 
+	// Re-point adopted aliases to the new image and apply any
+	// alias descriptions, which the copy does not carry over.
+	for _, alias := range adopted {
+		err := resourceLxdImageSetAlias(dstServer, alias, id.fingerprint, aliasDescriptions[alias])
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, a := range aliases {
+		if desc, ok := aliasDescriptions[a.Name]; ok {
+			err := resourceLxdImageSetAlias(dstServer, a.Name, id.fingerprint, desc)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// The copy doesn't carry profiles or the expiry date,
+	// so set them on the new image.
+	_, hasProfiles := d.GetOk("profiles")
+	_, hasExpiry := d.GetOk("expires_at")
+	if hasProfiles || hasExpiry {
+		err := resourceLxdImageUpdate(dstServer, id.fingerprint, func(put *api.ImagePut) {
+			if hasProfiles {
+				put.Profiles = resourceLxdImageProfiles(d)
+			}
+			if hasExpiry {
+				put.ExpiresAt = resourceLxdImageExpiresAt(d)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+
 	// store remote aliases that we've copied, so we can filter them out later
 	copied := make([]string, 0)
 	if copyAliases {
@@ -161,18 +359,53 @@ func resourceLxdCachedImageCreate(d *schema.ResourceData, meta interface{}) erro
 	return resourceLxdCachedImageRead(d, meta)
 }
 
+// resourceLxdCachedImagePullWithSecret has the destination pull a private
+// image using a secret generated on the source, so that neither the
+// destination nor Terraform have to be trusted by the source.
+func resourceLxdCachedImagePullWithSecret(dst lxd.ContainerServer, src lxd.ImageServer, fingerprint, secret string, args lxd.ImageCopyArgs) (lxd.Operation, error) {
+	info, err := src.GetConnectionInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(info.Addresses) == 0 {
+		return nil, fmt.Errorf("Unable to determine the address of the source remote")
+	}
+
+	req := api.ImagesPost{
+		Source: &api.ImagesPostSource{
+			ImageSource: api.ImageSource{
+				Certificate: info.Certificate,
+				Protocol:    info.Protocol,
+				Server:      info.Addresses[0],
+			},
+			Fingerprint: fingerprint,
+			Secret:      secret,
+			Mode:        "pull",
+			Type:        "image",
+			Project:     info.Project,
+		},
+		Aliases: args.Aliases,
+	}
+	req.Public = args.Public
+
+	log.Printf("[DEBUG] Pulling image %s from %s with a secret", fingerprint, info.Addresses[0])
+	return dst.CreateImage(req, nil)
+}
+
 func resourceLxdCachedImageCopyProgressHandler(prog string) {
 	log.Println("[DEBUG] - image copy progress: ", prog)
 }
 
 func resourceLxdCachedImageUpdate(d *schema.ResourceData, meta interface{}) error {
 	p := meta.(*lxdProvider)
-	remote := p.selectRemote(d)
-	server, err := p.GetContainerServer(remote)
+	server, err := resourceLxdCachedImageServer(d, p)
 	if err != nil {
 		return err
 	}
 	id := newCachedImageIDFromResourceID(d.Id())
+	adoptAliases := d.Get("adopt_existing_aliases").(bool)
+	aliasDescriptions := resourceLxdConfigMap(d.Get("alias_descriptions"))
 
 	if d.HasChange("aliases") {
 		old, new := d.GetChange("aliases")
@@ -193,24 +426,51 @@ func resourceLxdCachedImageUpdate(d *schema.ResourceData, meta interface{}) erro
 		for _, a := range aliasesToAdd.List() {
 			alias := a.(string)
 
-			req := api.ImageAliasesPost{}
-			req.Name = alias
-			req.Target = id.fingerprint
+			existing, _, _ := server.GetImageAlias(alias)
+			if existing != nil && !adoptAliases {
+				return fmt.Errorf("Image alias already exists on destination: %s", alias)
+			}
 
-			err := server.CreateImageAlias(req)
+			err := resourceLxdImageSetAlias(server, alias, id.fingerprint, aliasDescriptions[alias])
 			if err != nil {
 				return err
 			}
 		}
 	}
 
+	if d.HasChange("alias_descriptions") {
+		old, _ := d.GetChange("alias_descriptions")
+		oldDescriptions := resourceLxdConfigMap(old)
+
+		for _, a := range d.Get("aliases").([]interface{}) {
+			alias := a.(string)
+			if oldDescriptions[alias] == aliasDescriptions[alias] {
+				continue
+			}
+
+			err := resourceLxdImageSetAlias(server, alias, id.fingerprint, aliasDescriptions[alias])
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if d.HasChange("profiles") || d.HasChange("expires_at") {
+		err := resourceLxdImageUpdate(server, id.fingerprint, func(put *api.ImagePut) {
+			put.Profiles = resourceLxdImageProfiles(d)
+			put.ExpiresAt = resourceLxdImageExpiresAt(d)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
 func resourceLxdCachedImageDelete(d *schema.ResourceData, meta interface{}) error {
 	p := meta.(*lxdProvider)
-	remote := p.selectRemote(d)
-	server, err := p.GetContainerServer(remote)
+	server, err := resourceLxdCachedImageServer(d, p)
 	if err != nil {
 		return err
 	}
@@ -227,8 +487,7 @@ func resourceLxdCachedImageDelete(d *schema.ResourceData, meta interface{}) erro
 
 func resourceLxdCachedImageExists(d *schema.ResourceData, meta interface{}) (bool, error) {
 	p := meta.(*lxdProvider)
-	remote := p.selectRemote(d)
-	server, err := p.GetContainerServer(remote)
+	server, err := resourceLxdCachedImageServer(d, p)
 	if err != nil {
 		return false, err
 	}
@@ -238,6 +497,10 @@ func resourceLxdCachedImageExists(d *schema.ResourceData, meta interface{}) (boo
 	_, _, err = server.GetImage(id.fingerprint)
 	if err != nil {
 		if err.Error() == "not found" {
+			if d.Get("auto_update").(bool) {
+				img, err := resourceLxdCachedImageFindUpdated(server, d)
+				return img != nil, err
+			}
 			return false, nil
 		}
 		return false, err
@@ -248,8 +511,7 @@ func resourceLxdCachedImageExists(d *schema.ResourceData, meta interface{}) (boo
 
 func resourceLxdCachedImageRead(d *schema.ResourceData, meta interface{}) error {
 	p := meta.(*lxdProvider)
-	remote := p.selectRemote(d)
-	server, err := p.GetImageServer(remote)
+	server, err := resourceLxdCachedImageServer(d, p)
 	if err != nil {
 		return err
 	}
@@ -258,17 +520,36 @@ func resourceLxdCachedImageRead(d *schema.ResourceData, meta interface{}) error
 
 	img, _, err := server.GetImage(id.fingerprint)
 	if err != nil {
-		if err.Error() == "not found" {
+		if err.Error() != "not found" {
+			return err
+		}
+
+		if d.Get("auto_update").(bool) {
+			img, err = resourceLxdCachedImageFindUpdated(server, d)
+			if err != nil {
+				return err
+			}
+		}
+
+		if img == nil {
 			d.SetId("")
 			return nil
 		}
-		return err
+
+		// LXD has refreshed the image, track the new fingerprint.
+		log.Printf("[DEBUG] Image %s was updated to %s", id.fingerprint, img.Fingerprint)
+		id = newCachedImageID(id.remote, img.Fingerprint)
+		d.SetId(id.resourceID())
 	}
 
 	d.Set("fingerprint", id.fingerprint)
 	d.Set("source_remote", d.Get("source_remote"))
 	d.Set("copy_aliases", d.Get("copy_aliases"))
 	d.Set("architecture", img.Architecture)
+	d.Set("type", img.Type)
+	d.Set("auto_update", img.AutoUpdate)
+	d.Set("profiles", img.Profiles)
+	d.Set("expires_at", resourceLxdImageFormatTime(img.ExpiresAt))
 	d.Set("created_at", img.CreatedAt.Unix())
 
 	// Read aliases from img and set in resource data
@@ -277,6 +558,7 @@ func resourceLxdCachedImageRead(d *schema.ResourceData, meta interface{}) error
 	// in the Terraform config.
 	// These need to be filtered out here so not to cause a diff.
 	var aliases []string
+	aliasDescriptions := make(map[string]string)
 	copiedAliases := d.Get("copied_aliases").([]interface{})
 	configAliases := d.Get("aliases").([]interface{})
 	copiedSet := schema.NewSet(schema.HashString, copiedAliases)
@@ -285,15 +567,86 @@ func resourceLxdCachedImageRead(d *schema.ResourceData, meta interface{}) error
 	for _, a := range img.Aliases {
 		if configSet.Contains(a.Name) || !copiedSet.Contains(a.Name) {
 			aliases = append(aliases, a.Name)
+			if a.Description != "" {
+				aliasDescriptions[a.Name] = a.Description
+			}
 		} else {
 			log.Println("[DEBUG] filtered alias ", a)
 		}
 	}
 	d.Set("aliases", aliases)
+	d.Set("alias_descriptions", aliasDescriptions)
 
 	return nil
 }
 
+// resourceLxdCachedImageServer returns a client for the remote the image
+// is cached on, scoped to its project. target_project is the deprecated
+// name of project.
+func resourceLxdCachedImageServer(d *schema.ResourceData, p *lxdProvider) (lxd.ContainerServer, error) {
+	server, err := p.selectServer(d)
+	if err != nil {
+		return nil, err
+	}
+
+	if project := d.Get("target_project").(string); project != "" {
+		server = server.UseProject(project)
+	}
+
+	return server, nil
+}
+
+// resourceLxdCachedImageFindUpdated looks for the image that replaced an
+// auto-updated image. LXD deletes the old image once it has been refreshed
+// and moves its aliases over to the new one. Images of the same alias for
+// other architectures or types, cached by other resources, are skipped.
+func resourceLxdCachedImageFindUpdated(server lxd.ImageServer, d *schema.ResourceData) (*api.Image, error) {
+	for _, a := range d.Get("aliases").([]interface{}) {
+		alias, _, err := server.GetImageAlias(a.(string))
+		if err != nil {
+			continue
+		}
+
+		img, _, err := server.GetImage(alias.Target)
+		if err != nil {
+			return nil, err
+		}
+		if resourceLxdCachedImageMatches(d, img) {
+			return img, nil
+		}
+	}
+
+	images, err := server.GetImages()
+	if err != nil {
+		return nil, err
+	}
+
+	source := d.Get("source_image").(string)
+	for _, img := range images {
+		if img.AutoUpdate && img.UpdateSource != nil && img.UpdateSource.Alias == source && resourceLxdCachedImageMatches(d, &img) {
+			return &img, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// resourceLxdCachedImageMatches tells whether an image has the
+// architecture and type of the cached image.
+func resourceLxdCachedImageMatches(d *schema.ResourceData, img *api.Image) bool {
+	if arch := d.Get("architecture").(string); arch != "" && normalizeArchitecture(img.Architecture) != normalizeArchitecture(arch) {
+		return false
+	}
+
+	// Images from before types existed are containers.
+	imgType := img.Type
+	if imgType == "" {
+		imgType = "container"
+	}
+
+	return imgType == d.Get("type").(string)
+}
+
 type cachedImageID struct {
 	remote      string
 	fingerprint string
@@ -165,6 +165,142 @@ func TestAccCachedImage_addRemoveAlias(t *testing.T) {
 	})
 }
 
+func TestAccCachedImage_adoptExistingAlias(t *testing.T) {
+	var img, lost api.Image
+	alias := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccCachedImage_aliasExists1(alias),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCachedImageExists(t, "lxd_cached_image.exists1", &img),
+					testAccCachedImageContainsAlias(&img, alias),
+				),
+			},
+			resource.TestStep{
+				// The alias moves from exists1 to adopt1.
+				Config: testAccCachedImage_adoptAlias(alias),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCachedImageExists(t, "lxd_cached_image.adopt1", &img),
+					resourceAccCachedImageCheckAttributes("lxd_cached_image.adopt1", &img),
+					testAccCachedImageContainsAlias(&img, alias),
+					resource.TestCheckResourceAttr("lxd_cached_image.adopt1", "alias_descriptions."+alias, "adopted"),
+					testAccCachedImageExists(t, "lxd_cached_image.exists1", &lost),
+					testAccCachedImageNotContainsAlias(&lost, alias),
+				),
+			},
+		},
+	})
+}
+
+func TestAccCachedImage_architecture(t *testing.T) {
+	var img api.Image
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccCachedImage_architecture("i386"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCachedImageExists(t, "lxd_cached_image.img5", &img),
+					resourceAccCachedImageCheckAttributes("lxd_cached_image.img5", &img),
+					resource.TestCheckResourceAttr("lxd_cached_image.img5", "architecture", "i686"),
+					resource.TestCheckResourceAttr("lxd_cached_image.img5", "type", "container"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccCachedImage_autoUpdate(t *testing.T) {
+	var img api.Image
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccCachedImage_autoUpdate(),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCachedImageExists(t, "lxd_cached_image.img6", &img),
+					resourceAccCachedImageCheckAttributes("lxd_cached_image.img6", &img),
+					resource.TestCheckResourceAttr("lxd_cached_image.img6", "auto_update", "true"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccCachedImage_expectedFingerprint(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccCachedImage_expectedFingerprint("0000"),
+				ExpectError: regexp.MustCompile(`expected 0000`),
+			},
+		},
+	})
+}
+
+func TestAccCachedImage_profiles(t *testing.T) {
+	var img api.Image
+	profileName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccCachedImage_profiles(profileName, `"default"`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCachedImageExists(t, "lxd_cached_image.img8", &img),
+					resource.TestCheckResourceAttr("lxd_cached_image.img8", "profiles.#", "1"),
+					resource.TestCheckResourceAttr("lxd_cached_image.img8", "profiles.0", "default"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccCachedImage_profiles(profileName, `"default", "${lxd_profile.profile1.name}"`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCachedImageExists(t, "lxd_cached_image.img8", &img),
+					resource.TestCheckResourceAttr("lxd_cached_image.img8", "profiles.#", "2"),
+					resource.TestCheckResourceAttr("lxd_cached_image.img8", "profiles.1", profileName),
+				),
+			},
+		},
+	})
+}
+
+func TestAccCachedImage_expiresAt(t *testing.T) {
+	var img api.Image
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccCachedImage_expiresAt("2030-01-01T00:00:00Z"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCachedImageExists(t, "lxd_cached_image.img9", &img),
+					resource.TestCheckResourceAttr("lxd_cached_image.img9", "expires_at", "2030-01-01T00:00:00Z"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccCachedImage_expiresAt("2031-06-01T12:00:00Z"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCachedImageExists(t, "lxd_cached_image.img9", &img),
+					resource.TestCheckResourceAttr("lxd_cached_image.img9", "expires_at", "2031-06-01T12:00:00Z"),
+				),
+			},
+		},
+	})
+}
+
 func testAccCachedImageExists(t *testing.T, n string, image *api.Image) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rs, ok := s.RootModule().Resources[n]
@@ -215,6 +351,18 @@ func testAccCachedImageContainsAlias(img *api.Image, alias string) resource.Test
 	}
 }
 
+func testAccCachedImageNotContainsAlias(img *api.Image, alias string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		for _, a := range img.Aliases {
+			if a.Name == alias {
+				return fmt.Errorf("Alias still found: %s", alias)
+			}
+		}
+
+		return nil
+	}
+}
+
 func resourceAccCachedImageCheckAttributes(n string, img *api.Image) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rs, ok := s.RootModule().Resources[n]
@@ -294,6 +442,37 @@ resource "lxd_cached_image" "exists2" {
 	`, alias, alias)
 }
 
+func testAccCachedImage_adoptAlias(alias string) string {
+	return fmt.Sprintf(`
+resource "lxd_cached_image" "exists1" {
+  source_remote = "images"
+  source_image = "alpine/3.9/i386"
+
+  aliases = ["%s"]
+  copy_aliases = false
+
+  # The alias is adopted by adopt1.
+  lifecycle {
+    ignore_changes = ["aliases"]
+  }
+}
+
+resource "lxd_cached_image" "adopt1" {
+  source_remote = "images"
+  source_image = "alpine/3.9/amd64"
+
+  aliases = ["%s"]
+  copy_aliases = false
+  adopt_existing_aliases = true
+  depends_on = ["lxd_cached_image.exists1"]
+
+  alias_descriptions {
+    %s = "adopted"
+  }
+}
+	`, alias, alias, alias)
+}
+
 func testAccCachedImage_aliases2(aliases ...string) string {
 	return fmt.Sprintf(`
 resource "lxd_cached_image" "img3" {
@@ -317,3 +496,57 @@ resource "lxd_cached_image" "img4" {
 }
 	`)
 }
+
+func testAccCachedImage_architecture(arch string) string {
+	return fmt.Sprintf(`
+resource "lxd_cached_image" "img5" {
+  source_remote = "images"
+  source_image = "alpine/3.9"
+  architecture = "%s"
+}
+	`, arch)
+}
+
+func testAccCachedImage_autoUpdate() string {
+	return fmt.Sprintf(`
+resource "lxd_cached_image" "img6" {
+  source_remote = "images"
+  source_image = "alpine/3.9"
+  auto_update = true
+}
+	`)
+}
+
+func testAccCachedImage_expectedFingerprint(fingerprint string) string {
+	return fmt.Sprintf(`
+resource "lxd_cached_image" "img7" {
+  source_remote = "images"
+  source_image = "alpine/3.9/amd64"
+  expected_fingerprint = "%s"
+}
+	`, fingerprint)
+}
+
+func testAccCachedImage_profiles(profileName, profiles string) string {
+	return fmt.Sprintf(`
+resource "lxd_profile" "profile1" {
+  name = "%s"
+}
+
+resource "lxd_cached_image" "img8" {
+  source_remote = "images"
+  source_image = "alpine/3.9/amd64"
+  profiles = [%s]
+}
+	`, profileName, profiles)
+}
+
+func testAccCachedImage_expiresAt(expiresAt string) string {
+	return fmt.Sprintf(`
+resource "lxd_cached_image" "img9" {
+  source_remote = "images"
+  source_image = "alpine/3.9/amd64"
+  expires_at = "%s"
+}
+	`, expiresAt)
+}
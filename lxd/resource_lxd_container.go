@@ -40,6 +40,12 @@ func resourceLxdContainer() *schema.Resource {
 				Default:  "",
 			},
 
+			"project": &schema.Schema{
+				Type:     schema.TypeString,
+				ForceNew: true,
+				Optional: true,
+			},
+
 			"image": &schema.Schema{
 				Type:             schema.TypeString,
 				ForceNew:         true,
@@ -183,7 +189,7 @@ func resourceLxdContainerCreate(d *schema.ResourceData, meta interface{}) error
 
 	p := meta.(*lxdProvider)
 	remote := p.selectRemote(d)
-	server, err := p.GetContainerServer(remote)
+	server, err := p.selectServer(d)
 	if err != nil {
 		return err
 	}
@@ -359,8 +365,7 @@ func resourceLxdContainerCreate(d *schema.ResourceData, meta interface{}) error
 
 func resourceLxdContainerRead(d *schema.ResourceData, meta interface{}) error {
 	p := meta.(*lxdProvider)
-	remote := p.selectRemote(d)
-	server, err := p.GetContainerServer(remote)
+	server, err := p.selectServer(d)
 	if err != nil {
 		return err
 	}
@@ -445,9 +450,15 @@ func resourceLxdContainerRead(d *schema.ResourceData, meta interface{}) error {
 	// Set the profiles used by the container
 	d.Set("profiles", container.Profiles)
 
-	// Set the devices used by the container
+	// Set the devices used by the container, leaving out the custom
+	// volumes attached by lxd_volume_attach
+	declared := resourceLxdDevices(d.Get("device"))
 	devices := make([]map[string]interface{}, 0)
 	for name, lxddevice := range container.Devices {
+		if _, ok := declared[name]; !ok && isAttachedVolume(lxddevice) {
+			continue
+		}
+
 		device := make(map[string]interface{})
 		device["name"] = name
 		delete(lxddevice, "name")
@@ -463,8 +474,7 @@ func resourceLxdContainerRead(d *schema.ResourceData, meta interface{}) error {
 
 func resourceLxdContainerUpdate(d *schema.ResourceData, meta interface{}) error {
 	p := meta.(*lxdProvider)
-	remote := p.selectRemote(d)
-	server, err := p.GetContainerServer(remote)
+	server, err := p.selectServer(d)
 	if err != nil {
 		return err
 	}
@@ -582,8 +592,7 @@ func resourceLxdContainerUpdate(d *schema.ResourceData, meta interface{}) error
 
 func resourceLxdContainerDelete(d *schema.ResourceData, meta interface{}) (err error) {
 	p := meta.(*lxdProvider)
-	remote := p.selectRemote(d)
-	server, err := p.GetContainerServer(remote)
+	server, err := p.selectServer(d)
 	if err != nil {
 		return err
 	}
@@ -639,8 +648,7 @@ func resourceLxdContainerDelete(d *schema.ResourceData, meta interface{}) (err e
 
 func resourceLxdContainerExists(d *schema.ResourceData, meta interface{}) (exists bool, err error) {
 	p := meta.(*lxdProvider)
-	remote := p.selectRemote(d)
-	server, err := p.GetContainerServer(remote)
+	server, err := p.selectServer(d)
 	if err != nil {
 		return false, err
 	}
@@ -675,7 +683,7 @@ func resourceLxdContainerImport(d *schema.ResourceData, meta interface{}) ([]*sc
 		d.Set("remote", remote)
 	}
 
-	server, err := p.GetContainerServer(p.selectRemote(d))
+	server, err := p.selectServer(d)
 	if err != nil {
 		return nil, err
 	}
@@ -70,6 +70,12 @@ func resourceLxdContainerFile() *schema.Resource {
 				ForceNew: true,
 				Optional: true,
 			},
+
+			"project": &schema.Schema{
+				Type:     schema.TypeString,
+				ForceNew: true,
+				Optional: true,
+			},
 		},
 	}
 }
@@ -77,7 +83,7 @@ func resourceLxdContainerFile() *schema.Resource {
 func resourceLxdContainerFileCreate(d *schema.ResourceData, meta interface{}) error {
 	p := meta.(*lxdProvider)
 	remote := p.selectRemote(d)
-	server, err := p.GetContainerServer(remote)
+	server, err := p.selectServer(d)
 	if err != nil {
 		return err
 	}
@@ -112,10 +118,9 @@ func resourceLxdContainerFileCreate(d *schema.ResourceData, meta interface{}) er
 func resourceLxdContainerFileRead(d *schema.ResourceData, meta interface{}) error {
 	p := meta.(*lxdProvider)
 	v, targetFile := newFileIDFromResourceID(d.Id())
-	remote, containerName, err := p.LXDConfig.ParseRemote(v)
+	_, containerName, err := p.LXDConfig.ParseRemote(v)
 
-	remote = p.selectRemote(d)
-	server, err := p.GetContainerServer(remote)
+	server, err := p.selectServer(d)
 	if err != nil {
 		return err
 	}
@@ -144,8 +149,8 @@ func resourceLxdContainerFileRead(d *schema.ResourceData, meta interface{}) erro
 func resourceLxdContainerFileDelete(d *schema.ResourceData, meta interface{}) error {
 	p := meta.(*lxdProvider)
 	v, targetFile := newFileIDFromResourceID(d.Id())
-	remote, containerName, err := p.LXDConfig.ParseRemote(v)
-	server, err := p.GetContainerServer(remote)
+	_, containerName, err := p.LXDConfig.ParseRemote(v)
+	server, err := p.selectServer(d)
 	if err != nil {
 		return err
 	}
@@ -166,13 +171,13 @@ func resourceLxdContainerFileDelete(d *schema.ResourceData, meta interface{}) er
 func resourceLxdContainerFileExists(d *schema.ResourceData, meta interface{}) (exists bool, err error) {
 	p := meta.(*lxdProvider)
 	v, targetFile := newFileIDFromResourceID(d.Id())
-	remote, containerName, err := p.LXDConfig.ParseRemote(v)
+	_, containerName, err := p.LXDConfig.ParseRemote(v)
 	if err != nil {
 		err = fmt.Errorf("unable to determine remote: %s", err)
 		return
 	}
 
-	server, err := p.GetContainerServer(remote)
+	server, err := p.selectServer(d)
 	if err != nil {
 		return
 	}
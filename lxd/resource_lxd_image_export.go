@@ -0,0 +1,314 @@
+package lxd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	lxd "github.com/lxc/lxd/client"
+	"github.com/mitchellh/go-homedir"
+)
+
+func resourceLxdImageExport() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceLxdImageExportCreate,
+		Delete: resourceLxdImageExportDelete,
+		Exists: resourceLxdImageExportExists,
+		Read:   resourceLxdImageExportRead,
+
+		Schema: map[string]*schema.Schema{
+			"image": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"output_directory": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"format": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      "any",
+				ValidateFunc: resourceLxdValidateImageExportFormat,
+			},
+
+			"remote": {
+				Type:     schema.TypeString,
+				ForceNew: true,
+				Optional: true,
+				Default:  "",
+			},
+
+			"project": {
+				Type:     schema.TypeString,
+				ForceNew: true,
+				Optional: true,
+			},
+
+			// Computed attributes
+
+			"fingerprint": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"meta_file": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"meta_sha256": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"rootfs_file": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"rootfs_sha256": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceLxdImageExportCreate(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectImageServer(d)
+	if err != nil {
+		return err
+	}
+
+	image := d.Get("image").(string)
+	// has the user provided an fingerprint or alias?
+	aliasTarget, _, _ := server.GetImageAlias(image)
+	if aliasTarget != nil {
+		image = aliasTarget.Target
+	}
+
+	img, _, err := server.GetImage(image)
+	if err != nil {
+		return err
+	}
+
+	outDir, err := homedir.Expand(d.Get("output_directory").(string))
+	if err != nil {
+		return fmt.Errorf("unable to determine output directory: %s", err)
+	}
+
+	if err := os.MkdirAll(outDir, 0750); err != nil {
+		return fmt.Errorf("Could not create output directory: %s", err)
+	}
+
+	metaTmp := filepath.Join(outDir, img.Fingerprint+".meta.tmp")
+	rootfsTmp := filepath.Join(outDir, img.Fingerprint+".root.tmp")
+	metaPath, rootfsPath := "", ""
+
+	// Nothing is left behind when the export fails.
+	exported := false
+	defer func() {
+		if !exported {
+			for _, path := range []string{metaTmp, rootfsTmp, metaPath, rootfsPath} {
+				if path != "" {
+					os.Remove(path)
+				}
+			}
+		}
+	}()
+
+	metaFile, err := os.Create(metaTmp)
+	if err != nil {
+		return err
+	}
+	defer metaFile.Close()
+
+	rootfsFile, err := os.Create(rootfsTmp)
+	if err != nil {
+		return err
+	}
+	defer rootfsFile.Close()
+
+	req := lxd.ImageFileRequest{
+		MetaFile:   metaFile,
+		RootfsFile: rootfsFile,
+	}
+
+	log.Printf("[DEBUG] Exporting image %s to %s", img.Fingerprint, outDir)
+	resp, err := server.GetImageFile(img.Fingerprint, req)
+	if err != nil {
+		return fmt.Errorf("Unable to export image %s: %s", img.Fingerprint, err)
+	}
+
+	metaFile.Close()
+	rootfsFile.Close()
+
+	// LXD exports images the way they are stored, unified tarballs have
+	// no separate rootfs.
+	unified := resp.RootfsSize == 0
+	switch format := d.Get("format").(string); {
+	case format == "unified" && !unified:
+		return fmt.Errorf("Image %s is a split image, it can't be exported as a unified tarball", img.Fingerprint)
+	case format == "split" && unified:
+		return fmt.Errorf("Image %s is a unified tarball, it can't be exported as a split image", img.Fingerprint)
+	}
+
+	if unified {
+		os.Remove(rootfsTmp)
+	} else {
+		rootfsPath, err = resourceLxdImageExportFilePath(outDir, resp.RootfsName, img.Fingerprint+".root", rootfsTmp)
+		if err != nil {
+			return err
+		}
+		if err := os.Rename(rootfsTmp, rootfsPath); err != nil {
+			rootfsPath = ""
+			return err
+		}
+	}
+
+	metaPath, err = resourceLxdImageExportFilePath(outDir, resp.MetaName, img.Fingerprint, metaTmp)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(metaTmp, metaPath); err != nil {
+		metaPath = ""
+		return err
+	}
+
+	exported = true
+	d.SetId(img.Fingerprint)
+	d.Set("fingerprint", img.Fingerprint)
+	d.Set("meta_file", metaPath)
+	d.Set("rootfs_file", rootfsPath)
+
+	return resourceLxdImageExportRead(d, meta)
+}
+
+func resourceLxdImageExportRead(d *schema.ResourceData, meta interface{}) error {
+	metaSum, err := resourceLxdImageExportChecksum(d.Get("meta_file").(string))
+	if err != nil {
+		if os.IsNotExist(err) {
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	rootfsSum, err := resourceLxdImageExportChecksum(d.Get("rootfs_file").(string))
+	if err != nil {
+		if os.IsNotExist(err) {
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	d.Set("meta_sha256", metaSum)
+	d.Set("rootfs_sha256", rootfsSum)
+
+	return nil
+}
+
+func resourceLxdImageExportDelete(d *schema.ResourceData, meta interface{}) error {
+	for _, k := range []string{"meta_file", "rootfs_file"} {
+		path := d.Get(k).(string)
+		if path == "" {
+			continue
+		}
+
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("Unable to remove exported file %s: %s", path, err)
+		}
+	}
+
+	return nil
+}
+
+func resourceLxdImageExportExists(d *schema.ResourceData, meta interface{}) (bool, error) {
+	if _, err := os.Stat(d.Get("meta_file").(string)); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// resourceLxdImageExportFilePath returns the path to give an exported file,
+// the name LXD sent it with or, when it sent none, the name of the image
+// followed by an extension matching the content of the file.
+func resourceLxdImageExportFilePath(outDir, name, fallback, tmpPath string) (string, error) {
+	if name = filepath.Base(name); name != "" && name != "." && name != "/" {
+		return filepath.Join(outDir, name), nil
+	}
+
+	f, err := os.Open(tmpPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	magic := make([]byte, 6)
+	n, _ := io.ReadFull(f, magic)
+	magic = magic[:n]
+
+	ext := ".tar"
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		ext = ".tar.gz"
+	case bytes.HasPrefix(magic, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}):
+		ext = ".tar.xz"
+	case bytes.HasPrefix(magic, []byte("hsqs")):
+		ext = ".squashfs"
+	case bytes.HasPrefix(magic, []byte("QFI")):
+		ext = ".qcow2"
+	}
+
+	return filepath.Join(outDir, fallback+ext), nil
+}
+
+// resourceLxdValidateImageExportFormat validates the format of an
+// image export.
+func resourceLxdValidateImageExportFormat(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	if value != "any" && value != "unified" && value != "split" {
+		errors = append(errors, fmt.Errorf(
+			"Only any, unified and split are supported values for '%s'", k))
+	}
+
+	return
+}
+
+// resourceLxdImageExportChecksum returns the hex encoded sha256 of a file.
+// An empty path yields an empty checksum.
+func resourceLxdImageExportChecksum(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
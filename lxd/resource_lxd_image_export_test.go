@@ -0,0 +1,110 @@
+package lxd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccImageExport_basic(t *testing.T) {
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "lxd-image-export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccImageExport_basic(tmpDir),
+				Check: resource.ComposeTestCheckFunc(
+					testAccImageExportFileExists("lxd_image_export.export1"),
+					resource.TestCheckResourceAttrPair(
+						"lxd_image_export.export1", "fingerprint",
+						"lxd_cached_image.img1", "fingerprint"),
+					resource.TestCheckResourceAttrSet("lxd_image_export.export1", "meta_sha256"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccImageExport_format(t *testing.T) {
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "lxd-image-export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				// The images remote serves split images.
+				Config: testAccImageExport_format(tmpDir, "split"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccImageExportFileExists("lxd_image_export.export1"),
+					resource.TestCheckResourceAttrSet("lxd_image_export.export1", "rootfs_file"),
+					resource.TestCheckResourceAttrSet("lxd_image_export.export1", "rootfs_sha256"),
+				),
+			},
+			resource.TestStep{
+				Config:      testAccImageExport_format(tmpDir, "unified"),
+				ExpectError: regexp.MustCompile(`.*it can't be exported as a unified tarball.*`),
+			},
+		},
+	})
+}
+
+func testAccImageExportFileExists(n string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found in state: %s", n)
+		}
+
+		metaFile := rs.Primary.Attributes["meta_file"]
+		if _, err := os.Stat(metaFile); err != nil {
+			return fmt.Errorf("Exported file not found: %s", metaFile)
+		}
+
+		return nil
+	}
+}
+
+func testAccImageExport_basic(dir string) string {
+	return fmt.Sprintf(`
+resource "lxd_cached_image" "img1" {
+  source_remote = "images"
+  source_image = "alpine/3.9"
+}
+
+resource "lxd_image_export" "export1" {
+  image = "${lxd_cached_image.img1.fingerprint}"
+  output_directory = "%s"
+}
+	`, dir)
+}
+
+func testAccImageExport_format(dir, format string) string {
+	return fmt.Sprintf(`
+resource "lxd_cached_image" "img1" {
+  source_remote = "images"
+  source_image = "alpine/3.9"
+}
+
+resource "lxd_image_export" "export1" {
+  image = "${lxd_cached_image.img1.fingerprint}"
+  output_directory = "%s"
+  format = "%s"
+}
+	`, dir, format)
+}
@@ -0,0 +1,228 @@
+package lxd
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	lxd "github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/mitchellh/go-homedir"
+)
+
+func resourceLxdImageFromFile() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceLxdImageFromFileCreate,
+		Update: resourceLxdLocalImageUpdate,
+		Delete: resourceLxdLocalImageDelete,
+		Exists: resourceLxdLocalImageExists,
+		Read:   resourceLxdLocalImageRead,
+
+		Schema: map[string]*schema.Schema{
+			"meta_file": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"rootfs_file": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"type": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      "container",
+				ValidateFunc: resourceLxdValidateImageType,
+			},
+
+			"aliases": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
+			"properties": {
+				Type:     schema.TypeMap,
+				Optional: true,
+			},
+
+			"expires_at": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Computed:         true,
+				ValidateFunc:     resourceLxdValidateTimestamp,
+				DiffSuppressFunc: suppressTimestampDifferences,
+			},
+
+			"profiles": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
+			"public": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"remote": {
+				Type:     schema.TypeString,
+				ForceNew: true,
+				Optional: true,
+				Default:  "",
+			},
+
+			"project": {
+				Type:     schema.TypeString,
+				ForceNew: true,
+				Optional: true,
+			},
+
+			// Computed attributes
+
+			"architecture": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"created_at": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"fingerprint": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceLxdImageFromFileCreate(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	metaPath, err := homedir.Expand(d.Get("meta_file").(string))
+	if err != nil {
+		return fmt.Errorf("unable to determine meta file path: %s", err)
+	}
+
+	rootfsPath := ""
+	if v := d.Get("rootfs_file").(string); v != "" {
+		rootfsPath, err = homedir.Expand(v)
+		if err != nil {
+			return fmt.Errorf("unable to determine rootfs file path: %s", err)
+		}
+	}
+
+	return resourceLxdImageFromFileImport(d, meta, server, metaPath, rootfsPath)
+}
+
+// resourceLxdImageFromFileImport uploads the given image files to the
+// server, verifies the resulting fingerprint and sets the resource ID.
+// It is shared by the resources that import images from local files.
+func resourceLxdImageFromFileImport(d *schema.ResourceData, meta interface{}, server lxd.ContainerServer, metaPath, rootfsPath string) error {
+	// The fingerprint of an image is the sha256 of the metadata
+	// tarball followed by the rootfs, if there is one.
+	fingerprint, err := resourceLxdImageFromFileFingerprint(metaPath, rootfsPath)
+	if err != nil {
+		return err
+	}
+
+	aliases, err := resourceLxdImageNewAliases(d, server)
+	if err != nil {
+		return err
+	}
+
+	metaFile, err := os.Open(metaPath)
+	if err != nil {
+		return fmt.Errorf("unable to read meta file: %s", err)
+	}
+	defer metaFile.Close()
+
+	args := lxd.ImageCreateArgs{
+		MetaFile: metaFile,
+		MetaName: filepath.Base(metaPath),
+		Type:     d.Get("type").(string),
+	}
+
+	if rootfsPath != "" {
+		rootfsFile, err := os.Open(rootfsPath)
+		if err != nil {
+			return fmt.Errorf("unable to read rootfs file: %s", err)
+		}
+		defer rootfsFile.Close()
+
+		args.RootfsFile = rootfsFile
+		args.RootfsName = filepath.Base(rootfsPath)
+	}
+
+	req := api.ImagesPost{}
+	req.Aliases = aliases
+	req.Public = d.Get("public").(bool)
+	req.Properties = resourceLxdConfigMap(d.Get("properties"))
+	req.Profiles = resourceLxdImageProfiles(d)
+	req.ExpiresAt = resourceLxdImageExpiresAt(d)
+
+	log.Printf("[DEBUG] Importing image %s from %s", fingerprint, metaPath)
+	op, err := server.CreateImage(req, &args)
+	if err != nil {
+		return err
+	}
+
+	if err := op.Wait(); err != nil {
+		return fmt.Errorf("Failed to import image from %s: %s", metaPath, err)
+	}
+
+	opAPI := op.Get()
+	if v, ok := opAPI.Metadata["fingerprint"].(string); ok && v != fingerprint {
+		// The server has an image we didn't expect, don't leave it behind.
+		if op, err := server.DeleteImage(v); err == nil {
+			op.Wait()
+		}
+
+		return fmt.Errorf("Imported image fingerprint %s does not match local fingerprint %s", v, fingerprint)
+	}
+
+	d.SetId(fingerprint)
+
+	return resourceLxdLocalImageRead(d, meta)
+}
+
+// resourceLxdImageFromFileFingerprint computes the fingerprint LXD will
+// assign to an image made of the given files.
+func resourceLxdImageFromFileFingerprint(paths ...string) (string, error) {
+	h := sha256.New()
+
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return "", fmt.Errorf("unable to read image file: %s", err)
+		}
+
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", fmt.Errorf("unable to read image file %s: %s", path, err)
+		}
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
@@ -0,0 +1,53 @@
+package lxd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+func TestAccImageFromFile_basic(t *testing.T) {
+	var img api.Image
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "lxd-image-from-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccImageFromFile_basic(tmpDir),
+				Check: resource.ComposeTestCheckFunc(
+					testAccImageExists(t, "lxd_image_from_file.img1", &img),
+					resource.TestCheckResourceAttrPair(
+						"lxd_image_from_file.img1", "fingerprint",
+						"lxd_image_export.export1", "fingerprint"),
+				),
+			},
+		},
+	})
+}
+
+func testAccImageFromFile_basic(dir string) string {
+	return fmt.Sprintf(`
+resource "lxd_image_export" "export1" {
+  remote = "images"
+  image = "alpine/3.9/amd64"
+  output_directory = "%s"
+}
+
+resource "lxd_image_from_file" "img1" {
+  meta_file = "${lxd_image_export.export1.meta_file}"
+  rootfs_file = "${lxd_image_export.export1.rootfs_file}"
+}
+	`, dir)
+}
@@ -0,0 +1,261 @@
+package lxd
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	lxd "github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/shared/api"
+)
+
+func resourceLxdImageFromURL() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceLxdImageFromURLCreate,
+		Update: resourceLxdLocalImageUpdate,
+		Delete: resourceLxdLocalImageDelete,
+		Exists: resourceLxdLocalImageExists,
+		Read:   resourceLxdLocalImageRead,
+
+		Schema: map[string]*schema.Schema{
+			"url": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: resourceLxdValidateURL,
+			},
+
+			"sha256": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: resourceLxdValidateFingerprint,
+			},
+
+			"headers": {
+				Type:          schema.TypeMap,
+				Optional:      true,
+				ForceNew:      true,
+				Sensitive:     true,
+				ConflictsWith: []string{"server_download"},
+			},
+
+			"server_download": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				ForceNew: true,
+				Default:  false,
+			},
+
+			"type": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      "container",
+				ValidateFunc: resourceLxdValidateImageType,
+			},
+
+			"aliases": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
+			"properties": {
+				Type:     schema.TypeMap,
+				Optional: true,
+			},
+
+			"expires_at": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Computed:         true,
+				ValidateFunc:     resourceLxdValidateTimestamp,
+				DiffSuppressFunc: suppressTimestampDifferences,
+			},
+
+			"profiles": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
+			"public": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"remote": {
+				Type:     schema.TypeString,
+				ForceNew: true,
+				Optional: true,
+				Default:  "",
+			},
+
+			"project": {
+				Type:     schema.TypeString,
+				ForceNew: true,
+				Optional: true,
+			},
+
+			// Computed attributes
+
+			"architecture": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"created_at": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"fingerprint": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceLxdImageFromURLCreate(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	if d.Get("server_download").(bool) {
+		return resourceLxdImageFromURLServerImport(d, meta, server)
+	}
+
+	imageURL := d.Get("url").(string)
+
+	tmpDir, err := ioutil.TempDir("", "terraform-provider-lxd")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Keep the file name of the URL, LXD uses it as the image file name.
+	fileName := "image.tar"
+	if u, err := url.Parse(imageURL); err == nil && path.Base(u.Path) != "/" && path.Base(u.Path) != "." {
+		fileName = path.Base(u.Path)
+	}
+	filePath := filepath.Join(tmpDir, fileName)
+
+	sum, err := resourceLxdImageFromURLDownload(imageURL, d.Get("headers").(map[string]interface{}), filePath)
+	if err != nil {
+		return err
+	}
+
+	// A unified tarball is the whole image, so its sha256 is also the
+	// fingerprint: a prefix of it is enough.
+	if expected := d.Get("sha256").(string); expected != "" && !strings.HasPrefix(sum, strings.ToLower(expected)) {
+		return fmt.Errorf("Checksum mismatch for %s: expected %s, got %s", imageURL, expected, sum)
+	}
+
+	return resourceLxdImageFromFileImport(d, meta, server, filePath, "")
+}
+
+// resourceLxdImageFromURLDownload downloads a URL to a local file
+// and returns the hex encoded sha256 of its content.
+func resourceLxdImageFromURLDownload(imageURL string, headers map[string]interface{}, filePath string) (string, error) {
+	req, err := http.NewRequest("GET", imageURL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	for k, v := range headers {
+		req.Header.Set(k, v.(string))
+	}
+
+	log.Printf("[DEBUG] Downloading image from %s", imageURL)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("Unable to download %s: %s", imageURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Unable to download %s: %s", imageURL, resp.Status)
+	}
+
+	f, err := os.Create(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, h), resp.Body); err != nil {
+		return "", fmt.Errorf("Unable to download %s: %s", imageURL, err)
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// resourceLxdImageFromURLServerImport has LXD import the image from the
+// URL, like lxc image import <url> does. The URL must answer with the
+// LXD-Image-URL and LXD-Image-Hash headers pointing to the image.
+func resourceLxdImageFromURLServerImport(d *schema.ResourceData, meta interface{}, server lxd.ContainerServer) error {
+	imageURL := d.Get("url").(string)
+
+	aliases, err := resourceLxdImageNewAliases(d, server)
+	if err != nil {
+		return err
+	}
+
+	req := api.ImagesPost{
+		Source: &api.ImagesPostSource{
+			Type: "url",
+			URL:  imageURL,
+		},
+	}
+	req.Source.ImageType = d.Get("type").(string)
+	req.Aliases = aliases
+	req.Public = d.Get("public").(bool)
+	req.Properties = resourceLxdConfigMap(d.Get("properties"))
+	req.Profiles = resourceLxdImageProfiles(d)
+	req.ExpiresAt = resourceLxdImageExpiresAt(d)
+
+	log.Printf("[DEBUG] Importing image from %s", imageURL)
+	op, err := server.CreateImage(req, nil)
+	if err != nil {
+		return fmt.Errorf("Unable to import image from %s: %s", imageURL, err)
+	}
+
+	if err := op.Wait(); err != nil {
+		return fmt.Errorf("Unable to import image from %s: %s", imageURL, err)
+	}
+
+	fingerprint, _ := op.Get().Metadata["fingerprint"].(string)
+	if fingerprint == "" {
+		return fmt.Errorf("Unable to determine the fingerprint of the image imported from %s", imageURL)
+	}
+
+	// The server only learns the fingerprint once the image is
+	// imported, so a mismatching one has to be removed again.
+	if expected := d.Get("sha256").(string); expected != "" && !strings.HasPrefix(fingerprint, strings.ToLower(expected)) {
+		if op, err := server.DeleteImage(fingerprint); err == nil {
+			op.Wait()
+		}
+
+		return fmt.Errorf("Checksum mismatch for %s: expected %s, got %s", imageURL, expected, fingerprint)
+	}
+
+	d.SetId(fingerprint)
+
+	return resourceLxdLocalImageRead(d, meta)
+}
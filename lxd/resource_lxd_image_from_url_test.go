@@ -0,0 +1,65 @@
+package lxd
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccImageFromURL_checksumMismatch(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccImageFromURL_url("https://images.linuxcontainers.org/meta/1.0/index-system", "0000000000000000000000000000000000000000000000000000000000000000", false),
+				ExpectError: regexp.MustCompile(`.*Checksum mismatch.*`),
+			},
+		},
+	})
+}
+
+func TestAccImageFromURL_invalidURL(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccImageFromURL_url("ftp://images.example.com/image.tar.gz", "", false),
+				ExpectError: regexp.MustCompile(`.*must be an http or https URL.*`),
+			},
+		},
+	})
+}
+
+func TestAccImageFromURL_serverDownloadNotAnImage(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				// The URL doesn't answer with the LXD-Image-URL and
+				// LXD-Image-Hash headers, so LXD refuses it.
+				Config:      testAccImageFromURL_url("https://images.linuxcontainers.org/meta/1.0/index-system", "", true),
+				ExpectError: regexp.MustCompile(`.*Unable to import image from.*`),
+			},
+		},
+	})
+}
+
+func testAccImageFromURL_url(url, sum string, serverDownload bool) string {
+	checksum := ""
+	if sum != "" {
+		checksum = fmt.Sprintf(`sha256 = "%s"`, sum)
+	}
+
+	return fmt.Sprintf(`
+resource "lxd_image_from_url" "img1" {
+  url = "%s"
+  %s
+  server_download = %t
+}
+	`, url, checksum, serverDownload)
+}
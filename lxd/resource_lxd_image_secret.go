@@ -0,0 +1,131 @@
+package lxd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func resourceLxdImageSecret() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceLxdImageSecretCreate,
+		Delete: resourceLxdImageSecretDelete,
+		Read:   resourceLxdImageSecretRead,
+
+		Schema: map[string]*schema.Schema{
+			"image": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"remote": {
+				Type:     schema.TypeString,
+				ForceNew: true,
+				Optional: true,
+				Default:  "",
+			},
+
+			"project": {
+				Type:     schema.TypeString,
+				ForceNew: true,
+				Optional: true,
+			},
+
+			// Computed attributes
+
+			"fingerprint": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"secret": {
+				Type:      schema.TypeString,
+				Computed:  true,
+				Sensitive: true,
+			},
+
+			"server_address": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"server_certificate": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceLxdImageSecretCreate(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	image := d.Get("image").(string)
+	// has the user provided an fingerprint or alias?
+	aliasTarget, _, _ := server.GetImageAlias(image)
+	if aliasTarget != nil {
+		image = aliasTarget.Target
+	}
+
+	img, _, err := server.GetImage(image)
+	if err != nil {
+		return err
+	}
+
+	op, err := server.CreateImageSecret(img.Fingerprint)
+	if err != nil {
+		return fmt.Errorf("Unable to create secret for image %s: %s", img.Fingerprint, err)
+	}
+
+	// The operation stays around until the secret is used or cancelled.
+	opAPI := op.Get()
+	secret, ok := opAPI.Metadata["secret"].(string)
+	if !ok {
+		return fmt.Errorf("Unable to determine secret for image %s", img.Fingerprint)
+	}
+
+	log.Printf("[DEBUG] Created secret for image %s in operation %s", img.Fingerprint, opAPI.ID)
+	d.SetId(opAPI.ID)
+	d.Set("fingerprint", img.Fingerprint)
+	d.Set("secret", secret)
+
+	info, err := server.GetConnectionInfo()
+	if err != nil {
+		return err
+	}
+
+	if len(info.Addresses) > 0 {
+		d.Set("server_address", info.Addresses[0])
+	}
+	d.Set("server_certificate", info.Certificate)
+
+	return nil
+}
+
+func resourceLxdImageSecretRead(d *schema.ResourceData, meta interface{}) error {
+	// A secret is only valid until it's been used, after which LXD
+	// forgets about it. There is nothing to refresh in the meantime.
+	return nil
+}
+
+func resourceLxdImageSecretDelete(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	// Cancel the secret if it hasn't been used yet.
+	err = server.DeleteOperation(d.Id())
+	if err != nil && err.Error() != "not found" {
+		return err
+	}
+
+	return nil
+}
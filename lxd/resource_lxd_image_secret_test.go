@@ -0,0 +1,39 @@
+package lxd
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccImageSecret_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccImageSecret_basic(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair(
+						"lxd_image_secret.secret1", "fingerprint",
+						"lxd_cached_image.img1", "fingerprint"),
+					resource.TestCheckResourceAttrSet("lxd_image_secret.secret1", "secret"),
+				),
+			},
+		},
+	})
+}
+
+func testAccImageSecret_basic() string {
+	return fmt.Sprintf(`
+resource "lxd_cached_image" "img1" {
+  source_remote = "images"
+  source_image = "alpine/3.9/amd64"
+}
+
+resource "lxd_image_secret" "secret1" {
+  image = "${lxd_cached_image.img1.fingerprint}"
+}
+	`)
+}
@@ -0,0 +1,3115 @@
+package lxd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+
+	lxd "github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/units"
+	"github.com/mitchellh/go-homedir"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func resourceLxdInstance() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceLxdInstanceCreate,
+		Update: resourceLxdInstanceUpdate,
+		Delete: resourceLxdInstanceDelete,
+		Exists: resourceLxdInstanceExists,
+		Read:   resourceLxdInstanceRead,
+		Importer: &schema.ResourceImporter{
+			State: resourceLxdInstanceImport,
+		},
+
+		CustomizeDiff: resourceLxdInstanceCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"remote": {
+				Type:     schema.TypeString,
+				ForceNew: true,
+				Optional: true,
+				Default:  "",
+			},
+
+			"project": {
+				Type:     schema.TypeString,
+				ForceNew: true,
+				Optional: true,
+				Default:  "",
+			},
+
+			"target": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: resourceLxdValidateTarget,
+			},
+
+			"type": {
+				Type:         schema.TypeString,
+				ForceNew:     true,
+				Optional:     true,
+				Default:      "container",
+				ValidateFunc: resourceLxdValidateImageType,
+			},
+
+			"image": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				DiffSuppressFunc: suppressInstanceImageDifferences,
+				ConflictsWith:    []string{"source_instance"},
+			},
+
+			"source_instance": {
+				Type:     schema.TypeList,
+				ForceNew: true,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"remote": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+
+						"project": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+
+						"name": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+
+						"snapshot": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+
+						"instance_only": {
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  false,
+						},
+
+						"mode": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "pull",
+							ValidateFunc: resourceLxdValidateCopyMode,
+						},
+					},
+				},
+			},
+
+			"rebuild_on_image_change": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"source_backup": {
+				Type:          schema.TypeString,
+				ForceNew:      true,
+				Optional:      true,
+				ConflictsWith: []string{"image", "source_instance", "instance_type"},
+			},
+
+			"instance_type": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"source_instance"},
+			},
+
+			"profiles": {
+				Type:     schema.TypeList,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Optional: true,
+				Computed: true,
+			},
+
+			"ignore_profile_order": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"device": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+
+						"type": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: resourceLxdValidateDeviceType,
+						},
+
+						"properties": {
+							Type:     schema.TypeMap,
+							Required: true,
+						},
+					},
+				},
+			},
+
+			"description": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"snapshot_schedule": {
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"schedule": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: resourceLxdValidateSnapshotSchedule,
+						},
+
+						"pattern": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+
+						"expiry": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: resourceLxdValidateSnapshotExpiry,
+						},
+
+						"stopped": {
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  false,
+						},
+					},
+				},
+			},
+
+			"config": {
+				Type:     schema.TypeMap,
+				Optional: true,
+			},
+
+			"metadata": {
+				Type:         schema.TypeMap,
+				Optional:     true,
+				ValidateFunc: resourceLxdValidateMetadata,
+			},
+
+			"limits": {
+				Type:         schema.TypeMap,
+				Optional:     true,
+				Deprecated:   "Use resource_limits instead, or config for the limits it doesn't cover",
+				ValidateFunc: resourceLxdValidateLimits,
+			},
+
+			"resource_limits": {
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: instanceResourceLimitsSchema(),
+				},
+			},
+
+			"idmap": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"type": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: resourceLxdValidateIdmapType,
+						},
+
+						"host_id": {
+							Type:         schema.TypeInt,
+							Required:     true,
+							ValidateFunc: resourceLxdValidateIdmapID,
+						},
+
+						"instance_id": {
+							Type:         schema.TypeInt,
+							Required:     true,
+							ValidateFunc: resourceLxdValidateIdmapID,
+						},
+
+						"range": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Default:      1,
+							ValidateFunc: resourceLxdValidateIdmapRange,
+						},
+					},
+				},
+			},
+
+			"user_data": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				ForceNew:         true,
+				DiffSuppressFunc: suppressCloudInitDifferences,
+			},
+
+			"vendor_data": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				ForceNew:         true,
+				DiffSuppressFunc: suppressCloudInitDifferences,
+			},
+
+			"network_config": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				ForceNew:         true,
+				DiffSuppressFunc: suppressCloudInitDifferences,
+			},
+
+			"privileged": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Computed: true,
+			},
+
+			"nesting": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Computed: true,
+			},
+
+			"idmap_isolated": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Computed: true,
+			},
+
+			"secureboot": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Computed: true,
+			},
+
+			"protected": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Computed: true,
+			},
+
+			"csm": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Computed: true,
+			},
+
+			"sev": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Computed: true,
+			},
+
+			"agent_nic_config": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Computed: true,
+			},
+
+			"autostart": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Computed: true,
+			},
+
+			"boot_priority": {
+				Type:     schema.TypeInt,
+				Optional: true,
+				Computed: true,
+			},
+
+			"stop_priority": {
+				Type:     schema.TypeInt,
+				Optional: true,
+				Computed: true,
+			},
+
+			"ephemeral": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				ForceNew: true,
+			},
+
+			"running": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+
+			"stateful": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"restart_on_change": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "when-required",
+				ValidateFunc: resourceLxdValidateRestartOnChange,
+			},
+
+			"shutdown_timeout": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "5m",
+				ValidateFunc: resourceLxdValidateDuration,
+			},
+
+			"wait_for_network": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+
+			"console_output_size": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: resourceLxdValidateConsoleOutputSize,
+			},
+
+			"wait_for": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"type": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: resourceLxdValidateWaitForType,
+						},
+
+						"interface": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+
+						"timeout": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "5m",
+							ValidateFunc: resourceLxdValidateDuration,
+						},
+					},
+				},
+			},
+
+			"file": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"content": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+
+						"source": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+
+						"target_path": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+
+						"uid": {
+							Type:     schema.TypeInt,
+							Optional: true,
+						},
+
+						"gid": {
+							Type:     schema.TypeInt,
+							Optional: true,
+						},
+
+						"mode": {
+							Type:     schema.TypeString,
+							Optional: true,
+							Default:  "0644",
+						},
+
+						"create_directories": {
+							Type:     schema.TypeBool,
+							Optional: true,
+						},
+					},
+				},
+			},
+
+			"exec": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"command": {
+							Type:     schema.TypeList,
+							Required: true,
+							MinItems: 1,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+
+						"environment": {
+							Type:     schema.TypeMap,
+							Optional: true,
+						},
+
+						"working_dir": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+
+						"uid": {
+							Type:     schema.TypeInt,
+							Optional: true,
+							Default:  0,
+						},
+
+						"gid": {
+							Type:     schema.TypeInt,
+							Optional: true,
+							Default:  0,
+						},
+
+						"fail_on_error": {
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  true,
+						},
+					},
+				},
+			},
+
+			// Computed attributes
+
+			"ip_address": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"mac_address": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"ipv4_address": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"ipv6_address": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"network_interfaces": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"device": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"type": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"state": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"host_name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"mac_address": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"mtu": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+
+						"addresses": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"family": {
+										Type:     schema.TypeString,
+										Computed: true,
+									},
+
+									"address": {
+										Type:     schema.TypeString,
+										Computed: true,
+									},
+
+									"netmask": {
+										Type:     schema.TypeString,
+										Computed: true,
+									},
+
+									"scope": {
+										Type:     schema.TypeString,
+										Computed: true,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+
+			"status": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"restart_pending": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+
+			"image_fingerprint": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"vsock_id": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"console_output": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"location": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"expanded_config": {
+				Type:     schema.TypeMap,
+				Computed: true,
+			},
+
+			"expanded_devices": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"type": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"properties": {
+							Type:     schema.TypeMap,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func resourceLxdInstanceCreate(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	remote := p.selectRemote(d)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+	refreshInterval := p.RefreshInterval
+
+	name := d.Get("name").(string)
+	instType := d.Get("type").(string)
+
+	// Prepare instance config
+	config := resourceLxdConfigMap(d.Get("config"))
+	config = resourceLxdConfigMapAppend(config, d.Get("limits"), "limits.")
+	config = resourceLxdConfigMapAppend(config, d.Get("metadata"), "user.")
+	for attr, key := range instanceCloudInitKeys {
+		if v := d.Get(attr).(string); v != "" {
+			config[key] = v
+		}
+	}
+	for attr, key := range instanceConfigKeys {
+		if v, ok := d.GetOkExists(attr); ok {
+			config[key] = fmt.Sprint(v)
+		}
+	}
+
+	if idmap := d.Get("idmap").([]interface{}); len(idmap) > 0 {
+		if err := resourceLxdInstanceCheckIdmap(server, idmap); err != nil {
+			return err
+		}
+		config["raw.idmap"] = instanceIdmap(idmap)
+	}
+
+	for k, v := range instanceSnapshotSchedule(d.Get("snapshot_schedule").([]interface{})) {
+		config[k] = v
+	}
+
+	for k, v := range instanceResourceLimits(d.Get("resource_limits").([]interface{})) {
+		config[k] = v
+	}
+
+	// The legacy BIOS of CSM can't do secure boot,
+	// so it is disabled unless asked otherwise.
+	if _, ok := d.GetOkExists("secureboot"); !ok && d.Get("csm").(bool) {
+		config["security.secureboot"] = "false"
+	}
+
+	devices := resourceLxdDevices(d.Get("device"))
+	if err := resourceLxdCheckNicNetworks(server, devices); err != nil {
+		return err
+	}
+
+	profiles := []string{}
+	if v, ok := d.GetOk("profiles"); ok {
+		for _, v := range v.([]interface{}) {
+			profiles = append(profiles, v.(string))
+		}
+	}
+
+	// build API request
+	createReq := api.InstancesPost{
+		Name: name,
+		Type: api.InstanceType(instType),
+	}
+	createReq.Description = d.Get("description").(string)
+	createReq.InstanceType = d.Get("instance_type").(string)
+	createReq.Profiles = profiles
+	createReq.Config = config
+	createReq.Devices = devices
+	createReq.Ephemeral = d.Get("ephemeral").(bool)
+
+	// Place the instance on a cluster member or group, if requested.
+	targetServer := server
+	if target := d.Get("target").(string); target != "" {
+		targetServer = server.UseTarget(target)
+	}
+
+	// Create instance. It will not be running after this operation
+	var op1 interface{ Wait() error }
+	if v, ok := d.GetOk("source_instance"); ok {
+		source := v.([]interface{})[0].(map[string]interface{})
+		op1, err = resourceLxdInstanceCopy(p, remote, d.Get("project").(string), targetServer, createReq, source)
+	} else if v, ok := d.GetOk("source_backup"); ok {
+		op1, err = resourceLxdInstanceCreateFromBackup(targetServer, createReq, v.(string))
+	} else {
+		op1, err = resourceLxdInstanceCreateFromImage(p, remote, targetServer, createReq, d.Get("image").(string))
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = op1.Wait(); err != nil {
+		return fmt.Errorf("failed to create instance (%s): %s", name, err)
+	}
+
+	// Instance has been created, store ID
+	d.SetId(name)
+
+	if err := resourceLxdInstanceProvision(d, server, name, instType, refreshInterval); err != nil {
+		return err
+	}
+
+	d.Set("restart_pending", false)
+
+	return resourceLxdInstanceRead(d, meta)
+}
+
+// resourceLxdInstanceProvision provisions a freshly created or rebuilt
+// instance: its files are pushed, and if it is to be running, it is
+// started and its commands are run.
+func resourceLxdInstanceProvision(d *schema.ResourceData, server lxd.ContainerServer, name, instType string, refreshInterval time.Duration) error {
+	// Files are pushed into containers before they start, so they can
+	// be used at boot. Virtual machines need to be running for it.
+	files := d.Get("file").([]interface{})
+	if instType == "container" {
+		if err := resourceLxdInstanceUploadFiles(server, name, files); err != nil {
+			return err
+		}
+	}
+
+	if !d.Get("running").(bool) {
+		return nil
+	}
+
+	if err := resourceLxdInstanceSetState(server, name, "start", false, refreshInterval); err != nil {
+		return err
+	}
+
+	if err := resourceLxdInstanceWaitReady(d, server, name, refreshInterval); err != nil {
+		return err
+	}
+
+	if instType == "virtual-machine" {
+		if err := resourceLxdInstanceUploadFiles(server, name, files); err != nil {
+			return err
+		}
+	}
+
+	for _, v := range d.Get("exec").([]interface{}) {
+		if err := resourceLxdInstanceExec(server, name, v.(map[string]interface{})); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resourceLxdInstanceCreateFromImage creates an instance from an image,
+// given as an alias or fingerprint, optionally prefixed with a remote.
+func resourceLxdInstanceCreateFromImage(p *lxdProvider, remote string, server lxd.ContainerServer, req api.InstancesPost, image string) (lxd.RemoteOperation, error) {
+	// If no profiles were set, use the default profile
+	if len(req.Profiles) == 0 {
+		req.Profiles = []string{"default"}
+	}
+
+	imgServer, imgInfo, alias, err := resourceLxdInstanceImage(p, remote, string(req.Type), image)
+	if err != nil {
+		return nil, err
+	}
+	req.Source.Alias = alias
+
+	return server.CreateInstanceFromImage(imgServer, *imgInfo, req)
+}
+
+// resourceLxdInstanceRebuild re-creates the root disk of an instance from
+// its new image, keeping its name, config, devices and attached volumes.
+// LXD only rebuilds stopped instances, so a running instance is stopped
+// for it, then provisioned again as if it had just been created.
+func resourceLxdInstanceRebuild(d *schema.ResourceData, p *lxdProvider, server lxd.ContainerServer, name string) error {
+	instType := d.Get("type").(string)
+	image := d.Get("image").(string)
+
+	imgServer, imgInfo, alias, err := resourceLxdInstanceImage(p, p.selectRemote(d), instType, image)
+	if err != nil {
+		return err
+	}
+
+	st, _, err := server.GetInstanceState(name)
+	if err != nil {
+		return err
+	}
+
+	if st.Status != "Stopped" {
+		if err := resourceLxdInstanceStop(d, server, name, p.RefreshInterval); err != nil {
+			return err
+		}
+	}
+
+	req := api.InstanceRebuildPost{
+		Source: api.InstanceSource{
+			Type:  "image",
+			Alias: alias,
+		},
+	}
+
+	log.Printf("[DEBUG] Rebuilding instance %s from image %s", name, image)
+	op, err := server.RebuildInstanceFromImage(imgServer, *imgInfo, name, req)
+	if err != nil {
+		return fmt.Errorf("Unable to rebuild instance (%s): %s", name, err)
+	}
+
+	if err := op.Wait(); err != nil {
+		return fmt.Errorf("Error waiting for instance (%s) to be rebuilt: %s", name, err)
+	}
+
+	return resourceLxdInstanceProvision(d, server, name, instType, p.RefreshInterval)
+}
+
+// resourceLxdInstanceImage looks up an image for an instance type, given
+// as an alias or fingerprint, optionally prefixed with a remote. It returns
+// the server of the image, its info and the alias it was referred to by.
+func resourceLxdInstanceImage(p *lxdProvider, remote, instType, image string) (lxd.ImageServer, *api.Image, string, error) {
+	imgRemote := remote
+	if imgParts := strings.SplitN(image, ":", 2); len(imgParts) == 2 {
+		imgRemote = imgParts[0]
+		image = imgParts[1]
+	}
+	imgServer, err := p.GetImageServer(imgRemote)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("could not create image server client: %v", err)
+	}
+
+	// Gather info about source image.
+	// Aliases are resolved for the type of the instance,
+	// as containers and virtual machines use different images.
+	conn, err := imgServer.GetConnectionInfo()
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("could not get image server connection info: %v", err)
+	}
+	if conn.Protocol == "simplestreams" {
+		imgInfo := &api.Image{}
+		imgInfo.Fingerprint = image
+		imgInfo.Public = true
+		return imgServer, imgInfo, image, nil
+	}
+
+	alias := ""
+	if aliasTarget, _, err := imgServer.GetImageAliasType(instType, image); err == nil {
+		alias = image
+		image = aliasTarget.Target
+	}
+
+	imgInfo, _, err := imgServer.GetImage(image)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("could not get image info: %v", err)
+	}
+
+	return imgServer, imgInfo, alias, nil
+}
+
+// resourceLxdInstanceCopy creates an instance as a copy of another
+// instance, or of one of its snapshots. The profiles, config and devices
+// of the new instance take precedence over the ones of the source.
+func resourceLxdInstanceCopy(p *lxdProvider, remote, project string, server lxd.ContainerServer, req api.InstancesPost, source map[string]interface{}) (lxd.RemoteOperation, error) {
+	srcRemote := source["remote"].(string)
+	if srcRemote == "" {
+		srcRemote = remote
+	}
+
+	srcServer, err := p.GetContainerServer(srcRemote)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sources on the same remote are looked up in the same project,
+	// unless told otherwise.
+	if srcProject := source["project"].(string); srcProject != "" {
+		srcServer = srcServer.UseProject(srcProject)
+	} else if project != "" && srcRemote == remote {
+		srcServer = srcServer.UseProject(project)
+	}
+
+	srcName := source["name"].(string)
+	srcInstance, _, err := srcServer.GetInstance(srcName)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to get source instance (%s): %s", srcName, err)
+	}
+
+	// The type of an instance can't change when copying it.
+	if srcInstance.Type != string(req.Type) {
+		return nil, fmt.Errorf("Source instance (%s) is a %s, type must be set accordingly", srcName, srcInstance.Type)
+	}
+
+	if snapName := source["snapshot"].(string); snapName != "" {
+		snapshot, _, err := srcServer.GetInstanceSnapshot(srcName, snapName)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to get snapshot %s of instance (%s): %s", snapName, srcName, err)
+		}
+
+		snapshot.Profiles, snapshot.Config, snapshot.Devices = instanceCopyOverride(
+			req, snapshot.Profiles, snapshot.Config, snapshot.Devices)
+		snapshot.Ephemeral = req.Ephemeral
+
+		args := lxd.InstanceSnapshotCopyArgs{
+			Name: req.Name,
+			Mode: source["mode"].(string),
+		}
+
+		log.Printf("[DEBUG] Copying snapshot %s of instance %s to %s", snapName, srcName, req.Name)
+		return server.CopyInstanceSnapshot(srcServer, srcName, *snapshot, &args)
+	}
+
+	srcInstance.Profiles, srcInstance.Config, srcInstance.Devices = instanceCopyOverride(
+		req, srcInstance.Profiles, srcInstance.Config, srcInstance.Devices)
+	srcInstance.Ephemeral = req.Ephemeral
+
+	args := lxd.InstanceCopyArgs{
+		Name:         req.Name,
+		InstanceOnly: source["instance_only"].(bool),
+		Mode:         source["mode"].(string),
+	}
+
+	log.Printf("[DEBUG] Copying instance %s to %s", srcName, req.Name)
+	return server.CopyInstance(srcServer, *srcInstance, &args)
+}
+
+// resourceLxdInstanceCreateFromBackup imports a backup tarball as a new
+// instance, in the storage pool of its root disk if one is set. The
+// profiles, config and devices of the new instance are then applied as
+// for copies, through the returned operation.
+func resourceLxdInstanceCreateFromBackup(server lxd.ContainerServer, req api.InstancesPost, file string) (lxd.Operation, error) {
+	file, err := homedir.Expand(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to determine backup path: %s", err)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to open backup %s: %s", file, err)
+	}
+	defer f.Close()
+
+	args := lxd.InstanceBackupArgs{
+		BackupFile: f,
+		PoolName:   instanceRootDisk(req.Devices)["pool"],
+		Name:       req.Name,
+	}
+
+	log.Printf("[DEBUG] Importing backup %s as instance %s", file, req.Name)
+	op, err := server.CreateInstanceFromBackup(args)
+	if err != nil {
+		return nil, err
+	}
+	if err := op.Wait(); err != nil {
+		return nil, fmt.Errorf("Unable to import backup %s: %s", file, err)
+	}
+
+	instance, etag, err := server.GetInstance(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	// The type of an instance can't change when importing it.
+	if instance.Type != string(req.Type) {
+		if op, err := server.DeleteInstance(req.Name); err == nil {
+			op.Wait()
+		}
+		return nil, fmt.Errorf("Backup %s is of a %s, type must be set accordingly", file, instance.Type)
+	}
+
+	instance.Profiles, instance.Config, instance.Devices = instanceCopyOverride(
+		req, instance.Profiles, instance.Config, instance.Devices)
+	instance.Ephemeral = req.Ephemeral
+	if req.Description != "" {
+		instance.Description = req.Description
+	}
+
+	return server.UpdateInstance(req.Name, instance.Writable(), etag)
+}
+
+// instanceCopyOverride applies the settings of a new instance to the
+// ones copied from its source. The volatile keys identifying the source,
+// such as its MAC addresses, are dropped so the copy gets its own.
+func instanceCopyOverride(req api.InstancesPost, profiles []string, config map[string]string, devices map[string]map[string]string) ([]string, map[string]string, map[string]map[string]string) {
+	if len(req.Profiles) > 0 {
+		profiles = req.Profiles
+	}
+
+	newConfig := make(map[string]string)
+	for k, v := range config {
+		if k == "volatile.uuid" || (strings.HasPrefix(k, "volatile.") &&
+			(strings.HasSuffix(k, ".hwaddr") || strings.HasSuffix(k, ".name"))) {
+			continue
+		}
+		newConfig[k] = v
+	}
+	for k, v := range req.Config {
+		newConfig[k] = v
+	}
+
+	newDevices := make(map[string]map[string]string)
+	for k, v := range devices {
+		newDevices[k] = v
+	}
+	for k, v := range req.Devices {
+		newDevices[k] = v
+	}
+
+	return profiles, newConfig, newDevices
+}
+
+// resourceLxdInstanceWaitReady waits for a freshly started
+// instance to be usable.
+func resourceLxdInstanceWaitReady(d *schema.ResourceData, server lxd.ContainerServer, name string, refreshInterval time.Duration) error {
+	var err error
+
+	// The network state of a virtual machine is reported by the LXD agent
+	// running inside it, so wait for it to come up first.
+	if d.Get("type").(string) == "virtual-machine" {
+		agentConf := &resource.StateChangeConf{
+			Target:     []string{"OK"},
+			Refresh:    resourceLxdInstanceWaitForAgent(server, name),
+			Timeout:    5 * time.Minute,
+			Delay:      refreshInterval,
+			MinTimeout: 3 * time.Second,
+		}
+
+		if _, err = agentConf.WaitForState(); err != nil {
+			return fmt.Errorf("Error waiting for the LXD agent of instance (%s): %s", name, err)
+		}
+	}
+
+	if d.Get("wait_for_network").(bool) {
+		// Lxd will return "Running" even if "inet" has not yet been set.
+		// wait until we see an "inet" ip_address before reading the state.
+		networkConf := &resource.StateChangeConf{
+			Target:     []string{"OK"},
+			Refresh:    resourceLxdInstanceWaitForNetwork(server, name),
+			Timeout:    3 * time.Minute,
+			Delay:      refreshInterval,
+			MinTimeout: 3 * time.Second,
+		}
+
+		if _, err = networkConf.WaitForState(); err != nil {
+			return fmt.Errorf("Error waiting for instance (%s) network information: %s", name, err)
+		}
+	}
+
+	for _, v := range d.Get("wait_for").([]interface{}) {
+		w := v.(map[string]interface{})
+		waitType := w["type"].(string)
+		iface := w["interface"].(string)
+
+		// The timeout was validated when planning.
+		timeout, _ := time.ParseDuration(w["timeout"].(string))
+
+		var refresh resource.StateRefreshFunc
+		switch waitType {
+		case "ipv4":
+			refresh = resourceLxdInstanceWaitForAddress(server, name, iface, "inet")
+		case "ipv6":
+			refresh = resourceLxdInstanceWaitForAddress(server, name, iface, "inet6")
+		case "cloud-init":
+			refresh = resourceLxdInstanceWaitForCloudInit(server, name)
+		case "agent":
+			refresh = resourceLxdInstanceWaitForAgent(server, name)
+		}
+
+		log.Printf("[DEBUG] Waiting up to %s for %s of instance %s", timeout, waitType, name)
+		waitConf := &resource.StateChangeConf{
+			Target:     []string{"OK"},
+			Refresh:    refresh,
+			Timeout:    timeout,
+			Delay:      refreshInterval,
+			MinTimeout: 3 * time.Second,
+		}
+
+		if _, err = waitConf.WaitForState(); err != nil {
+			return fmt.Errorf("Error waiting for %s of instance (%s): %s", waitType, name, err)
+		}
+	}
+
+	return nil
+}
+
+func resourceLxdInstanceRead(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	name := d.Id()
+
+	instance, _, err := server.GetInstance(name)
+	if err != nil {
+		return err
+	}
+	log.Printf("[DEBUG] Retrieved instance %s: %#v", name, instance)
+
+	state, _, err := server.GetInstanceState(name)
+	if err != nil {
+		return err
+	}
+	log.Printf("[DEBUG] Retrieved instance state %s:\n%#v", name, state)
+
+	d.Set("type", instance.Type)
+	d.Set("ephemeral", instance.Ephemeral)
+	d.Set("image_fingerprint", instance.Config["volatile.base_image"])
+
+	// The vsock ID is used to reach the LXD agent of virtual machines.
+	vsockID, _ := strconv.Atoi(instance.Config["volatile.vsock_id"])
+	d.Set("vsock_id", vsockID)
+	d.Set("status", instance.Status)
+
+	// The console log is only read when asked for, as it can be large.
+	consoleOutput := ""
+	if size := d.Get("console_output_size").(int); size > 0 {
+		consoleOutput = resourceLxdInstanceConsoleOutput(server, name, size)
+	}
+	d.Set("console_output", consoleOutput)
+
+	// Stopped instances apply all their config when started.
+	if instance.Status != "Running" {
+		d.Set("restart_pending", false)
+	}
+	d.Set("running", instance.Status == "Running")
+
+	// Standalone servers report "none" as location. A cluster group
+	// target is kept as is, as it doesn't name the member in use.
+	location := ""
+	if instance.Location != "none" {
+		location = instance.Location
+	}
+	d.Set("location", location)
+	if target := d.Get("target").(string); location != "" && !strings.HasPrefix(target, "@") {
+		d.Set("target", location)
+	}
+
+	// Keys set by LXD itself are left out, everything else
+	// could have been set by the user, including VM specific
+	// keys such as security.secureboot or agent.nic_config.
+	// Limits go to the limits map, unless they were set through config.
+	configured := d.Get("config").(map[string]interface{})
+	config := make(map[string]string)
+	limits := make(map[string]string)
+	for k, v := range instance.Config {
+		if _, ok := configured[k]; !ok && strings.HasPrefix(k, "limits.") {
+			limits[strings.TrimPrefix(k, "limits.")] = v
+		} else if !strings.HasPrefix(k, "volatile.") && !strings.HasPrefix(k, "image.") {
+			config[k] = v
+		}
+	}
+
+	// cloud-init keys go to their own attributes,
+	// unless they were set through config.
+	for attr, key := range instanceCloudInitKeys {
+		if _, ok := configured[key]; ok {
+			continue
+		}
+		d.Set(attr, config[key])
+		delete(config, key)
+	}
+
+	// So do the keys set through attributes. Unset keys take
+	// the default of LXD, which only enables secure boot.
+	for attr, key := range instanceConfigKeys {
+		if _, ok := configured[key]; ok {
+			continue
+		}
+
+		switch d.Get(attr).(type) {
+		case bool:
+			v, err := strconv.ParseBool(config[key])
+			if err != nil {
+				v = attr == "secureboot" && instance.Type == "virtual-machine"
+			}
+			d.Set(attr, v)
+		case int:
+			v, _ := strconv.Atoi(config[key])
+			d.Set(attr, v)
+		}
+		delete(config, key)
+	}
+
+	// raw.idmap is parsed into idmap blocks, unless set through
+	// config or written in a way the blocks can't express.
+	if _, ok := configured["raw.idmap"]; !ok {
+		idmap, err := parseInstanceIdmap(config["raw.idmap"])
+		if err == nil {
+			d.Set("idmap", idmap)
+			delete(config, "raw.idmap")
+		} else {
+			log.Printf("[DEBUG] Leaving raw.idmap of %s in config: %s", name, err)
+			d.Set("idmap", nil)
+		}
+	}
+
+	// So are the keys of scheduled snapshots, into a snapshot_schedule block.
+	scheduleConfigured := false
+	for _, key := range instanceSnapshotScheduleKeys {
+		if _, ok := configured[key]; ok {
+			scheduleConfigured = true
+		}
+	}
+	if !scheduleConfigured {
+		schedule := []interface{}{}
+		if config["snapshots.schedule"] != "" {
+			block := make(map[string]interface{})
+			for attr, key := range instanceSnapshotScheduleKeys {
+				if attr == "stopped" {
+					block[attr] = config[key] == "true"
+				} else {
+					block[attr] = config[key]
+				}
+				delete(config, key)
+			}
+			schedule = append(schedule, block)
+		}
+		d.Set("snapshot_schedule", schedule)
+	}
+
+	// user keys are only managed when they were set through
+	// metadata or config, the ones of other tools are left alone.
+	metadata := make(map[string]string)
+	managed := d.Get("metadata").(map[string]interface{})
+	for k, v := range config {
+		if _, ok := configured[k]; ok || !strings.HasPrefix(k, "user.") {
+			continue
+		}
+
+		if _, ok := managed[strings.TrimPrefix(k, "user.")]; ok {
+			metadata[strings.TrimPrefix(k, "user.")] = v
+		}
+		delete(config, k)
+	}
+
+	// The limits LXD derived from the instance type
+	// are left out, unless they were set through limits
+	// or resource_limits.
+	configuredLimits := d.Get("limits").(map[string]interface{})
+	resourceLimits := instanceResourceLimits(d.Get("resource_limits").([]interface{}))
+	if d.Get("instance_type").(string) != "" {
+		for _, k := range []string{"cpu", "memory"} {
+			_, inMap := configuredLimits[k]
+			_, inBlock := resourceLimits["limits."+k]
+			if !inMap && !inBlock {
+				delete(limits, k)
+			}
+		}
+	}
+
+	// The limits resource_limits covers go to its block,
+	// unless they were set through limits.
+	block := make(map[string]interface{})
+	for attr, key := range instanceResourceLimitKeys {
+		name := strings.TrimPrefix(key, "limits.")
+		if _, ok := configuredLimits[name]; ok {
+			continue
+		}
+		if v, ok := limits[name]; ok {
+			block[attr] = v
+			delete(limits, name)
+		}
+	}
+	if len(block) > 0 {
+		d.Set("resource_limits", []interface{}{block})
+	} else {
+		d.Set("resource_limits", nil)
+	}
+
+	d.Set("description", instance.Description)
+	d.Set("config", config)
+	d.Set("limits", limits)
+	d.Set("metadata", metadata)
+
+	// Interfaces are sorted by name, so the addresses
+	// picked below don't change between reads.
+	names := make([]string, 0, len(state.Network))
+	for iface := range state.Network {
+		if iface != "lo" {
+			names = append(names, iface)
+		}
+	}
+	sort.Strings(names)
+
+	// If there was an access_interface set,
+	// base the addresses off of it.
+	ifaces := names
+	if ai, ok := instance.Config["user.access_interface"]; ok {
+		ifaces = append([]string{ai}, names...)
+	}
+
+	ipv4, ipv6, mac := "", "", ""
+	for _, iface := range ifaces {
+		net := state.Network[iface]
+		for _, ip := range net.Addresses {
+			if ip.Scope != "global" {
+				continue
+			}
+
+			if ip.Family == "inet" && ipv4 == "" {
+				ipv4 = ip.Address
+				mac = net.Hwaddr
+			}
+
+			if ip.Family == "inet6" && ipv6 == "" {
+				ipv6 = ip.Address
+			}
+		}
+	}
+
+	d.Set("ip_address", ipv4)
+	d.Set("ipv4_address", ipv4)
+	d.Set("ipv6_address", ipv6)
+	d.Set("mac_address", mac)
+
+	// Interfaces are matched to the NIC devices they come from
+	// through the name LXD gave to their host side.
+	nics := make(map[string]string)
+	for k, v := range instance.Config {
+		if strings.HasPrefix(k, "volatile.") && strings.HasSuffix(k, ".host_name") && v != "" {
+			nics[v] = strings.TrimSuffix(strings.TrimPrefix(k, "volatile."), ".host_name")
+		}
+	}
+
+	networkInterfaces := make([]map[string]interface{}, 0, len(names))
+	for _, iface := range names {
+		net := state.Network[iface]
+
+		addresses := make([]map[string]interface{}, 0, len(net.Addresses))
+		for _, ip := range net.Addresses {
+			addresses = append(addresses, map[string]interface{}{
+				"family":  ip.Family,
+				"address": ip.Address,
+				"netmask": ip.Netmask,
+				"scope":   ip.Scope,
+			})
+		}
+
+		networkInterfaces = append(networkInterfaces, map[string]interface{}{
+			"name":        iface,
+			"device":      nics[net.HostName],
+			"type":        net.Type,
+			"state":       net.State,
+			"host_name":   net.HostName,
+			"mac_address": net.Hwaddr,
+			"mtu":         net.Mtu,
+			"addresses":   addresses,
+		})
+	}
+	d.Set("network_interfaces", networkInterfaces)
+
+	sshIP := ipv4
+	if sshIP == "" {
+		sshIP = ipv6
+	}
+
+	// Initialize the connection info
+	d.SetConnInfo(map[string]string{
+		"type": "ssh",
+		"host": sshIP,
+	})
+
+	d.Set("profiles", instance.Profiles)
+
+	// Custom volumes attached by lxd_volume_attach are left to it, unless
+	// they are declared as devices of the instance too.
+	declared := resourceLxdDevices(d.Get("device"))
+	devices := make([]map[string]interface{}, 0)
+	for name, lxddevice := range instance.Devices {
+		if _, ok := declared[name]; !ok && isAttachedVolume(lxddevice) {
+			continue
+		}
+
+		device := make(map[string]interface{})
+		device["name"] = name
+		delete(lxddevice, "name")
+		device["type"] = lxddevice["type"]
+		delete(lxddevice, "type")
+		device["properties"] = lxddevice
+		devices = append(devices, device)
+	}
+	d.Set("device", devices)
+
+	// The expanded config and devices are the ones that apply once
+	// merged with the profiles, leaving out the keys LXD keeps its
+	// own state in.
+	expandedConfig := make(map[string]string)
+	for k, v := range instance.ExpandedConfig {
+		if !strings.HasPrefix(k, "volatile.") {
+			expandedConfig[k] = v
+		}
+	}
+	d.Set("expanded_config", expandedConfig)
+
+	deviceNames := make([]string, 0, len(instance.ExpandedDevices))
+	for name := range instance.ExpandedDevices {
+		deviceNames = append(deviceNames, name)
+	}
+	sort.Strings(deviceNames)
+
+	expandedDevices := make([]map[string]interface{}, 0, len(deviceNames))
+	for _, name := range deviceNames {
+		properties := make(map[string]string)
+		for k, v := range instance.ExpandedDevices[name] {
+			if k != "type" {
+				properties[k] = v
+			}
+		}
+
+		expandedDevices = append(expandedDevices, map[string]interface{}{
+			"name":       name,
+			"type":       instance.ExpandedDevices[name]["type"],
+			"properties": properties,
+		})
+	}
+	d.Set("expanded_devices", expandedDevices)
+
+	return nil
+}
+
+func resourceLxdInstanceUpdate(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	if d.HasChange("name") {
+		if err := resourceLxdInstanceRename(d, server, p.RefreshInterval); err != nil {
+			return err
+		}
+	}
+
+	name := d.Id()
+
+	if d.HasChange("target") {
+		if err := resourceLxdInstanceRetarget(d, server, name, p.RefreshInterval); err != nil {
+			return err
+		}
+	}
+
+	// Rebuilt instances get all their files and commands again.
+	rebuilt := d.HasChange("image")
+	if rebuilt {
+		if err := resourceLxdInstanceRebuild(d, p, server, name); err != nil {
+			return err
+		}
+		d.Set("restart_pending", false)
+	}
+
+	// changed determines if an update call needs made.
+	var changed bool
+
+	// coldplug determines if the instance needs to be
+	// stopped for the update, to change devices.
+	var coldplug bool
+
+	instance, etag, err := server.GetInstance(name)
+	if err != nil {
+		return err
+	}
+
+	newInstance := instance.Writable()
+
+	if d.HasChange("profiles") {
+		changed = true
+		var profiles []string
+		for _, p := range d.Get("profiles").([]interface{}) {
+			profiles = append(profiles, p.(string))
+		}
+
+		newInstance.Profiles = profiles
+
+		log.Printf("[DEBUG] Updated profiles: %#v", newInstance.Profiles)
+	}
+
+	if d.HasChange("device") {
+		changed = true
+		old, new := d.GetChange("device")
+		oldDevices := resourceLxdDevices(old)
+		newDevices := resourceLxdDevices(new)
+		if err := resourceLxdCheckNicNetworks(server, newDevices); err != nil {
+			return err
+		}
+
+		for n := range oldDevices {
+			delete(newInstance.Devices, n)
+		}
+
+		for n, d := range newDevices {
+			if n != "" {
+				newInstance.Devices[n] = d
+			}
+		}
+
+		for _, devices := range []map[string]map[string]string{oldDevices, newDevices} {
+			for n, dev := range devices {
+				if !reflect.DeepEqual(oldDevices[n], newDevices[n]) && instanceColdplugDevice(server, instance.Type, dev) {
+					coldplug = true
+				}
+			}
+		}
+
+		log.Printf("[DEBUG] Updated device list: %#v", newInstance.Devices)
+	}
+
+	if d.HasChange("config") {
+		changed = true
+		oldConfig, newConfig := d.GetChange("config")
+
+		for k := range oldConfig.(map[string]interface{}) {
+			delete(newInstance.Config, k)
+		}
+
+		for k, v := range newConfig.(map[string]interface{}) {
+			newInstance.Config[k] = v.(string)
+		}
+	}
+
+	if d.HasChange("description") {
+		changed = true
+		newInstance.Description = d.Get("description").(string)
+	}
+
+	if d.HasChange("metadata") {
+		changed = true
+		oldMetadata, newMetadata := d.GetChange("metadata")
+
+		for k := range oldMetadata.(map[string]interface{}) {
+			delete(newInstance.Config, fmt.Sprintf("user.%s", k))
+		}
+
+		for k, v := range newMetadata.(map[string]interface{}) {
+			newInstance.Config[fmt.Sprintf("user.%s", k)] = v.(string)
+		}
+	}
+
+	if d.HasChange("limits") {
+		changed = true
+		oldLimits, newLimits := d.GetChange("limits")
+
+		for k := range oldLimits.(map[string]interface{}) {
+			delete(newInstance.Config, fmt.Sprintf("limits.%s", k))
+		}
+
+		for k, v := range newLimits.(map[string]interface{}) {
+			newInstance.Config[fmt.Sprintf("limits.%s", k)] = v.(string)
+		}
+	}
+
+	if d.HasChange("resource_limits") {
+		changed = true
+		oldLimits, newLimits := d.GetChange("resource_limits")
+
+		for k := range instanceResourceLimits(oldLimits.([]interface{})) {
+			delete(newInstance.Config, k)
+		}
+
+		for k, v := range instanceResourceLimits(newLimits.([]interface{})) {
+			newInstance.Config[k] = v
+		}
+	}
+
+	for attr, key := range instanceConfigKeys {
+		if d.HasChange(attr) {
+			changed = true
+			newInstance.Config[key] = fmt.Sprint(d.Get(attr))
+		}
+	}
+
+	if d.HasChange("idmap") {
+		changed = true
+		idmap := d.Get("idmap").([]interface{})
+		if err := resourceLxdInstanceCheckIdmap(server, idmap); err != nil {
+			return err
+		}
+
+		if len(idmap) > 0 {
+			newInstance.Config["raw.idmap"] = instanceIdmap(idmap)
+		} else {
+			delete(newInstance.Config, "raw.idmap")
+		}
+	}
+
+	if d.HasChange("snapshot_schedule") {
+		changed = true
+		for _, key := range instanceSnapshotScheduleKeys {
+			delete(newInstance.Config, key)
+		}
+
+		for k, v := range instanceSnapshotSchedule(d.Get("snapshot_schedule").([]interface{})) {
+			newInstance.Config[k] = v
+		}
+	}
+
+	running := d.Get("running").(bool)
+
+	coldplug = coldplug && instance.Status == "Running"
+	if coldplug {
+		log.Printf("[DEBUG] Stopping instance %s to change its devices", name)
+		if err := resourceLxdInstanceStop(d, server, name, p.RefreshInterval); err != nil {
+			return err
+		}
+		instance.Status = "Stopped"
+
+		// The stop changed the etag.
+		_, etag, err = server.GetInstance(name)
+		if err != nil {
+			return err
+		}
+	}
+
+	if changed {
+		log.Printf("[DEBUG] Updating instance %s: %#v", name, newInstance)
+		op, err := server.UpdateInstance(name, newInstance, etag)
+		if err != nil {
+			return err
+		}
+		if err = op.Wait(); err != nil {
+			return err
+		}
+	}
+
+	if coldplug && running {
+		if err := resourceLxdInstanceSetState(server, name, "start", false, p.RefreshInterval); err != nil {
+			return err
+		}
+		instance.Status = "Running"
+
+		if err := resourceLxdInstanceWaitReady(d, server, name, p.RefreshInterval); err != nil {
+			return err
+		}
+	}
+
+	// Some keys are only applied when the instance starts.
+	changedKeys := resourceLxdInstanceChangedKeys(d)
+	if running && !coldplug && instance.Status == "Running" {
+		if instanceRestartOnChange(d.Get("restart_on_change").(string), instance.Type, changedKeys) {
+			log.Printf("[DEBUG] Restarting instance %s to apply %v", name, changedKeys)
+			if err := resourceLxdInstanceSetState(server, name, "restart", false, p.RefreshInterval); err != nil {
+				return err
+			}
+			d.Set("restart_pending", false)
+		} else if instanceRestartRequired(instance.Type, changedKeys) {
+			log.Printf("[DEBUG] Leaving instance %s to be restarted to apply %v", name, changedKeys)
+			d.Set("restart_pending", true)
+		}
+	} else if coldplug {
+		d.Set("restart_pending", false)
+	}
+
+	// The instance may already be in the wanted state,
+	// e.g. after having been stopped to be renamed.
+	if d.HasChange("running") && running != (instance.Status == "Running") {
+		if running {
+			if err := resourceLxdInstanceSetState(server, name, "start", false, p.RefreshInterval); err != nil {
+				return err
+			}
+
+			if err := resourceLxdInstanceWaitReady(d, server, name, p.RefreshInterval); err != nil {
+				return err
+			}
+		} else {
+			stop := resourceLxdInstanceStop
+			if d.Get("stateful").(bool) {
+				stop = resourceLxdInstanceStopStateful
+			}
+			if err := stop(d, server, name, p.RefreshInterval); err != nil {
+				return err
+			}
+		}
+		d.Set("restart_pending", false)
+	}
+
+	if d.HasChange("file") && !rebuilt {
+		old, new := d.GetChange("file")
+		oldFiles := make(map[string]interface{})
+		for _, v := range old.([]interface{}) {
+			f := v.(map[string]interface{})
+			oldFiles[f["target_path"].(string)] = f
+		}
+
+		var changedFiles []interface{}
+		for _, v := range new.([]interface{}) {
+			f := v.(map[string]interface{})
+			target := f["target_path"].(string)
+			if !reflect.DeepEqual(oldFiles[target], v) {
+				changedFiles = append(changedFiles, v)
+			}
+			delete(oldFiles, target)
+		}
+
+		// The files left are no longer managed.
+		for target := range oldFiles {
+			if err := instanceDeleteFile(server, name, target); err != nil {
+				return err
+			}
+		}
+
+		if err := resourceLxdInstanceUploadFiles(server, name, changedFiles); err != nil {
+			return err
+		}
+	}
+
+	// Only the commands added or changed since the last run are run.
+	if d.HasChange("exec") && !rebuilt {
+		old, new := d.GetChange("exec")
+		oldExecs := old.([]interface{})
+		for i, v := range new.([]interface{}) {
+			if i < len(oldExecs) && reflect.DeepEqual(oldExecs[i], v) {
+				continue
+			}
+
+			if err := resourceLxdInstanceExec(server, name, v.(map[string]interface{})); err != nil {
+				return err
+			}
+		}
+	}
+
+	return resourceLxdInstanceRead(d, meta)
+}
+
+// resourceLxdInstanceUploadFiles pushes the files of file blocks.
+func resourceLxdInstanceUploadFiles(server lxd.ContainerServer, name string, files []interface{}) error {
+	for _, v := range files {
+		f := v.(map[string]interface{})
+		file := File{
+			ContainerName:     name,
+			TargetFile:        f["target_path"].(string),
+			Content:           f["content"].(string),
+			Source:            f["source"].(string),
+			UID:               f["uid"].(int),
+			GID:               f["gid"].(int),
+			Mode:              f["mode"].(string),
+			CreateDirectories: f["create_directories"].(bool),
+		}
+
+		if err := instanceUploadFile(server, name, file); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resourceLxdInstanceConsoleOutput returns the last size bytes of the
+// console log of an instance. The output is empty when LXD can't read
+// the log, e.g. of virtual machines on older versions of LXD.
+func resourceLxdInstanceConsoleOutput(server lxd.ContainerServer, name string, size int) string {
+	r, err := server.GetInstanceConsoleLog(name, &lxd.InstanceConsoleLogArgs{})
+	if err != nil {
+		log.Printf("[DEBUG] Unable to read console log of instance %s: %s", name, err)
+		return ""
+	}
+	defer r.Close()
+
+	output, err := ioutil.ReadAll(r)
+	if err != nil {
+		log.Printf("[DEBUG] Unable to read console log of instance %s: %s", name, err)
+		return ""
+	}
+
+	// Keep whole characters when cutting the output.
+	if len(output) > size {
+		output = output[len(output)-size:]
+		for len(output) > 0 && output[0]&0xC0 == 0x80 {
+			output = output[1:]
+		}
+	}
+
+	return string(output)
+}
+
+// resourceLxdInstanceExec runs the command of an exec block in an
+// instance and waits for it to exit. Its output is logged, and the
+// error output is part of the error returned when the command fails.
+func resourceLxdInstanceExec(server lxd.ContainerServer, name string, exec map[string]interface{}) error {
+	var command []string
+	for _, v := range exec["command"].([]interface{}) {
+		command = append(command, v.(string))
+	}
+
+	req := api.InstanceExecPost{
+		Command:     command,
+		Environment: resourceLxdConfigMap(exec["environment"]),
+		Cwd:         exec["working_dir"].(string),
+		User:        uint32(exec["uid"].(int)),
+		Group:       uint32(exec["gid"].(int)),
+		WaitForWS:   true,
+	}
+
+	var stdout, stderr bytes.Buffer
+	dataDone := make(chan bool)
+	args := lxd.InstanceExecArgs{
+		Stdin:    ioutil.NopCloser(&bytes.Buffer{}),
+		Stdout:   nopWriteCloser{&stdout},
+		Stderr:   nopWriteCloser{&stderr},
+		DataDone: dataDone,
+	}
+
+	log.Printf("[DEBUG] Running %v in instance %s", command, name)
+	op, err := server.ExecInstance(name, req, &args)
+	if err != nil {
+		return fmt.Errorf("Unable to run %v in instance (%s): %s", command, name, err)
+	}
+
+	if err := op.Wait(); err != nil {
+		return fmt.Errorf("Error running %v in instance (%s): %s", command, name, err)
+	}
+
+	// Wait for the output to be received.
+	<-dataDone
+
+	log.Printf("[DEBUG] Output of %v in instance %s:\n%s", command, name, stdout.String())
+	if stderr.Len() > 0 {
+		log.Printf("[DEBUG] Error output of %v in instance %s:\n%s", command, name, stderr.String())
+	}
+
+	exitCode, _ := op.Get().Metadata["return"].(float64)
+	if exitCode != 0 && exec["fail_on_error"].(bool) {
+		return fmt.Errorf("Command %v in instance (%s) exited with code %d: %s",
+			command, name, int(exitCode), strings.TrimSpace(stderr.String()))
+	}
+
+	return nil
+}
+
+// nopWriteCloser adds a no-op Close method to a writer.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// resourceLxdInstanceRename renames an instance, keeping its volumes,
+// snapshots and addresses. LXD only renames stopped instances, so a
+// running instance is stopped for the duration of the rename.
+func resourceLxdInstanceRename(d *schema.ResourceData, server lxd.ContainerServer, refreshInterval time.Duration) error {
+	oldName := d.Id()
+	newName := d.Get("name").(string)
+
+	st, _, err := server.GetInstanceState(oldName)
+	if err != nil {
+		return err
+	}
+
+	running := st.Status == "Running"
+	if running {
+		if err := resourceLxdInstanceStop(d, server, oldName, refreshInterval); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("[DEBUG] Renaming instance %s to %s", oldName, newName)
+	op, err := server.RenameInstance(oldName, api.InstancePost{Name: newName})
+	if err != nil {
+		return fmt.Errorf("Unable to rename instance (%s): %s", oldName, err)
+	}
+
+	if err := op.Wait(); err != nil {
+		return fmt.Errorf("Error waiting for instance (%s) to be renamed: %s", oldName, err)
+	}
+
+	d.SetId(newName)
+
+	if running && d.Get("running").(bool) {
+		if err := resourceLxdInstanceSetState(server, newName, "start", false, refreshInterval); err != nil {
+			return err
+		}
+
+		if err := resourceLxdInstanceWaitReady(d, server, newName, refreshInterval); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resourceLxdInstanceRetarget relocates an instance to another member of
+// the cluster. Stateful running instances are migrated live, any other
+// running instance is stopped for the move and started again after it.
+func resourceLxdInstanceRetarget(d *schema.ResourceData, server lxd.ContainerServer, name string, refreshInterval time.Duration) error {
+	target := d.Get("target").(string)
+	if target == "" {
+		// Leaving the placement to the cluster keeps the instance in place.
+		return nil
+	}
+
+	// Instances already on a member of a cluster group
+	// stay where they are when targeted at the group.
+	if strings.HasPrefix(target, "@") {
+		inGroup, err := resourceLxdInstanceInClusterGroup(server, name, target[1:])
+		if err != nil {
+			return err
+		}
+		if inGroup {
+			return nil
+		}
+	}
+
+	st, _, err := server.GetInstanceState(name)
+	if err != nil {
+		return err
+	}
+
+	running := st.Status == "Running"
+	live := running && d.Get("stateful").(bool)
+	if running && !live {
+		if err := resourceLxdInstanceStop(d, server, name, refreshInterval); err != nil {
+			return err
+		}
+	}
+
+	if err := resourceLxdInstanceMoveMember(server, name, target, live); err != nil {
+		return err
+	}
+
+	if running && !live && d.Get("running").(bool) {
+		if err := resourceLxdInstanceSetState(server, name, "start", false, refreshInterval); err != nil {
+			return err
+		}
+
+		if err := resourceLxdInstanceWaitReady(d, server, name, refreshInterval); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resourceLxdInstanceInClusterGroup returns whether an instance is on
+// a member of a cluster group.
+func resourceLxdInstanceInClusterGroup(server lxd.ContainerServer, name, group string) (bool, error) {
+	instance, _, err := server.GetInstance(name)
+	if err != nil {
+		return false, err
+	}
+
+	clusterGroup, _, err := server.GetClusterGroup(group)
+	if err != nil {
+		return false, fmt.Errorf("Unable to get cluster group %s: %s", group, err)
+	}
+
+	for _, member := range clusterGroup.Members {
+		if member == instance.Location {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func resourceLxdInstanceDelete(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	name := d.Id()
+
+	// Frozen instances are stopped too, and the ones already
+	// gone, e.g. ephemeral instances stopped outside of Terraform,
+	// don't need to be deleted.
+	instance, _, err := server.GetInstance(name)
+	if err != nil {
+		if err.Error() == "not found" {
+			return nil
+		}
+		return err
+	}
+
+	// Protected instances are left untouched, rather than
+	// stopped before LXD refuses to delete them.
+	if instance.ExpandedConfig["security.protection.delete"] == "true" {
+		return fmt.Errorf("Instance (%s) is protected from deletion, set protected to false to destroy it", name)
+	}
+
+	st, _, err := server.GetInstanceState(name)
+	if err != nil {
+		if err.Error() == "not found" {
+			return nil
+		}
+		return err
+	}
+
+	if st.Status != "Stopped" {
+		if err := resourceLxdInstanceStop(d, server, name, p.RefreshInterval); err != nil {
+			return err
+		}
+	}
+
+	op, err := server.DeleteInstance(name)
+	if err != nil {
+		// Ephemeral instances are deleted when they stop.
+		if err.Error() == "not found" {
+			return nil
+		}
+		return err
+	}
+
+	// Wait for the instance to be deleted
+	return op.Wait()
+}
+
+func resourceLxdInstanceExists(d *schema.ResourceData, meta interface{}) (bool, error) {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return false, err
+	}
+
+	_, _, err = server.GetInstance(d.Id())
+	if err != nil {
+		if err.Error() == "not found" {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// resourceLxdInstanceImport imports an instance from an ID of the form
+// [remote:][project/]name. The image of an imported instance is not
+// known, so its fingerprint stands for it.
+func resourceLxdInstanceImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	p := meta.(*lxdProvider)
+	log.Printf("[DEBUG] Starting import for %s", d.Id())
+
+	remote, name, err := p.LXDConfig.ParseRemote(d.Id())
+	if err != nil {
+		return nil, err
+	}
+
+	if p.LXDConfig.DefaultRemote != remote {
+		d.Set("remote", remote)
+	}
+
+	if parts := strings.SplitN(name, "/", 2); len(parts) == 2 {
+		d.Set("project", parts[0])
+		name = parts[1]
+	}
+
+	server, err := p.selectServer(d)
+	if err != nil {
+		return nil, err
+	}
+
+	instance, _, err := server.GetInstance(name)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to get instance (%s): %s", name, err)
+	}
+
+	log.Printf("[DEBUG] Import instance %#v", instance)
+	d.SetId(name)
+	d.Set("name", name)
+	d.Set("image", instance.Config["volatile.base_image"])
+
+	// All the user keys of an imported instance are managed.
+	metadata := make(map[string]string)
+	for k, v := range instance.Config {
+		if strings.HasPrefix(k, "user.") {
+			metadata[strings.TrimPrefix(k, "user.")] = v
+		}
+	}
+	d.Set("metadata", metadata)
+	d.Set("restart_pending", false)
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// resourceLxdInstanceDiffServer returns a client for the remote
+// and project of an instance, or of a resource of an instance,
+// when planning.
+func resourceLxdInstanceDiffServer(d *schema.ResourceDiff, p *lxdProvider) (lxd.ContainerServer, error) {
+	remote := d.Get("remote").(string)
+	if remote == "" {
+		remote = p.LXDConfig.DefaultRemote
+	}
+
+	server, err := p.GetContainerServer(remote)
+	if err != nil {
+		return nil, err
+	}
+
+	if project := d.Get("project").(string); project != "" {
+		server = server.UseProject(project)
+	}
+
+	return server, nil
+}
+
+// suppressInstanceImageDifferences ignores the image of imported
+// instances, which is the fingerprint of the image they were created
+// from rather than the alias it was referred to by.
+func suppressInstanceImageDifferences(k, old, new string, d *schema.ResourceData) bool {
+	if d.Id() == "" {
+		return false
+	}
+	return old == "" || old == d.Get("image_fingerprint").(string)
+}
+
+func resourceLxdInstanceCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	if err := resourceLxdValidateDevicesDiff(d); err != nil {
+		return err
+	}
+
+	if d.Id() != "" && d.HasChange("device") {
+		if err := resourceLxdInstanceCheckRootResize(d, meta.(*lxdProvider)); err != nil {
+			return err
+		}
+	}
+
+	// The image can only change in place by rebuilding the instance.
+	if d.Id() != "" && d.HasChange("image") && !d.Get("rebuild_on_image_change").(bool) {
+		if err := d.ForceNew("image"); err != nil {
+			return err
+		}
+	}
+
+	// Profiles override each other in order, reordering them is a
+	// change unless told otherwise.
+	if d.Id() != "" && d.HasChange("profiles") && d.NewValueKnown("profiles") {
+		o, n := d.GetChange("profiles")
+		if sameProfiles(o.([]interface{}), n.([]interface{})) {
+			if d.Get("ignore_profile_order").(bool) {
+				if err := d.Clear("profiles"); err != nil {
+					return err
+				}
+			} else {
+				log.Printf("[INFO] Only the order of the profiles of instance %s changes, from %v to %v", d.Id(), o, n)
+			}
+		}
+	}
+
+	if d.Id() == "" && d.NewValueKnown("image") && d.NewValueKnown("source_instance") && d.NewValueKnown("source_backup") &&
+		d.Get("image").(string) == "" && len(d.Get("source_instance").([]interface{})) == 0 &&
+		d.Get("source_backup").(string) == "" {
+		return fmt.Errorf("one of image, source_instance or source_backup must be set")
+	}
+
+	if d.Get("csm").(bool) && d.Get("secureboot").(bool) {
+		return fmt.Errorf("csm can't be enabled along with secureboot, set secureboot to false")
+	}
+
+	config := d.Get("config").(map[string]interface{})
+	for k := range d.Get("metadata").(map[string]interface{}) {
+		if _, ok := config["user."+k]; ok {
+			return fmt.Errorf("metadata %s conflicts with user.%s in config", k, k)
+		}
+	}
+
+	// Unknown keys are reported when planning, rather than by LXD
+	// when applying.
+	if (d.Id() == "" || d.HasChange("config") || d.HasChange("limits")) &&
+		d.NewValueKnown("config") && d.NewValueKnown("limits") {
+		keys := resourceLxdConfigMap(config)
+		keys = resourceLxdConfigMapAppend(keys, d.Get("limits"), "limits.")
+		if err := resourceLxdCheckConfigKeys(d, meta.(*lxdProvider), "instance", keys); err != nil {
+			return err
+		}
+	}
+
+	if d.NewValueKnown("resource_limits") {
+		limits := d.Get("limits").(map[string]interface{})
+		for key := range instanceResourceLimits(d.Get("resource_limits").([]interface{})) {
+			if _, ok := config[key]; ok {
+				return fmt.Errorf("resource_limits conflicts with %s in config", key)
+			}
+			if _, ok := limits[strings.TrimPrefix(key, "limits.")]; ok {
+				return fmt.Errorf("resource_limits conflicts with %s in limits", strings.TrimPrefix(key, "limits."))
+			}
+		}
+	}
+
+	if _, ok := d.GetOk("snapshot_schedule"); ok {
+		for _, key := range instanceSnapshotScheduleKeys {
+			if _, ok := config[key]; ok {
+				return fmt.Errorf("snapshot_schedule conflicts with %s in config", key)
+			}
+		}
+	}
+
+	if _, ok := d.GetOk("idmap"); ok {
+		if d.Get("type").(string) != "container" {
+			return fmt.Errorf("idmap can only be set on instances of type container")
+		}
+		if _, ok := config["raw.idmap"]; ok {
+			return fmt.Errorf("idmap conflicts with raw.idmap in config")
+		}
+	}
+
+	for attr, instType := range instanceAttributeTypes {
+		if v, ok := d.GetOk(attr); ok && v.(bool) && d.Get("type").(string) != instType {
+			return fmt.Errorf("%s can only be enabled on instances of type %s", attr, instType)
+		}
+	}
+
+	// Files are pushed to virtual machines through their agent.
+	if _, ok := d.GetOk("file"); ok && !d.Get("running").(bool) && d.Get("type").(string) == "virtual-machine" &&
+		(d.Id() == "" || d.HasChange("file")) {
+		return fmt.Errorf("file blocks of virtual machines can only be pushed when running is true")
+	}
+
+	// Commands can only be run in running instances.
+	if _, ok := d.GetOk("exec"); ok && !d.Get("running").(bool) && (d.Id() == "" || d.HasChange("exec")) {
+		return fmt.Errorf("exec blocks can only be run when running is true")
+	}
+
+	// Show in the plan that the instance is going to be started,
+	// stopped or restarted. Starting an instance applies all of its
+	// config, and a stopped one has nothing left to apply, so no restart
+	// is pending either way.
+	if d.Id() != "" && d.HasChange("running") {
+		if err := d.SetNew("restart_pending", false); err != nil {
+			return err
+		}
+		return d.SetNewComputed("status")
+	}
+
+	if d.Id() != "" && d.Get("status").(string) == "Running" {
+		instType := d.Get("type").(string)
+		keys := resourceLxdInstanceChangedKeys(d)
+
+		if instanceRestartOnChange(d.Get("restart_on_change").(string), instType, keys) {
+			if err := d.SetNew("restart_pending", false); err != nil {
+				return err
+			}
+			return d.SetNewComputed("status")
+		}
+
+		if instanceRestartRequired(instType, keys) {
+			return d.SetNew("restart_pending", true)
+		}
+	}
+
+	return nil
+}
+
+// sameProfiles reports whether two lists of profiles hold the same
+// profiles, regardless of their order.
+func sameProfiles(a, b []interface{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	count := make(map[string]int, len(a))
+	for _, v := range a {
+		count[v.(string)]++
+	}
+	for _, v := range b {
+		count[v.(string)]--
+		if count[v.(string)] < 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// resourceLxdInstanceCheckRootResize checks that a new size of the root
+// disk of an instance can be applied in place. Volumes can always grow,
+// but the block volumes of virtual machines can't shrink, and neither
+// can the ones of containers on lvm and ceph pools, unless formatted
+// with ext4.
+func resourceLxdInstanceCheckRootResize(d *schema.ResourceDiff, p *lxdProvider) error {
+	o, n := d.GetChange("device")
+	oldRoot := instanceRootDisk(resourceLxdDevices(o))
+	newRoot := instanceRootDisk(resourceLxdDevices(n))
+	if oldRoot == nil || newRoot == nil || oldRoot["pool"] != newRoot["pool"] ||
+		oldRoot["size"] == "" || newRoot["size"] == "" {
+		return nil
+	}
+
+	oldSize, err := units.ParseByteSizeString(oldRoot["size"])
+	if err != nil {
+		return nil
+	}
+	newSize, err := units.ParseByteSizeString(newRoot["size"])
+	if err != nil || newSize >= oldSize {
+		return nil
+	}
+
+	if d.Get("type").(string) == "virtual-machine" {
+		return fmt.Errorf("The root disk of a virtual machine can't shrink, from %s to %s",
+			oldRoot["size"], newRoot["size"])
+	}
+
+	server, err := resourceLxdInstanceDiffServer(d, p)
+	if err != nil {
+		return err
+	}
+
+	pool, _, err := server.GetStoragePool(newRoot["pool"])
+	if err != nil {
+		return nil
+	}
+
+	if pool.Driver == "lvm" || pool.Driver == "ceph" {
+		fs := pool.Config["volume.block.filesystem"]
+		if fs == "" {
+			fs = "ext4"
+		}
+
+		if fs != "ext4" {
+			return fmt.Errorf("The root disk can't shrink from %s to %s on %s pool %s, which formats volumes with %s",
+				oldRoot["size"], newRoot["size"], pool.Driver, pool.Name, fs)
+		}
+	}
+
+	return nil
+}
+
+// instanceRootDisk returns the root disk among the devices
+// of an instance, or nil when it comes from a profile.
+func instanceRootDisk(devices map[string]map[string]string) map[string]string {
+	for _, device := range devices {
+		if device["type"] == "disk" && device["path"] == "/" {
+			return device
+		}
+	}
+	return nil
+}
+
+// instanceRestartKeys lists, per instance type, the config keys
+// LXD can't apply to a running instance. Entries ending with a dot
+// match all the keys starting with them.
+var instanceRestartKeys = map[string][]string{
+	"container": {
+		"linux.kernel_modules", "nvidia.", "raw.idmap", "raw.lxc", "raw.seccomp",
+		"security.idmap.", "security.privileged", "security.syscalls.",
+	},
+	"virtual-machine": {
+		"agent.", "limits.cpu", "limits.memory", "raw.qemu", "security.csm",
+		"security.secureboot", "security.sev",
+	},
+}
+
+// instanceRestartRequired reports whether any of the changed config
+// keys needs a restart of an instance of the given type to apply.
+func instanceRestartRequired(instType string, keys []string) bool {
+	for _, key := range keys {
+		for _, k := range instanceRestartKeys[instType] {
+			if key == k || (strings.HasSuffix(k, ".") && strings.HasPrefix(key, k)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// instanceRestartOnChange reports whether an instance is restarted
+// after its config keys changed, following its restart_on_change
+// policy: always, never, or when one of the keys requires it.
+func instanceRestartOnChange(policy, instType string, keys []string) bool {
+	switch policy {
+	case "always":
+		return len(keys) > 0
+	case "never":
+		return false
+	}
+	return instanceRestartRequired(instType, keys)
+}
+
+// resourceLxdInstanceChangedKeys returns the config keys changed
+// through the config, limits, resource_limits, idmap and shorthand
+// attributes.
+func resourceLxdInstanceChangedKeys(d interface {
+	GetChange(string) (interface{}, interface{})
+}) []string {
+	var keys []string
+	for attr, prefix := range map[string]string{"config": "", "limits": "limits."} {
+		o, n := d.GetChange(attr)
+		oldMap, _ := o.(map[string]interface{})
+		newMap, _ := n.(map[string]interface{})
+
+		for k, v := range newMap {
+			if ov, ok := oldMap[k]; !ok || ov != v {
+				keys = append(keys, prefix+k)
+			}
+		}
+
+		for k := range oldMap {
+			if _, ok := newMap[k]; !ok {
+				keys = append(keys, prefix+k)
+			}
+		}
+	}
+
+	o, n := d.GetChange("resource_limits")
+	oldLimits, _ := o.([]interface{})
+	newLimits, _ := n.([]interface{})
+	oldKeys, newKeys := instanceResourceLimits(oldLimits), instanceResourceLimits(newLimits)
+	for k, v := range newKeys {
+		if ov, ok := oldKeys[k]; !ok || ov != v {
+			keys = append(keys, k)
+		}
+	}
+	for k := range oldKeys {
+		if _, ok := newKeys[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+
+	for attr, key := range instanceConfigKeys {
+		if o, n := d.GetChange(attr); o != n {
+			keys = append(keys, key)
+		}
+	}
+
+	if o, n := d.GetChange("idmap"); !reflect.DeepEqual(o, n) {
+		keys = append(keys, "raw.idmap")
+	}
+	return keys
+}
+
+// resourceLxdValidateWaitForType validates the type of a wait_for block.
+func resourceLxdValidateWaitForType(v interface{}, k string) (ws []string, errors []error) {
+	switch v.(string) {
+	case "ipv4", "ipv6", "cloud-init", "agent":
+	default:
+		errors = append(errors, fmt.Errorf(
+			"Only ipv4, ipv6, cloud-init and agent are supported values for '%s'", k))
+	}
+
+	return
+}
+
+// resourceLxdValidateTarget validates the target of an instance, a
+// cluster member or a cluster group prefixed with @.
+func resourceLxdValidateTarget(v interface{}, k string) (ws []string, errors []error) {
+	if v.(string) == "@" {
+		errors = append(errors, fmt.Errorf("'%s' must name a cluster group after @, e.g. @gpu", k))
+	}
+
+	return
+}
+
+// resourceLxdValidateCopyMode validates the transfer mode of a copy.
+func resourceLxdValidateCopyMode(v interface{}, k string) (ws []string, errors []error) {
+	switch v.(string) {
+	case "pull", "push", "relay":
+	default:
+		errors = append(errors, fmt.Errorf(
+			"Only pull, push and relay are supported values for '%s'", k))
+	}
+
+	return
+}
+
+// resourceLxdValidateMetadata validates the keys of the metadata
+// of an instance, which are set with the user. prefix.
+func resourceLxdValidateMetadata(v interface{}, k string) (ws []string, errors []error) {
+	for key := range v.(map[string]interface{}) {
+		if strings.HasPrefix(key, "user.") {
+			errors = append(errors, fmt.Errorf(
+				"The keys of '%s' are set with the user. prefix, %q must leave it out", k, key))
+		}
+	}
+
+	return
+}
+
+// resourceLxdValidateConsoleOutputSize validates the number of
+// bytes of the console log of an instance to read.
+func resourceLxdValidateConsoleOutputSize(v interface{}, k string) (ws []string, errors []error) {
+	if v.(int) < 0 {
+		errors = append(errors, fmt.Errorf("'%s' can't be negative", k))
+	}
+
+	return
+}
+
+// resourceLxdValidateRestartOnChange validates the restart_on_change
+// policy of an instance.
+func resourceLxdValidateRestartOnChange(v interface{}, k string) (ws []string, errors []error) {
+	switch v.(string) {
+	case "never", "always", "when-required":
+	default:
+		errors = append(errors, fmt.Errorf(
+			"Only never, always and when-required are supported values for '%s'", k))
+	}
+
+	return
+}
+
+// resourceLxdValidateIdmapType validates the type of an idmap block.
+func resourceLxdValidateIdmapType(v interface{}, k string) (ws []string, errors []error) {
+	switch v.(string) {
+	case "uid", "gid", "both":
+	default:
+		errors = append(errors, fmt.Errorf(
+			"Only uid, gid and both are supported values for '%s'", k))
+	}
+
+	return
+}
+
+// resourceLxdValidateIdmapID validates the IDs of an idmap block.
+func resourceLxdValidateIdmapID(v interface{}, k string) (ws []string, errors []error) {
+	if id := v.(int); id < 0 || int64(id) > maxIdmapID {
+		errors = append(errors, fmt.Errorf("'%s' must be between 0 and %d", k, maxIdmapID))
+	}
+
+	return
+}
+
+// resourceLxdValidateIdmapRange validates the range of an idmap block.
+func resourceLxdValidateIdmapRange(v interface{}, k string) (ws []string, errors []error) {
+	if v.(int) < 1 {
+		errors = append(errors, fmt.Errorf("'%s' must be at least 1", k))
+	}
+
+	return
+}
+
+// maxIdmapID is the largest valid uid or gid.
+const maxIdmapID = 1<<32 - 2
+
+// instanceSnapshotScheduleKeys maps the attributes of
+// a snapshot_schedule block to the config keys they set.
+var instanceSnapshotScheduleKeys = map[string]string{
+	"schedule": "snapshots.schedule",
+	"pattern":  "snapshots.pattern",
+	"expiry":   "snapshots.expiry",
+	"stopped":  "snapshots.schedule.stopped",
+}
+
+// instanceSnapshotSchedule returns the config keys
+// set by the snapshot_schedule block of an instance.
+func instanceSnapshotSchedule(v []interface{}) map[string]string {
+	config := make(map[string]string)
+	if len(v) == 0 || v[0] == nil {
+		return config
+	}
+
+	for attr, value := range v[0].(map[string]interface{}) {
+		if s := fmt.Sprint(value); s != "" && (attr != "stopped" || value.(bool)) {
+			config[instanceSnapshotScheduleKeys[attr]] = s
+		}
+	}
+	return config
+}
+
+// instanceResourceLimitKeys maps the attributes of
+// a resource_limits block to the config keys they set.
+var instanceResourceLimitKeys = map[string]string{
+	"cpu":                  "limits.cpu",
+	"cpu_allowance":        "limits.cpu.allowance",
+	"cpu_priority":         "limits.cpu.priority",
+	"memory":               "limits.memory",
+	"memory_enforce":       "limits.memory.enforce",
+	"memory_swap":          "limits.memory.swap",
+	"memory_swap_priority": "limits.memory.swap.priority",
+	"disk_priority":        "limits.disk.priority",
+	"network_priority":     "limits.network.priority",
+	"processes":            "limits.processes",
+}
+
+// instanceResourceLimitsSchema returns the attributes of a
+// resource_limits block, each checked like the limit it sets.
+func instanceResourceLimitsSchema() map[string]*schema.Schema {
+	attrs := make(map[string]*schema.Schema)
+	for attr, key := range instanceResourceLimitKeys {
+		attrs[attr] = &schema.Schema{
+			Type:         schema.TypeString,
+			Optional:     true,
+			ValidateFunc: resourceLxdValidateLimit(strings.TrimPrefix(key, "limits.")),
+		}
+	}
+	return attrs
+}
+
+// instanceResourceLimits returns the config keys
+// set by the resource_limits block of an instance.
+func instanceResourceLimits(v []interface{}) map[string]string {
+	config := make(map[string]string)
+	if len(v) == 0 || v[0] == nil {
+		return config
+	}
+
+	for attr, value := range v[0].(map[string]interface{}) {
+		if s, ok := value.(string); ok && s != "" {
+			config[instanceResourceLimitKeys[attr]] = s
+		}
+	}
+	return config
+}
+
+// instanceIdmap renders idmap blocks as a raw.idmap value,
+// one mapping per line, e.g. "both 1000 1000" or
+// "uid 1000-1009 2000-2009".
+func instanceIdmap(idmap []interface{}) string {
+	lines := make([]string, 0, len(idmap))
+	for _, v := range idmap {
+		m := v.(map[string]interface{})
+		host, inst, n := m["host_id"].(int), m["instance_id"].(int), m["range"].(int)
+		if n > 1 {
+			lines = append(lines, fmt.Sprintf("%s %d-%d %d-%d", m["type"], host, host+n-1, inst, inst+n-1))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s %d %d", m["type"], host, inst))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parseInstanceIdmap parses a raw.idmap value into idmap blocks.
+func parseInstanceIdmap(raw string) ([]interface{}, error) {
+	idmap := []interface{}{}
+	for _, line := range strings.Split(raw, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid idmap entry %q", line)
+		}
+		if _, errs := resourceLxdValidateIdmapType(fields[0], "type"); len(errs) > 0 {
+			return nil, errs[0]
+		}
+
+		host, hostRange, err := parseIdmapRange(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		inst, instRange, err := parseIdmapRange(fields[2])
+		if err != nil {
+			return nil, err
+		}
+		if hostRange != instRange {
+			return nil, fmt.Errorf("ranges of idmap entry %q differ in size", line)
+		}
+
+		idmap = append(idmap, map[string]interface{}{
+			"type":        fields[0],
+			"host_id":     host,
+			"instance_id": inst,
+			"range":       hostRange,
+		})
+	}
+	return idmap, nil
+}
+
+// parseIdmapRange parses an ID or an inclusive range of IDs,
+// such as "1000-1009", returning its start and size.
+func parseIdmapRange(v string) (int, int, error) {
+	parts := strings.SplitN(v, "-", 2)
+	start, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid idmap ID %q", v)
+	}
+
+	end := start
+	if len(parts) == 2 {
+		if end, err = strconv.Atoi(parts[1]); err != nil || end < start {
+			return 0, 0, fmt.Errorf("invalid idmap range %q", v)
+		}
+	}
+	return start, end - start + 1, nil
+}
+
+// resourceLxdInstanceCheckIdmap checks that the host IDs of idmap
+// blocks are delegated to root in /etc/subuid and /etc/subgid, which
+// LXD requires. The files can only be read when the server is reached
+// through its local unix socket, and are ignored if they don't
+// delegate anything to root.
+func resourceLxdInstanceCheckIdmap(server lxd.ContainerServer, idmap []interface{}) error {
+	info, err := server.GetConnectionInfo()
+	if err != nil || info.SocketPath == "" {
+		return nil
+	}
+
+	files := map[string][]string{
+		"uid":  {"/etc/subuid"},
+		"gid":  {"/etc/subgid"},
+		"both": {"/etc/subuid", "/etc/subgid"},
+	}
+
+	for _, v := range idmap {
+		m := v.(map[string]interface{})
+		host, n := int64(m["host_id"].(int)), int64(m["range"].(int))
+
+		for _, file := range files[m["type"].(string)] {
+			ranges := readSubIDRanges(file, "root")
+			if len(ranges) == 0 {
+				continue
+			}
+
+			allowed := false
+			for _, r := range ranges {
+				if host >= r[0] && host+n <= r[0]+r[1] {
+					allowed = true
+					break
+				}
+			}
+			if !allowed {
+				return fmt.Errorf("host IDs %d-%d of idmap aren't delegated to root in %s", host, host+n-1, file)
+			}
+		}
+	}
+
+	return nil
+}
+
+// readSubIDRanges returns the start and size of the
+// ranges a subuid or subgid file delegates to a user.
+func readSubIDRanges(file, user string) [][2]int64 {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+
+	var ranges [][2]int64
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Split(strings.TrimSpace(line), ":")
+		if len(fields) != 3 || fields[0] != user {
+			continue
+		}
+
+		start, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			continue
+		}
+		ranges = append(ranges, [2]int64{start, size})
+	}
+	return ranges
+}
+
+// instanceColdplugDevice reports whether a device can only be added
+// to, changed on, or removed from a stopped instance of the given type.
+// This is the case of TPMs, and of the ISO images of virtual machines,
+// which are attached as CD-ROM drives.
+func instanceColdplugDevice(server lxd.ContainerServer, instType string, device map[string]string) bool {
+	switch {
+	case device["type"] == "tpm":
+		return true
+	case device["type"] == "disk" && instType == "virtual-machine":
+		if pool := device["pool"]; pool != "" && device["source"] != "" {
+			vol, _, err := server.GetStoragePoolVolume(pool, "custom", device["source"])
+			return err == nil && vol.ContentType == "iso"
+		}
+		return strings.HasSuffix(strings.ToLower(device["source"]), ".iso")
+	}
+	return false
+}
+
+// instanceConfigKeys maps the attributes of an instance
+// that are shorthands for config keys to the keys they set.
+var instanceConfigKeys = map[string]string{
+	"privileged":       "security.privileged",
+	"nesting":          "security.nesting",
+	"idmap_isolated":   "security.idmap.isolated",
+	"secureboot":       "security.secureboot",
+	"protected":        "security.protection.delete",
+	"csm":              "security.csm",
+	"sev":              "security.sev",
+	"agent_nic_config": "agent.nic_config",
+	"autostart":        "boot.autostart",
+	"boot_priority":    "boot.autostart.priority",
+	"stop_priority":    "boot.stop.priority",
+}
+
+// instanceAttributeTypes lists the attributes
+// that only apply to one type of instance.
+var instanceAttributeTypes = map[string]string{
+	"privileged":       "container",
+	"nesting":          "container",
+	"idmap_isolated":   "container",
+	"secureboot":       "virtual-machine",
+	"csm":              "virtual-machine",
+	"sev":              "virtual-machine",
+	"agent_nic_config": "virtual-machine",
+}
+
+// instanceCloudInitKeys maps the cloud-init attributes
+// of an instance to the config keys they set.
+var instanceCloudInitKeys = map[string]string{
+	"user_data":      "cloud-init.user-data",
+	"vendor_data":    "cloud-init.vendor-data",
+	"network_config": "cloud-init.network-config",
+}
+
+// suppressCloudInitDifferences ignores differences in cloud-init data
+// that don't change its meaning, such as trailing spaces, blank lines or
+// the order of YAML keys.
+func suppressCloudInitDifferences(k, old, new string, d *schema.ResourceData) bool {
+	return normalizeCloudInit(old) == normalizeCloudInit(new)
+}
+
+// normalizeCloudInit returns cloud-init data in a form fit for comparison.
+// YAML data is re-encoded with sorted keys, keeping its header line such
+// as #cloud-config. Anything else, such as scripts, only loses its
+// trailing spaces and blank lines.
+func normalizeCloudInit(v string) string {
+	lines := strings.Split(strings.Replace(v, "\r\n", "\n", -1), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	v = strings.TrimSpace(strings.Join(lines, "\n"))
+
+	if strings.HasPrefix(v, "#!") {
+		return v
+	}
+
+	var header string
+	if strings.HasPrefix(v, "#") {
+		header = strings.SplitN(v, "\n", 2)[0]
+	}
+
+	var data interface{}
+	if err := yaml.Unmarshal([]byte(v), &data); err != nil {
+		return v
+	}
+
+	out, err := yaml.Marshal(data)
+	if err != nil {
+		return v
+	}
+
+	return header + "\n" + strings.TrimSpace(string(out))
+}
+
+// resourceLxdInstanceSetState starts or stops an instance and waits
+// until LXD reports the resulting status. A stateful stop keeps the
+// runtime state of the instance, which is restored on the next start.
+func resourceLxdInstanceSetState(server lxd.ContainerServer, name, action string, stateful bool, refreshInterval time.Duration) error {
+	req := api.InstanceStatePut{
+		Action:   action,
+		Timeout:  updateTimeout,
+		Stateful: stateful,
+	}
+
+	return resourceLxdInstanceUpdateState(server, name, req, refreshInterval)
+}
+
+// resourceLxdInstanceStop stops an instance, giving it shutdown_timeout
+// to shut down cleanly before forcing it to stop. Frozen instances can't
+// shut down, so they are forced to stop right away.
+func resourceLxdInstanceStop(d *schema.ResourceData, server lxd.ContainerServer, name string, refreshInterval time.Duration) error {
+	timeout := time.Duration(updateTimeout) * time.Second
+	if v := d.Get("shutdown_timeout").(string); v != "" {
+		timeout, _ = time.ParseDuration(v)
+	}
+
+	st, _, err := server.GetInstanceState(name)
+	if err != nil {
+		return err
+	}
+
+	if st.Status == "Stopped" {
+		return nil
+	}
+
+	if st.Status != "Frozen" && timeout > 0 {
+		req := api.InstanceStatePut{
+			Action:  "stop",
+			Timeout: int(timeout.Seconds()),
+		}
+
+		err := resourceLxdInstanceUpdateState(server, name, req, refreshInterval)
+		if err == nil {
+			return nil
+		}
+		log.Printf("[DEBUG] Forcing instance %s to stop: %s", name, err)
+	}
+
+	req := api.InstanceStatePut{
+		Action: "stop",
+		Force:  true,
+	}
+
+	return resourceLxdInstanceUpdateState(server, name, req, refreshInterval)
+}
+
+// resourceLxdInstanceStopStateful stops an instance, saving its
+// runtime state so that it resumes where it left off when started.
+func resourceLxdInstanceStopStateful(d *schema.ResourceData, server lxd.ContainerServer, name string, refreshInterval time.Duration) error {
+	err := resourceLxdInstanceSetState(server, name, "stop", true, refreshInterval)
+	return resourceLxdStatefulError(err)
+}
+
+// resourceLxdInstanceUpdateState sends a state change request
+// for an instance and waits for it to reach the new state.
+func resourceLxdInstanceUpdateState(server lxd.ContainerServer, name string, req api.InstanceStatePut, refreshInterval time.Duration) error {
+	action := req.Action
+	target := "Running"
+	if action == "stop" {
+		target = "Stopped"
+	}
+
+	op, err := server.UpdateInstanceState(name, req, "")
+	if err != nil {
+		return fmt.Errorf("LXD server rejected request to %s instance (%s): %s", action, name, err)
+	}
+
+	if err := op.Wait(); err != nil {
+		return fmt.Errorf("Error waiting for instance (%s) to %s: %s", name, action, err)
+	}
+
+	// Even though op.Wait has completed,
+	// wait until we can see the new status via a new API call.
+	// At a minimum, this adds some padding between API calls.
+	refresh := resourceLxdInstanceRefresh(server, name)
+	if action == "stop" {
+		refresh = resourceLxdInstanceRefreshStopped(server, name)
+	}
+
+	stateConf := &resource.StateChangeConf{
+		Target:     []string{target},
+		Refresh:    refresh,
+		Timeout:    3 * time.Minute,
+		Delay:      refreshInterval,
+		MinTimeout: 3 * time.Second,
+	}
+
+	if _, err := stateConf.WaitForState(); err != nil {
+		return fmt.Errorf("Error waiting for instance (%s) to %s: %s", name, action, err)
+	}
+
+	return nil
+}
+
+func resourceLxdInstanceRefresh(server lxd.ContainerServer, name string) resource.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		st, _, err := server.GetInstanceState(name)
+		if err != nil {
+			return st, "Error", err
+		}
+
+		return st, st.Status, nil
+	}
+}
+
+// resourceLxdInstanceRefreshStopped refreshes the status of an instance
+// being stopped. Ephemeral instances are deleted once they stop, so an
+// instance which is gone is reported as stopped.
+func resourceLxdInstanceRefreshStopped(server lxd.ContainerServer, name string) resource.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		st, status, err := resourceLxdInstanceRefresh(server, name)()
+		if err != nil && err.Error() == "not found" {
+			return &api.InstanceState{Status: "Stopped"}, "Stopped", nil
+		}
+
+		return st, status, err
+	}
+}
+
+// resourceLxdInstanceWaitForAgent waits for the LXD agent of a virtual
+// machine, which LXD needs to report processes and network state.
+func resourceLxdInstanceWaitForAgent(server lxd.ContainerServer, name string) resource.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		st, _, err := server.GetInstanceState(name)
+		if err != nil {
+			return st, "Error", err
+		}
+
+		if st.Processes > 0 {
+			return st, "OK", nil
+		}
+
+		return st, "NOT READY", nil
+	}
+}
+
+// resourceLxdInstanceWaitForAddress waits for a global address of the
+// given family, on the given interface or on any but the loopback.
+func resourceLxdInstanceWaitForAddress(server lxd.ContainerServer, name, iface, family string) resource.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		st, _, err := server.GetInstanceState(name)
+		if err != nil {
+			return st, "Error", err
+		}
+
+		for n, net := range st.Network {
+			if (iface != "" && n != iface) || (iface == "" && n == "lo") {
+				continue
+			}
+
+			for _, ip := range net.Addresses {
+				if ip.Family == family && ip.Scope == "global" {
+					return st, "OK", nil
+				}
+			}
+		}
+		return st, "NOT FOUND", nil
+	}
+}
+
+// resourceLxdInstanceWaitForCloudInit waits for cloud-init to finish,
+// which it records by writing its result file. Errors reported by
+// cloud-init fail the wait.
+func resourceLxdInstanceWaitForCloudInit(server lxd.ContainerServer, name string) resource.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		// The file can't be read until it exists, nor before
+		// the LXD agent of a virtual machine is running.
+		content, _, err := server.GetInstanceFile(name, "/run/cloud-init/result.json")
+		if err != nil {
+			log.Printf("[DEBUG] cloud-init of instance %s not finished: %s", name, err)
+			return name, "NOT READY", nil
+		}
+		defer content.Close()
+
+		var result struct {
+			V1 struct {
+				Errors []string `json:"errors"`
+			} `json:"v1"`
+		}
+
+		if err := json.NewDecoder(content).Decode(&result); err != nil {
+			return name, "Error", fmt.Errorf("Unable to parse cloud-init result: %s", err)
+		}
+
+		if len(result.V1.Errors) > 0 {
+			return name, "Error", fmt.Errorf("cloud-init failed: %s", strings.Join(result.V1.Errors, ", "))
+		}
+
+		return name, "OK", nil
+	}
+}
+
+func resourceLxdInstanceWaitForNetwork(server lxd.ContainerServer, name string) resource.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		st, _, err := server.GetInstanceState(name)
+		if err != nil {
+			return st, "Error", err
+		}
+
+		for iface, net := range st.Network {
+			if iface != "lo" {
+				for _, ip := range net.Addresses {
+					if ip.Family == "inet" {
+						return st, "OK", nil
+					}
+				}
+			}
+		}
+		return st, "NOT FOUND", nil
+	}
+}
@@ -0,0 +1,332 @@
+package lxd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	lxd "github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/mitchellh/go-homedir"
+)
+
+func resourceLxdInstanceBackup() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceLxdInstanceBackupCreate,
+		Delete: resourceLxdInstanceBackupDelete,
+		Read:   resourceLxdInstanceBackupRead,
+
+		Importer: &schema.ResourceImporter{
+			State: resourceLxdInstanceBackupImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"instance": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"path": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"expiry"},
+			},
+
+			"expiry": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: resourceLxdValidateSnapshotExpiry,
+			},
+
+			"instance_only": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				ForceNew: true,
+			},
+
+			"optimized_storage": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				ForceNew: true,
+			},
+
+			"compression_algorithm": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"remote": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Default:  "",
+			},
+
+			"project": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			// Computed attributes
+
+			"created_at": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"expires_at": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"size": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"sha256": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceLxdInstanceBackupCreate(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	remote := p.selectRemote(d)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	path := ""
+	if v := d.Get("path").(string); v != "" {
+		path, err = homedir.Expand(v)
+		if err != nil {
+			return fmt.Errorf("unable to determine backup path: %s", err)
+		}
+	}
+
+	instance := d.Get("instance").(string)
+	req := api.InstanceBackupsPost{
+		Name:                 d.Get("name").(string),
+		InstanceOnly:         d.Get("instance_only").(bool),
+		OptimizedStorage:     d.Get("optimized_storage").(bool),
+		CompressionAlgorithm: d.Get("compression_algorithm").(string),
+	}
+
+	if v := d.Get("expiry").(string); v != "" {
+		req.ExpiresAt, err = snapshotExpiryTime(time.Now(), v)
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Printf("[DEBUG] Creating backup %s of instance %s", req.Name, instance)
+	op, err := server.CreateInstanceBackup(instance, req)
+	if err != nil {
+		return err
+	}
+	if err := op.Wait(); err != nil {
+		return fmt.Errorf("Error creating backup %s of instance %s: %s", req.Name, instance, err)
+	}
+
+	if path != "" {
+		backup, _, err := server.GetInstanceBackup(instance, req.Name)
+		if err == nil {
+			d.Set("created_at", backup.CreatedAt.UTC().Format(time.RFC3339))
+			err = resourceLxdInstanceBackupDownload(server, instance, req.Name, path)
+		}
+
+		// Downloaded backups don't stay on the server. Remove it even if
+		// the download failed: nothing would track it, and it may never
+		// expire.
+		if removeErr := resourceLxdInstanceBackupRemove(server, instance, req.Name); removeErr != nil {
+			if err != nil {
+				return fmt.Errorf("%s (removing it from the server also failed: %s)", err, removeErr)
+			}
+
+			// The file isn't tracked without an ID either.
+			os.Remove(path)
+			return fmt.Errorf("Unable to remove backup %s of instance %s from the server: %s", req.Name, instance, removeErr)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	d.SetId(newSnapshotID(remote, instance, req.Name).String())
+
+	return resourceLxdInstanceBackupRead(d, meta)
+}
+
+// resourceLxdInstanceBackupRemove deletes a backup from the server.
+func resourceLxdInstanceBackupRemove(server lxd.ContainerServer, instance, name string) error {
+	op, err := server.DeleteInstanceBackup(instance, name)
+	if err != nil {
+		return err
+	}
+
+	return op.Wait()
+}
+
+// resourceLxdInstanceBackupDownload downloads the tarball of a backup
+// to a local file, creating its directory if needed.
+func resourceLxdInstanceBackupDownload(server lxd.ContainerServer, instance, name, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return fmt.Errorf("Could not create backup directory: %s", err)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	log.Printf("[DEBUG] Downloading backup %s of instance %s to %s", name, instance, path)
+	req := lxd.BackupFileRequest{BackupFile: f}
+	if _, err := server.GetInstanceBackupFile(instance, name, &req); err != nil {
+		f.Close()
+		os.Remove(path)
+		return fmt.Errorf("Unable to download backup %s of instance %s: %s", name, instance, err)
+	}
+
+	return f.Close()
+}
+
+func resourceLxdInstanceBackupRead(d *schema.ResourceData, meta interface{}) error {
+	if v := d.Get("path").(string); v != "" {
+		path, err := homedir.Expand(v)
+		if err != nil {
+			return err
+		}
+
+		fi, err := os.Stat(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				d.SetId("")
+				return nil
+			}
+			return err
+		}
+
+		sum, err := resourceLxdImageExportChecksum(path)
+		if err != nil {
+			return err
+		}
+
+		d.Set("size", int(fi.Size()))
+		d.Set("sha256", sum)
+
+		return nil
+	}
+
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	backupID := newSnapshotIDFromResourceID(d.Id())
+
+	backup, _, err := server.GetInstanceBackup(backupID.container, backupID.snapshot)
+	if err != nil {
+		// The backup may have expired.
+		if err.Error() == "not found" {
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	d.Set("instance", backupID.container)
+	d.Set("name", backupID.snapshot)
+	d.Set("instance_only", backup.InstanceOnly)
+	d.Set("optimized_storage", backup.OptimizedStorage)
+	d.Set("created_at", backup.CreatedAt.UTC().Format(time.RFC3339))
+	if !backup.ExpiresAt.IsZero() {
+		d.Set("expires_at", backup.ExpiresAt.UTC().Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+func resourceLxdInstanceBackupDelete(d *schema.ResourceData, meta interface{}) error {
+	if v := d.Get("path").(string); v != "" {
+		path, err := homedir.Expand(v)
+		if err != nil {
+			return err
+		}
+
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("Unable to remove backup file %s: %s", path, err)
+		}
+
+		return nil
+	}
+
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	backupID := newSnapshotIDFromResourceID(d.Id())
+
+	err = resourceLxdInstanceBackupRemove(server, backupID.container, backupID.snapshot)
+	if err != nil && err.Error() == "not found" {
+		// The backup may have expired, or gone with its instance.
+		return nil
+	}
+
+	return err
+}
+
+// resourceLxdInstanceBackupImport imports a backup kept on the server
+// from an ID of the form [remote:][project/]instance/backup.
+func resourceLxdInstanceBackupImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	p := meta.(*lxdProvider)
+	log.Printf("[DEBUG] Starting import for %s", d.Id())
+
+	remote, name, err := p.LXDConfig.ParseRemote(d.Id())
+	if err != nil {
+		return nil, err
+	}
+
+	if p.LXDConfig.DefaultRemote != remote {
+		d.Set("remote", remote)
+	}
+
+	parts := strings.Split(name, "/")
+	switch len(parts) {
+	case 3:
+		d.Set("project", parts[0])
+		parts = parts[1:]
+	case 2:
+	default:
+		return nil, fmt.Errorf("Invalid backup ID %q, must be [remote:][project/]instance/backup", d.Id())
+	}
+
+	d.SetId(newSnapshotID(remote, parts[0], parts[1]).String())
+
+	return []*schema.ResourceData{d}, nil
+}
@@ -0,0 +1,93 @@
+package lxd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dustinkirkland/golang-petname"
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccInstanceBackup_basic(t *testing.T) {
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+	backupName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstanceBackup_basic(instanceName, backupName),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("lxd_instance_backup.backup1", "name", backupName),
+					resource.TestCheckResourceAttr("lxd_instance_backup.backup1", "instance", instanceName),
+					resource.TestCheckResourceAttrSet("lxd_instance_backup.backup1", "created_at"),
+					resource.TestCheckResourceAttrSet("lxd_instance_backup.backup1", "expires_at"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstanceBackup_download(t *testing.T) {
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+	backupName := strings.ToLower(petname.Generate(2, "-"))
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "lxd-instance-backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	path := filepath.Join(tmpDir, backupName+".tar.gz")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstanceBackup_download(instanceName, backupName, path),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("lxd_instance_backup.backup1", "path", path),
+					resource.TestCheckResourceAttrSet("lxd_instance_backup.backup1", "size"),
+					resource.TestCheckResourceAttrSet("lxd_instance_backup.backup1", "sha256"),
+					resource.TestCheckResourceAttrSet("lxd_instance_backup.backup1", "created_at"),
+				),
+			},
+		},
+	})
+}
+
+func testAccInstanceBackup_basic(instanceName, backupName string) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name  = "%s"
+  image = "images:alpine/3.9/amd64"
+}
+
+resource "lxd_instance_backup" "backup1" {
+  instance = "${lxd_instance.instance1.name}"
+  name     = "%s"
+  expiry   = "1d"
+}
+	`, instanceName, backupName)
+}
+
+func testAccInstanceBackup_download(instanceName, backupName, path string) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name  = "%s"
+  image = "images:alpine/3.9/amd64"
+}
+
+resource "lxd_instance_backup" "backup1" {
+  instance      = "${lxd_instance.instance1.name}"
+  name          = "%s"
+  path          = "%s"
+  instance_only = true
+}
+	`, instanceName, backupName, path)
+}
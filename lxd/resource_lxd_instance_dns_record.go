@@ -0,0 +1,346 @@
+package lxd
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	lxd "github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/shared/api"
+)
+
+func resourceLxdInstanceDNSRecord() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceLxdInstanceDNSRecordCreate,
+		Update: resourceLxdInstanceDNSRecordUpdate,
+		Delete: resourceLxdInstanceDNSRecordDelete,
+		Exists: resourceLxdInstanceDNSRecordExists,
+		Read:   resourceLxdInstanceDNSRecordRead,
+
+		CustomizeDiff: resourceLxdInstanceDNSRecordCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			"instance": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"zone": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"name": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+
+			"interface": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"ipv4": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+
+			"ipv6": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+
+			"ttl": {
+				Type:     schema.TypeInt,
+				Optional: true,
+				Default:  300,
+			},
+
+			"remote": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Default:  "",
+			},
+
+			"project": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"ipv4_address": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"ipv6_address": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceLxdInstanceDNSRecordCreate(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	instance := d.Get("instance").(string)
+	zone := d.Get("zone").(string)
+
+	name := instance
+	if v, ok := d.GetOk("name"); ok {
+		name = v.(string)
+	}
+
+	ipv4, ipv6, err := resourceLxdInstanceDNSAddresses(server, instance, d.Get("interface").(string))
+	if err != nil {
+		return err
+	}
+	if !d.Get("ipv4").(bool) {
+		ipv4 = ""
+	}
+	if !d.Get("ipv6").(bool) {
+		ipv6 = ""
+	}
+
+	if ipv4 == "" && ipv6 == "" {
+		return fmt.Errorf("Instance (%s) has no address to register in network zone %s yet", instance, zone)
+	}
+
+	req := api.NetworkZoneRecordsPost{}
+	req.Name = name
+	req.Description = fmt.Sprintf("Addresses of instance %s", instance)
+	req.Entries = resourceLxdInstanceDNSRecordEntries(ipv4, ipv6, d.Get("ttl").(int))
+
+	log.Printf("[DEBUG] Creating record %s of network zone %s: %#v", name, zone, req)
+	mutex.Lock()
+	err = server.CreateNetworkZoneRecord(zone, req)
+	mutex.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("Unable to create record (%s) of network zone %s: %s", name, zone, err)
+	}
+
+	d.SetId(fmt.Sprintf("%s/%s", zone, name))
+	d.Set("name", name)
+
+	return resourceLxdInstanceDNSRecordRead(d, meta)
+}
+
+func resourceLxdInstanceDNSRecordRead(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	name := d.Get("name").(string)
+	zone := d.Get("zone").(string)
+
+	record, _, err := server.GetNetworkZoneRecord(zone, name)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[DEBUG] Retrieved record %s of network zone %s: %#v", name, zone, record)
+
+	ipv4, ipv6 := "", ""
+	for _, entry := range record.Entries {
+		switch entry.Type {
+		case "A":
+			ipv4 = entry.Value
+		case "AAAA":
+			ipv6 = entry.Value
+		default:
+			continue
+		}
+		d.Set("ttl", int(entry.TTL))
+	}
+
+	d.Set("ipv4_address", ipv4)
+	d.Set("ipv6_address", ipv6)
+
+	return nil
+}
+
+func resourceLxdInstanceDNSRecordUpdate(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	name := d.Get("name").(string)
+	zone := d.Get("zone").(string)
+
+	record, etag, err := server.GetNetworkZoneRecord(zone, name)
+	if err != nil {
+		return err
+	}
+
+	// The addresses were observed when planning.
+	ipv4 := d.Get("ipv4_address").(string)
+	ipv6 := d.Get("ipv6_address").(string)
+
+	newRecord := record.Writable()
+	newRecord.Entries = resourceLxdInstanceDNSRecordEntries(ipv4, ipv6, d.Get("ttl").(int))
+
+	log.Printf("[DEBUG] Updating record %s of network zone %s: %#v", name, zone, newRecord)
+	mutex.Lock()
+	err = server.UpdateNetworkZoneRecord(zone, name, newRecord, etag)
+	mutex.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("Unable to update record (%s) of network zone %s: %s", name, zone, err)
+	}
+
+	return resourceLxdInstanceDNSRecordRead(d, meta)
+}
+
+func resourceLxdInstanceDNSRecordDelete(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	err = server.DeleteNetworkZoneRecord(d.Get("zone").(string), d.Get("name").(string))
+	if err != nil && err.Error() != "not found" {
+		return err
+	}
+
+	return nil
+}
+
+func resourceLxdInstanceDNSRecordExists(d *schema.ResourceData, meta interface{}) (exists bool, err error) {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return false, err
+	}
+
+	exists = false
+
+	if _, _, err := server.GetNetworkZoneRecord(d.Get("zone").(string), d.Get("name").(string)); err == nil {
+		exists = true
+	}
+
+	return
+}
+
+// resourceLxdInstanceDNSRecordCustomizeDiff plans an update of the
+// record when the addresses of the instance changed. An instance without
+// addresses, e.g. a stopped one, keeps its record as is.
+func resourceLxdInstanceDNSRecordCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" || !d.NewValueKnown("interface") {
+		return nil
+	}
+
+	server, err := resourceLxdInstanceDiffServer(d, meta.(*lxdProvider))
+	if err != nil {
+		return err
+	}
+
+	ipv4, ipv6, err := resourceLxdInstanceDNSAddresses(server, d.Get("instance").(string), d.Get("interface").(string))
+	if err != nil {
+		if err.Error() == "not found" {
+			return nil
+		}
+		return err
+	}
+
+	for _, family := range []struct{ attr, address string }{{"ipv4", ipv4}, {"ipv6", ipv6}} {
+		key := family.attr + "_address"
+		address := family.address
+		if !d.Get(family.attr).(bool) {
+			address = ""
+		} else if address == "" {
+			continue
+		}
+
+		if address != d.Get(key).(string) {
+			if err := d.SetNew(key, address); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// resourceLxdInstanceDNSAddresses returns the global IPv4 and IPv6
+// addresses of an instance, the ones of an interface or, like the
+// addresses of lxd_instance, the ones of the user.access_interface and
+// then the interfaces in the order of their names.
+func resourceLxdInstanceDNSAddresses(server lxd.ContainerServer, name, iface string) (string, string, error) {
+	state, _, err := server.GetInstanceState(name)
+	if err != nil {
+		return "", "", err
+	}
+
+	ifaces := []string{iface}
+	if iface == "" {
+		instance, _, err := server.GetInstance(name)
+		if err != nil {
+			return "", "", err
+		}
+
+		ifaces = make([]string, 0, len(state.Network))
+		for n := range state.Network {
+			if n != "lo" {
+				ifaces = append(ifaces, n)
+			}
+		}
+		sort.Strings(ifaces)
+
+		if ai, ok := instance.Config["user.access_interface"]; ok {
+			ifaces = append([]string{ai}, ifaces...)
+		}
+	}
+
+	ipv4, ipv6 := "", ""
+	for _, iface := range ifaces {
+		for _, ip := range state.Network[iface].Addresses {
+			if ip.Scope != "global" {
+				continue
+			}
+
+			if ip.Family == "inet" && ipv4 == "" {
+				ipv4 = ip.Address
+			}
+
+			if ip.Family == "inet6" && ipv6 == "" {
+				ipv6 = ip.Address
+			}
+		}
+	}
+
+	return ipv4, ipv6, nil
+}
+
+func resourceLxdInstanceDNSRecordEntries(ipv4, ipv6 string, ttl int) []api.NetworkZoneRecordEntry {
+	entries := []api.NetworkZoneRecordEntry{}
+	if ipv4 != "" {
+		entries = append(entries, api.NetworkZoneRecordEntry{Type: "A", Value: ipv4, TTL: uint64(ttl)})
+	}
+	if ipv6 != "" {
+		entries = append(entries, api.NetworkZoneRecordEntry{Type: "AAAA", Value: ipv6, TTL: uint64(ttl)})
+	}
+
+	return entries
+}
@@ -0,0 +1,91 @@
+package lxd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	petname "github.com/dustinkirkland/golang-petname"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccInstanceDNSRecord_basic(t *testing.T) {
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstanceDNSRecord_basic(instanceName, 300),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceDNSRecordAddress(t, "lxd_instance_dns_record.record1"),
+					resource.TestCheckResourceAttr("lxd_instance_dns_record.record1", "name", instanceName),
+					resource.TestCheckResourceAttrPair(
+						"lxd_instance_dns_record.record1", "ipv4_address",
+						"lxd_instance.instance1", "ipv4_address"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccInstanceDNSRecord_basic(instanceName, 3600),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceDNSRecordAddress(t, "lxd_instance_dns_record.record1"),
+					resource.TestCheckResourceAttr("lxd_instance_dns_record.record1", "ttl", "3600"),
+				),
+			},
+		},
+	})
+}
+
+// testAccInstanceDNSRecordAddress checks that the record of the zone
+// holds the address of the instance.
+func testAccInstanceDNSRecordAddress(t *testing.T, n string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No ID is set")
+		}
+
+		client, err := testAccProvider.Meta().(*lxdProvider).GetContainerServer("")
+		if err != nil {
+			return err
+		}
+		record, _, err := client.GetNetworkZoneRecord(rs.Primary.Attributes["zone"], rs.Primary.Attributes["name"])
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range record.Entries {
+			if entry.Type == "A" && entry.Value == rs.Primary.Attributes["ipv4_address"] {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("Record (%s) has no A entry for %s: %#v", rs.Primary.ID, rs.Primary.Attributes["ipv4_address"], record.Entries)
+	}
+}
+
+func testAccInstanceDNSRecord_basic(name string, ttl int) string {
+	return fmt.Sprintf(`
+resource "lxd_network_zone" "zone1" {
+  name = "%s.example.net"
+}
+
+resource "lxd_instance" "instance1" {
+  name  = "%s"
+  image = "images:alpine/3.9/amd64"
+}
+
+resource "lxd_instance_dns_record" "record1" {
+  instance = "${lxd_instance.instance1.name}"
+  zone     = "${lxd_network_zone.zone1.name}"
+  ipv6     = false
+  ttl      = %d
+}
+`, name, name, ttl)
+}
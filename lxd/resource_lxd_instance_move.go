@@ -0,0 +1,299 @@
+package lxd
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	lxd "github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/shared/api"
+)
+
+func resourceLxdInstanceMove() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceLxdInstanceMoveCreate,
+		Delete: resourceLxdInstanceMoveDelete,
+		Read:   resourceLxdInstanceMoveRead,
+
+		Schema: map[string]*schema.Schema{
+			"instance": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"target": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"target_remote": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"live": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				ForceNew: true,
+			},
+
+			"triggers": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"remote": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Default:  "",
+			},
+
+			"project": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"location": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceLxdInstanceMoveCreate(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	remote := p.selectRemote(d)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	name := d.Get("instance").(string)
+	target := d.Get("target").(string)
+	targetRemote := d.Get("target_remote").(string)
+	if targetRemote == remote {
+		targetRemote = ""
+	}
+	if target == "" && targetRemote == "" {
+		return fmt.Errorf("one of target or target_remote must be set to move instance (%s)", name)
+	}
+
+	st, _, err := server.GetInstanceState(name)
+	if err != nil {
+		return err
+	}
+
+	running := st.Status == "Running"
+	live := running && d.Get("live").(bool)
+	if running && !live {
+		if err := resourceLxdInstanceSetState(server, name, "stop", false, p.RefreshInterval); err != nil {
+			return err
+		}
+	}
+
+	dstServer := server
+	if targetRemote != "" {
+		dstServer, err = resourceLxdInstanceMoveServer(d, p)
+		if err != nil {
+			return err
+		}
+	}
+
+	if targetRemote == "" {
+		err = resourceLxdInstanceMoveMember(server, name, target, live)
+	} else {
+		err = resourceLxdInstanceMoveRemote(server, dstServer, name, target, live, p.RefreshInterval)
+	}
+	if err != nil {
+		// The instance was left on its source, don't leave it stopped.
+		if running && !live {
+			if startErr := resourceLxdInstanceSetState(server, name, "start", false, p.RefreshInterval); startErr != nil {
+				return fmt.Errorf("%s (restarting it also failed: %s)", err, startErr)
+			}
+		}
+		return err
+	}
+
+	if running && !live {
+		if err := resourceLxdInstanceSetState(dstServer, name, "start", false, p.RefreshInterval); err != nil {
+			return err
+		}
+	}
+
+	d.SetId(name)
+
+	return resourceLxdInstanceMoveRead(d, meta)
+}
+
+// resourceLxdInstanceMoveMember moves an instance to another member of
+// its cluster.
+func resourceLxdInstanceMoveMember(server lxd.ContainerServer, name, target string, live bool) error {
+	req := api.InstancePost{
+		Name:      name,
+		Migration: true,
+		Live:      live,
+	}
+
+	log.Printf("[DEBUG] Moving instance %s to %s", name, target)
+	op, err := server.UseTarget(target).MigrateInstance(name, req)
+	if err != nil {
+		return fmt.Errorf("Unable to move instance (%s) to %s: %s", name, target, err)
+	}
+
+	if err := op.Wait(); err != nil {
+		return fmt.Errorf("Error waiting for instance (%s) to be moved to %s: %s", name, target, err)
+	}
+
+	return nil
+}
+
+// resourceLxdInstanceMoveRemote moves an instance to another remote,
+// optionally to a given member of it, by copying it there and deleting
+// it from its source once copied. If it can't be deleted from its source,
+// the copy is removed again so that the instance is only left there.
+func resourceLxdInstanceMoveRemote(server, dstServer lxd.ContainerServer, name, target string, live bool, refreshInterval time.Duration) error {
+	instance, _, err := server.GetInstance(name)
+	if err != nil {
+		return err
+	}
+
+	if instance.ExpandedConfig["security.protection.delete"] == "true" {
+		return fmt.Errorf("Unable to move instance (%s) to another remote: security.protection.delete prevents deleting it from its source", name)
+	}
+
+	if target != "" {
+		dstServer = dstServer.UseTarget(target)
+	}
+
+	args := lxd.InstanceCopyArgs{
+		Name: name,
+		Live: live,
+	}
+
+	log.Printf("[DEBUG] Moving instance %s to another remote", name)
+	op, err := dstServer.CopyInstance(server, *instance, &args)
+	if err != nil {
+		return fmt.Errorf("Unable to move instance (%s): %s", name, err)
+	}
+
+	if err := op.Wait(); err != nil {
+		return fmt.Errorf("Error waiting for instance (%s) to be moved: %s", name, err)
+	}
+
+	// A live migrated instance keeps running on its source until
+	// it is removed from there.
+	if live {
+		if err := resourceLxdInstanceMoveForceStop(server, name, refreshInterval); err != nil {
+			return err
+		}
+	}
+
+	err = resourceLxdInstanceMoveDeleteFrom(server, name)
+	if err == nil {
+		return nil
+	}
+	err = fmt.Errorf("Unable to delete moved instance (%s) from its source: %s", name, err)
+
+	var undo []string
+	if live {
+		if err := resourceLxdInstanceMoveForceStop(dstServer, name, refreshInterval); err != nil {
+			undo = append(undo, fmt.Sprintf("stopping the copy failed: %s", err))
+		}
+	}
+	if err := resourceLxdInstanceMoveDeleteFrom(dstServer, name); err != nil {
+		undo = append(undo, fmt.Sprintf("removing the copy failed: %s", err))
+	}
+	if live {
+		if err := resourceLxdInstanceSetState(server, name, "start", false, refreshInterval); err != nil {
+			undo = append(undo, fmt.Sprintf("restarting the source failed: %s", err))
+		}
+	}
+
+	if len(undo) > 0 {
+		return fmt.Errorf("%s (%s)", err, strings.Join(undo, ", "))
+	}
+
+	return err
+}
+
+// resourceLxdInstanceMoveForceStop stops an instance without waiting
+// for it to shut down.
+func resourceLxdInstanceMoveForceStop(server lxd.ContainerServer, name string, refreshInterval time.Duration) error {
+	req := api.InstanceStatePut{
+		Action:  "stop",
+		Timeout: updateTimeout,
+		Force:   true,
+	}
+
+	return resourceLxdInstanceUpdateState(server, name, req, refreshInterval)
+}
+
+// resourceLxdInstanceMoveDeleteFrom deletes an instance from one end
+// of a move.
+func resourceLxdInstanceMoveDeleteFrom(server lxd.ContainerServer, name string) error {
+	op, err := server.DeleteInstance(name)
+	if err != nil {
+		return err
+	}
+
+	return op.Wait()
+}
+
+// resourceLxdInstanceMoveServer returns the server the instance is
+// moved to.
+func resourceLxdInstanceMoveServer(d *schema.ResourceData, p *lxdProvider) (lxd.ContainerServer, error) {
+	remote := d.Get("target_remote").(string)
+	if remote == "" {
+		return p.selectServer(d)
+	}
+
+	server, err := p.GetContainerServer(remote)
+	if err != nil {
+		return nil, err
+	}
+
+	if project := d.Get("project").(string); project != "" {
+		server = server.UseProject(project)
+	}
+
+	return server, nil
+}
+
+// resourceLxdInstanceMoveRead tracks where the instance ended up.
+func resourceLxdInstanceMoveRead(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := resourceLxdInstanceMoveServer(d, p)
+	if err != nil {
+		return err
+	}
+
+	instance, _, err := server.GetInstance(d.Id())
+	if err != nil {
+		if err.Error() == "not found" {
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	d.Set("location", instance.Location)
+
+	return nil
+}
+
+// resourceLxdInstanceMoveDelete only removes the move from the state,
+// the instance stays where it was moved to.
+func resourceLxdInstanceMoveDelete(d *schema.ResourceData, meta interface{}) error {
+	d.SetId("")
+	return nil
+}
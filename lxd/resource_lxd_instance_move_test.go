@@ -0,0 +1,49 @@
+package lxd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/dustinkirkland/golang-petname"
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccInstanceMove_target(t *testing.T) {
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	// Moving instances needs a cluster with at least two members.
+	members := strings.Split(os.Getenv("LXD_CLUSTER_MEMBERS"), ",")
+	if len(members) < 2 {
+		t.Skip("LXD_CLUSTER_MEMBERS must list two cluster members")
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstanceMove_target(instanceName, members[1]),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("lxd_instance_move.move1", "instance", instanceName),
+					resource.TestCheckResourceAttr("lxd_instance_move.move1", "location", members[1]),
+				),
+			},
+		},
+	})
+}
+
+func testAccInstanceMove_target(name, target string) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name  = "%s"
+  image = "images:alpine/3.9/amd64"
+}
+
+resource "lxd_instance_move" "move1" {
+  instance = "${lxd_instance.instance1.name}"
+  target   = "%s"
+}
+	`, name, target)
+}
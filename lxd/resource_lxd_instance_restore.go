@@ -0,0 +1,139 @@
+package lxd
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/lxc/lxd/shared/api"
+)
+
+func resourceLxdInstanceRestore() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceLxdInstanceRestoreCreate,
+		Delete: resourceLxdInstanceRestoreDelete,
+		Read:   resourceLxdInstanceRestoreRead,
+
+		Schema: map[string]*schema.Schema{
+			"instance": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"snapshot": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"stateful": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				ForceNew: true,
+			},
+
+			"triggers": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"remote": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Default:  "",
+			},
+
+			"project": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"restored_at": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceLxdInstanceRestoreCreate(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	remote := p.selectRemote(d)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	name := d.Get("instance").(string)
+	snapshot := d.Get("snapshot").(string)
+	stateful := d.Get("stateful").(bool)
+
+	st, _, err := server.GetInstanceState(name)
+	if err != nil {
+		return err
+	}
+
+	// Stateless restores happen on a stopped instance, which is started
+	// again afterwards. Stateful ones resume the saved runtime state.
+	running := st.Status == "Running" && !stateful
+	if running {
+		if err := resourceLxdInstanceSetState(server, name, "stop", false, p.RefreshInterval); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("[DEBUG] Restoring instance %s from snapshot %s", name, snapshot)
+	op, err := server.UpdateInstance(name, api.InstancePut{Restore: snapshot, Stateful: stateful}, "")
+	if err != nil {
+		return err
+	}
+	if err := op.Wait(); err != nil {
+		return fmt.Errorf("Error restoring instance (%s) from snapshot %s: %s", name, snapshot, err)
+	}
+
+	if running {
+		if err := resourceLxdInstanceSetState(server, name, "start", false, p.RefreshInterval); err != nil {
+			return err
+		}
+	}
+
+	d.SetId(newSnapshotID(remote, name, snapshot).String())
+	d.Set("restored_at", time.Now().UTC().Format(time.RFC3339))
+
+	return resourceLxdInstanceRestoreRead(d, meta)
+}
+
+// resourceLxdInstanceRestoreRead only checks that the instance still
+// exists. The restore is done, even once the snapshot is deleted.
+func resourceLxdInstanceRestoreRead(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	restoreID := newSnapshotIDFromResourceID(d.Id())
+
+	if _, _, err := server.GetInstance(restoreID.container); err != nil {
+		if err.Error() == "not found" {
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
+
+// resourceLxdInstanceRestoreDelete only removes the restore from the
+// state, the instance is left as it is.
+func resourceLxdInstanceRestoreDelete(d *schema.ResourceData, meta interface{}) error {
+	d.SetId("")
+	return nil
+}
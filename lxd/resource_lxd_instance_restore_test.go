@@ -0,0 +1,77 @@
+package lxd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dustinkirkland/golang-petname"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/lxc/lxd/shared/api"
+)
+
+func TestAccInstanceRestore_basic(t *testing.T) {
+	var instance api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+	snapshotName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstanceRestore_snapshot(instanceName, "web", snapshotName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceConfig(&instance, "user.tier", "web"),
+				),
+			},
+			resource.TestStep{
+				// The instance has the config of the snapshot once
+				// restored, which differs from the one of lxd_instance.
+				Config: testAccInstanceRestore_basic(instanceName, "db", snapshotName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceConfig(&instance, "user.tier", "web"),
+					resource.TestCheckResourceAttr("lxd_instance_restore.restore1", "snapshot", snapshotName),
+					resource.TestCheckResourceAttrSet("lxd_instance_restore.restore1", "restored_at"),
+					func(*terraform.State) error {
+						if instance.Status != "Running" {
+							return fmt.Errorf("Instance %s wasn't started again after the restore", instance.Name)
+						}
+						return nil
+					},
+				),
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+func testAccInstanceRestore_snapshot(instanceName, tier, snapshotName string) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name  = "%s"
+  image = "images:alpine/3.9/amd64"
+
+  config {
+    user.tier = "%s"
+  }
+}
+
+resource "lxd_instance_snapshot" "snapshot1" {
+  instance = "${lxd_instance.instance1.name}"
+  name     = "%s"
+}
+	`, instanceName, tier, snapshotName)
+}
+
+func testAccInstanceRestore_basic(instanceName, tier, snapshotName string) string {
+	return fmt.Sprintf(`%s
+resource "lxd_instance_restore" "restore1" {
+  instance = "${lxd_instance.instance1.name}"
+  snapshot = "${lxd_instance_snapshot.snapshot1.name}"
+}
+	`, testAccInstanceRestore_snapshot(instanceName, tier, snapshotName))
+}
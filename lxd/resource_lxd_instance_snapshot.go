@@ -0,0 +1,229 @@
+package lxd
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	lxd "github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/shared/api"
+)
+
+func resourceLxdInstanceSnapshot() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceLxdInstanceSnapshotCreate,
+		Delete: resourceLxdInstanceSnapshotDelete,
+		Exists: resourceLxdInstanceSnapshotExists,
+		Read:   resourceLxdInstanceSnapshotRead,
+
+		Importer: &schema.ResourceImporter{
+			State: resourceLxdInstanceSnapshotImport,
+		},
+
+		CustomizeDiff: resourceLxdInstanceSnapshotCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			"instance": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"stateful": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				ForceNew: true,
+			},
+
+			"remote": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Default:  "",
+			},
+
+			"project": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"created_at": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceLxdInstanceSnapshotCreate(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	remote := p.selectRemote(d)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	instance := d.Get("instance").(string)
+	req := api.InstanceSnapshotsPost{
+		Name:     d.Get("name").(string),
+		Stateful: d.Get("stateful").(bool),
+	}
+
+	err = resourceLxdSnapshotRetry(instance, req.Name, req.Stateful, func() error {
+		return resourceLxdInstanceSnapshotTake(server, instance, req)
+	})
+	if err != nil {
+		return err
+	}
+
+	d.SetId(newSnapshotID(remote, instance, req.Name).String())
+
+	return resourceLxdInstanceSnapshotRead(d, meta)
+}
+
+// resourceLxdInstanceSnapshotCustomizeDiff checks, when planning, that
+// a virtual machine can have its runtime state saved, which needs
+// migration.stateful. Containers need CRIU on the server instead, which
+// can't be checked here: LXD doesn't report it, so it is only detected
+// on apply, by resourceLxdStatefulError.
+func resourceLxdInstanceSnapshotCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() != "" || !d.Get("stateful").(bool) || !d.NewValueKnown("instance") {
+		return nil
+	}
+
+	server, err := resourceLxdInstanceDiffServer(d, meta.(*lxdProvider))
+	if err != nil {
+		return err
+	}
+
+	// The instance may be created by the same apply.
+	name := d.Get("instance").(string)
+	instance, _, err := server.GetInstance(name)
+	if err != nil {
+		return nil
+	}
+
+	if instance.Type == "virtual-machine" && instance.ExpandedConfig["migration.stateful"] != "true" {
+		return fmt.Errorf("Stateful snapshots of virtual machine %s need migration.stateful to be true", name)
+	}
+
+	return nil
+}
+
+// resourceLxdInstanceSnapshotTake takes a snapshot of an instance
+// and waits for it to be taken.
+func resourceLxdInstanceSnapshotTake(server lxd.ContainerServer, instance string, req api.InstanceSnapshotsPost) error {
+	op, err := server.CreateInstanceSnapshot(instance, req)
+	if err != nil {
+		return err
+	}
+
+	return op.Wait()
+}
+
+func resourceLxdInstanceSnapshotRead(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	snapID := newSnapshotIDFromResourceID(d.Id())
+
+	snap, _, err := server.GetInstanceSnapshot(snapID.container, snapID.snapshot)
+	if err != nil {
+		if err.Error() == "not found" {
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	d.Set("instance", snapID.container)
+	d.Set("name", snapID.snapshot)
+	d.Set("stateful", snap.Stateful)
+	d.Set("created_at", snap.CreatedAt.UTC().Format(time.RFC3339))
+
+	return nil
+}
+
+func resourceLxdInstanceSnapshotDelete(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	snapID := newSnapshotIDFromResourceID(d.Id())
+
+	op, err := server.DeleteInstanceSnapshot(snapID.container, snapID.snapshot)
+	if err != nil {
+		// The snapshot may have expired, or gone with its instance.
+		if err.Error() == "not found" {
+			return nil
+		}
+		return err
+	}
+
+	return op.Wait()
+}
+
+func resourceLxdInstanceSnapshotExists(d *schema.ResourceData, meta interface{}) (bool, error) {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return false, err
+	}
+
+	snapID := newSnapshotIDFromResourceID(d.Id())
+
+	_, _, err = server.GetInstanceSnapshot(snapID.container, snapID.snapshot)
+	if err != nil {
+		if err.Error() == "not found" {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// resourceLxdInstanceSnapshotImport imports a snapshot from an ID of
+// the form [remote:][project/]instance/snapshot.
+func resourceLxdInstanceSnapshotImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	p := meta.(*lxdProvider)
+	log.Printf("[DEBUG] Starting import for %s", d.Id())
+
+	remote, name, err := p.LXDConfig.ParseRemote(d.Id())
+	if err != nil {
+		return nil, err
+	}
+
+	if p.LXDConfig.DefaultRemote != remote {
+		d.Set("remote", remote)
+	}
+
+	parts := strings.Split(name, "/")
+	switch len(parts) {
+	case 3:
+		d.Set("project", parts[0])
+		parts = parts[1:]
+	case 2:
+	default:
+		return nil, fmt.Errorf("Invalid snapshot ID %q, must be [remote:][project/]instance/snapshot", d.Id())
+	}
+
+	d.SetId(newSnapshotID(remote, parts[0], parts[1]).String())
+
+	return []*schema.ResourceData{d}, nil
+}
@@ -0,0 +1,95 @@
+package lxd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/dustinkirkland/golang-petname"
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccInstanceSnapshot_basic(t *testing.T) {
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+	snapshotName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstanceSnapshot_basic(instanceName, "container", snapshotName),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("lxd_instance_snapshot.snapshot1", "name", snapshotName),
+					resource.TestCheckResourceAttr("lxd_instance_snapshot.snapshot1", "instance", instanceName),
+					resource.TestCheckResourceAttr("lxd_instance_snapshot.snapshot1", "stateful", "false"),
+					resource.TestCheckResourceAttrSet("lxd_instance_snapshot.snapshot1", "created_at"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstanceSnapshot_virtualMachine(t *testing.T) {
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+	snapshotName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstanceSnapshot_basic(instanceName, "virtual-machine", snapshotName),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("lxd_instance_snapshot.snapshot1", "name", snapshotName),
+					resource.TestCheckResourceAttrSet("lxd_instance_snapshot.snapshot1", "created_at"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstanceSnapshot_statefulVirtualMachine(t *testing.T) {
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+	snapshotName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstance_virtualMachine(instanceName),
+			},
+			resource.TestStep{
+				Config:      testAccInstanceSnapshot_stateful(instanceName, snapshotName),
+				ExpectError: regexp.MustCompile(`need migration.stateful to be true`),
+			},
+		},
+	})
+}
+
+func testAccInstanceSnapshot_basic(instanceName, instType, snapshotName string) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name  = "%s"
+  type  = "%s"
+  image = "images:ubuntu/focal/cloud"
+}
+
+resource "lxd_instance_snapshot" "snapshot1" {
+  instance = "${lxd_instance.instance1.name}"
+  name     = "%s"
+}
+	`, instanceName, instType, snapshotName)
+}
+
+func testAccInstanceSnapshot_stateful(instanceName, snapshotName string) string {
+	return fmt.Sprintf(`%s
+resource "lxd_instance_snapshot" "snapshot1" {
+  instance = "${lxd_instance.instance1.name}"
+  name     = "%s"
+  stateful = true
+}
+	`, testAccInstance_virtualMachine(instanceName), snapshotName)
+}
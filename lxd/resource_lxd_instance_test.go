@@ -0,0 +1,2022 @@
+package lxd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/dustinkirkland/golang-petname"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+func TestAccInstance_basic(t *testing.T) {
+	var instance api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstance_basic(instanceName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "name", instanceName),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "type", "container"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "status", "Running"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstance_expanded(t *testing.T) {
+	var instance api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstance_config(instanceName, "user.tier", "web"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "expanded_config.user.tier", "web"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "expanded_devices.#", "2"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "expanded_devices.0.name", "eth0"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "expanded_devices.1.name", "root"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "expanded_devices.1.properties.path", "/"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstance_consoleOutput(t *testing.T) {
+	var instance api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstance_consoleOutput(instanceName, 4096),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					resource.TestCheckResourceAttrSet("lxd_instance.instance1", "console_output"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccInstance_consoleOutput(instanceName, 16),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestMatchResourceAttr("lxd_instance.instance1", "console_output", regexp.MustCompile(`^(?s).{1,16}$`)),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstance_shutdownTimeout(t *testing.T) {
+	var instance api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstance_shutdownTimeout(instanceName, true, false),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "shutdown_timeout", "0s"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccInstance_shutdownTimeout(instanceName, false, false),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "status", "Stopped"),
+				),
+			},
+			// Ephemeral instances are gone once stopped for deletion.
+			resource.TestStep{
+				Config: testAccInstance_shutdownTimeout(instanceName, true, true),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "ephemeral", "true"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstance_networkInterfaces(t *testing.T) {
+	var instance api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstance_basic(instanceName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					resource.TestCheckResourceAttrSet("lxd_instance.instance1", "ipv4_address"),
+					resource.TestCheckResourceAttrPair("lxd_instance.instance1", "ipv4_address", "lxd_instance.instance1", "ip_address"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "network_interfaces.#", "1"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "network_interfaces.0.name", "eth0"),
+					resource.TestCheckResourceAttrSet("lxd_instance.instance1", "network_interfaces.0.mac_address"),
+					resource.TestCheckResourceAttrSet("lxd_instance.instance1", "network_interfaces.0.addresses.#"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstance_staticAddress(t *testing.T) {
+	var instance api.Instance
+	networkName := strings.ToLower(petname.Generate(1, "-"))
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccInstance_staticAddress(networkName, instanceName, "10.150.20.200"),
+				ExpectError: regexp.MustCompile(`is outside of the subnet 10.150.19.0/24`),
+			},
+			resource.TestStep{
+				Config: testAccInstance_staticAddress(networkName, instanceName, "10.150.19.200"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "ipv4_address", "10.150.19.200"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "mac_address", "00:16:3e:13:00:c8"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "network_interfaces.0.device", "eth0"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstance_virtualMachine(t *testing.T) {
+	var instance api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstance_virtualMachine(instanceName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "type", "virtual-machine"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "config.security.secureboot", "false"),
+					resource.TestCheckResourceAttrSet("lxd_instance.instance1", "ip_address"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstance_updateLimits(t *testing.T) {
+	var instance api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstance_limits(instanceName, "1"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceConfig(&instance, "limits.cpu", "1"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccInstance_limits(instanceName, "2"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceConfig(&instance, "limits.cpu", "2"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstance_updateConfig(t *testing.T) {
+	var instance api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstance_config(instanceName, "user.tier", "web"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceConfig(&instance, "user.tier", "web"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccInstance_config(instanceName, "user.tier", "db"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceConfig(&instance, "user.tier", "db"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccInstance_config(instanceName, "security.privileged", "true"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceConfig(&instance, "security.privileged", "true"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "status", "Running"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstance_running(t *testing.T) {
+	var instance api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstance_running(instanceName, false),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "status", "Stopped"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccInstance_running(instanceName, true),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "status", "Running"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccInstance_running(instanceName, false),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "status", "Stopped"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstance_rename(t *testing.T) {
+	var instance api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+	newName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstance_basic(instanceName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "name", instanceName),
+				),
+			},
+			resource.TestStep{
+				Config: testAccInstance_basic(newName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "name", newName),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "id", newName),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "status", "Running"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstance_target(t *testing.T) {
+	var instance api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	// Moving instances needs a cluster with at least two members.
+	members := strings.Split(os.Getenv("LXD_CLUSTER_MEMBERS"), ",")
+	if len(members) < 2 {
+		t.Skip("LXD_CLUSTER_MEMBERS must list two cluster members")
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstance_target(instanceName, members[0]),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "target", members[0]),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "location", members[0]),
+				),
+			},
+			resource.TestStep{
+				Config: testAccInstance_target(instanceName, members[1]),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "target", members[1]),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "location", members[1]),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "status", "Running"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstance_targetGroup(t *testing.T) {
+	var instance api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	// Targeting a group needs a cluster with a group of members.
+	group := os.Getenv("LXD_CLUSTER_GROUP")
+	if group == "" {
+		t.Skip("LXD_CLUSTER_GROUP must name a cluster group")
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccInstance_target(instanceName, "@"),
+				ExpectError: regexp.MustCompile(`must name a cluster group after @`),
+			},
+			resource.TestStep{
+				Config: testAccInstance_target(instanceName, "@"+group),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "target", "@"+group),
+					resource.TestCheckResourceAttrSet("lxd_instance.instance1", "location"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstance_rebuild(t *testing.T) {
+	var instance, created api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstance_rebuild(instanceName, "images:alpine/3.9/amd64"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &created),
+				),
+			},
+			resource.TestStep{
+				Config: testAccInstance_rebuild(instanceName, "images:alpine/3.10/amd64"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceNotRecreated(&instance, &created),
+					testAccInstanceConfig(&instance, "user.tier", "web"),
+					testAccInstanceFileContent(&instance, "/etc/motd", "rebuilt\n"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "image", "images:alpine/3.10/amd64"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "status", "Running"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstance_profileOrder(t *testing.T) {
+	var instance api.Instance
+	profileName := strings.ToLower(petname.Generate(2, "-"))
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstance_profileOrder(profileName, instanceName, `"default", "${lxd_profile.profile1.name}"`, false),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "expanded_config.limits.cpu", "2"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccInstance_profileOrder(profileName, instanceName, `"${lxd_profile.profile1.name}", "default"`, false),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "profiles.0", profileName),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "profiles.1", "default"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccInstance_profileOrder(profileName, instanceName, `"default", "${lxd_profile.profile1.name}"`, true),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "profiles.0", profileName),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "profiles.1", "default"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstance_cloudInit(t *testing.T) {
+	var instance api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstance_cloudInit(instanceName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceConfig(&instance, "cloud-init.user-data", "#cloud-config\npackages:\n  - curl  \n\n"),
+					resource.TestCheckNoResourceAttr("lxd_instance.instance1", "config.cloud-init.user-data"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstance_waitFor(t *testing.T) {
+	var instance api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstance_waitFor(instanceName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					resource.TestCheckResourceAttrSet("lxd_instance.instance1", "ipv4_address"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "wait_for.#", "2"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstance_metadata(t *testing.T) {
+	var instance api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstance_metadata(instanceName, "team-a"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceConfig(&instance, "user.owner", "team-a"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "description", "Web server"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "metadata.owner", "team-a"),
+					testAccInstanceSetConfig(&instance, "user.backup", "daily"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccInstance_metadata(instanceName, "team-b"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceConfig(&instance, "user.owner", "team-b"),
+					testAccInstanceConfig(&instance, "user.backup", "daily"),
+					resource.TestCheckNoResourceAttr("lxd_instance.instance1", "config.user.backup"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstance_sourceInstance(t *testing.T) {
+	var instance api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+	copyName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstance_sourceInstance(instanceName, copyName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance2", &instance),
+					testAccInstanceConfig(&instance, "user.copied", "true"),
+					resource.TestCheckResourceAttr("lxd_instance.instance2", "name", copyName),
+					resource.TestCheckResourceAttr("lxd_instance.instance2", "status", "Running"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstance_sourceInstanceRelay(t *testing.T) {
+	var instance api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+	copyName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccInstance_sourceInstanceMode(instanceName, copyName, "proxy"),
+				ExpectError: regexp.MustCompile(`Only pull, push and relay are supported values`),
+			},
+			resource.TestStep{
+				Config: testAccInstance_sourceInstanceMode(instanceName, copyName, "relay"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance2", &instance),
+					resource.TestCheckResourceAttr("lxd_instance.instance2", "name", copyName),
+					resource.TestCheckResourceAttr("lxd_instance.instance2", "source_instance.0.mode", "relay"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstance_sourceBackup(t *testing.T) {
+	var instance api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+	copyName := strings.ToLower(petname.Generate(2, "-"))
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "lxd-instance-backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	path := tmpDir + "/" + instanceName + ".tar.gz"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstance_sourceBackup(instanceName, copyName, path),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance2", &instance),
+					testAccInstanceConfig(&instance, "user.restored", "true"),
+					resource.TestCheckResourceAttr("lxd_instance.instance2", "name", copyName),
+					resource.TestCheckResourceAttr("lxd_instance.instance2", "status", "Running"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstance_file(t *testing.T) {
+	var instance api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstance_file(instanceName, "Hello, World!\n"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceFileContent(&instance, "/foo/bar.txt", "Hello, World!\n"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccInstance_file(instanceName, "Goodbye, World!\n"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceFileContent(&instance, "/foo/bar.txt", "Goodbye, World!\n"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstance_exec(t *testing.T) {
+	var instance api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstance_exec(instanceName, "true"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "exec.#", "2"),
+				),
+			},
+			resource.TestStep{
+				Config:      testAccInstance_exec(instanceName, "false"),
+				ExpectError: regexp.MustCompile(`exited with code 1`),
+			},
+		},
+	})
+}
+
+func TestAccInstance_device(t *testing.T) {
+	var instance api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstance_device(instanceName, "/tmp/shared1"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceDevice(&instance, "shared", "path", "/tmp/shared1"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccInstance_device(instanceName, "/tmp/shared2"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceDevice(&instance, "shared", "path", "/tmp/shared2"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccInstance_basic(instanceName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceNoDevice(&instance, "shared"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstance_security(t *testing.T) {
+	var instance api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstance_security(instanceName, false),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceConfig(&instance, "security.nesting", "true"),
+					testAccInstanceConfig(&instance, "security.privileged", "false"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "nesting", "true"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "privileged", "false"),
+					resource.TestCheckNoResourceAttr("lxd_instance.instance1", "config.security.nesting"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccInstance_security(instanceName, true),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceConfig(&instance, "security.privileged", "true"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "privileged", "true"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "status", "Running"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstance_protected(t *testing.T) {
+	var instance api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstance_protected(instanceName, true),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceConfig(&instance, "security.protection.delete", "true"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "protected", "true"),
+				),
+			},
+			resource.TestStep{
+				Config:      testAccInstance_protected(instanceName, true),
+				Destroy:     true,
+				ExpectError: regexp.MustCompile(`is protected from deletion`),
+			},
+			resource.TestStep{
+				Config: testAccInstance_protected(instanceName, false),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceConfig(&instance, "security.protection.delete", "false"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "protected", "false"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "status", "Running"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstance_snapshotSchedule(t *testing.T) {
+	var instance api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccInstance_snapshotSchedule(instanceName, "0 6 * *", "2w"),
+				ExpectError: regexp.MustCompile(`must be a cron expression of 5 fields`),
+			},
+			resource.TestStep{
+				Config:      testAccInstance_snapshotSchedule(instanceName, "@daily", "2 weeks"),
+				ExpectError: regexp.MustCompile(`must be a list of durations`),
+			},
+			resource.TestStep{
+				Config: testAccInstance_snapshotSchedule(instanceName, "0 6 * * *", "2w"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceConfig(&instance, "snapshots.schedule", "0 6 * * *"),
+					testAccInstanceConfig(&instance, "snapshots.pattern", "daily-%d"),
+					testAccInstanceConfig(&instance, "snapshots.expiry", "2w"),
+					resource.TestCheckNoResourceAttr("lxd_instance.instance1", "config.snapshots.schedule"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccInstance_snapshotSchedule(instanceName, "@hourly, @startup", "1d 12H"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceConfig(&instance, "snapshots.schedule", "@hourly, @startup"),
+					testAccInstanceConfig(&instance, "snapshots.expiry", "1d 12H"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstance_idmap(t *testing.T) {
+	var instance api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccInstance_idmap(instanceName, "virtual-machine", 1),
+				ExpectError: regexp.MustCompile(`idmap can only be set on instances of type container`),
+			},
+			resource.TestStep{
+				Config: testAccInstance_idmap(instanceName, "container", 1),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceConfig(&instance, "raw.idmap", "both 1000 1000"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "idmap.#", "1"),
+					resource.TestCheckNoResourceAttr("lxd_instance.instance1", "config.raw.idmap"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccInstance_idmap(instanceName, "container", 3),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceConfig(&instance, "raw.idmap", "both 1000-1002 1000-1002"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "idmap.0.range", "3"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "status", "Running"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstance_restartOnChange(t *testing.T) {
+	var instance api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstance_restartOnChange(instanceName, "never", false, "web"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "restart_pending", "false"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccInstance_restartOnChange(instanceName, "never", true, "web"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceConfig(&instance, "security.privileged", "true"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "status", "Running"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "restart_pending", "true"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccInstance_restartOnChange(instanceName, "always", true, "db"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceConfig(&instance, "user.tier", "db"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "status", "Running"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "restart_pending", "false"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstance_restartPendingStopped(t *testing.T) {
+	var instance api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstance_restartPending(instanceName, false, true),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "restart_pending", "false"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccInstance_restartPending(instanceName, true, true),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "restart_pending", "true"),
+				),
+			},
+			resource.TestStep{
+				// Stopping the instance along with a change needing a
+				// restart leaves no restart pending.
+				Config: testAccInstance_restartPending(instanceName, false, false),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "status", "Stopped"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "restart_pending", "false"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstance_autostart(t *testing.T) {
+	var instance api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstance_autostart(instanceName, 10),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceConfig(&instance, "boot.autostart", "true"),
+					testAccInstanceConfig(&instance, "boot.autostart.priority", "10"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "boot_priority", "10"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "stop_priority", "5"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccInstance_autostart(instanceName, 20),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceConfig(&instance, "boot.autostart.priority", "20"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "boot_priority", "20"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstance_firmware(t *testing.T) {
+	var instance api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccInstance_firmware(instanceName, "container"),
+				ExpectError: regexp.MustCompile(`can only be enabled on instances of type virtual-machine`),
+			},
+			resource.TestStep{
+				Config: testAccInstance_firmware(instanceName, "virtual-machine"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceConfig(&instance, "security.csm", "true"),
+					testAccInstanceConfig(&instance, "security.secureboot", "false"),
+					testAccInstanceConfig(&instance, "agent.nic_config", "true"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "csm", "true"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "secureboot", "false"),
+					resource.TestCheckResourceAttrSet("lxd_instance.instance1", "vsock_id"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstance_instanceType(t *testing.T) {
+	var instance api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstance_instanceType(instanceName, "c2-m4"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceConfig(&instance, "limits.cpu", "2"),
+					testAccInstanceConfig(&instance, "limits.memory", "4096MB"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "instance_type", "c2-m4"),
+					resource.TestCheckNoResourceAttr("lxd_instance.instance1", "limits.cpu"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccInstance_instanceType(instanceName, "t2.micro"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceConfig(&instance, "limits.cpu", "1"),
+					testAccInstanceConfig(&instance, "limits.memory", "1024MB"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstance_invalidLimits(t *testing.T) {
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccInstance_memoryLimits(instanceName, "2 gigs", "soft"),
+				ExpectError: regexp.MustCompile(`limits.memory must be a size or a percentage`),
+			},
+			resource.TestStep{
+				Config:      testAccInstance_memoryLimits(instanceName, "256MiB", "strict"),
+				ExpectError: regexp.MustCompile(`limits.memory.enforce must be hard or soft`),
+			},
+		},
+	})
+}
+
+func TestAccInstance_resourceLimits(t *testing.T) {
+	var instance api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstance_resourceLimits(instanceName, "1", "256MiB"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceConfig(&instance, "limits.cpu", "1"),
+					testAccInstanceConfig(&instance, "limits.memory", "256MiB"),
+					testAccInstanceConfig(&instance, "limits.memory.enforce", "soft"),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "resource_limits.0.cpu", "1"),
+					resource.TestCheckNoResourceAttr("lxd_instance.instance1", "limits.cpu"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccInstance_resourceLimits(instanceName, "2", "512MiB"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceConfig(&instance, "limits.cpu", "2"),
+					testAccInstanceConfig(&instance, "limits.memory", "512MiB"),
+				),
+			},
+			resource.TestStep{
+				Config:      testAccInstance_resourceLimits(instanceName, "2", "2 gigs"),
+				ExpectError: regexp.MustCompile(`resource_limits.0.memory must be a size or a percentage`),
+			},
+		},
+	})
+}
+
+func TestAccInstance_proxyDevice(t *testing.T) {
+	var instance api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccInstance_proxyDevice(instanceName, "tcp:8080"),
+				ExpectError: regexp.MustCompile(`invalid listen "tcp:8080"`),
+			},
+			resource.TestStep{
+				Config: testAccInstance_proxyDevice(instanceName, "tcp:127.0.0.1:18080"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceDevice(&instance, "web", "listen", "tcp:127.0.0.1:18080"),
+					testAccInstanceDevice(&instance, "web", "security.uid", "1000"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstance_usbDevice(t *testing.T) {
+	var instance, created api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstance_basic(instanceName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &created),
+				),
+			},
+			resource.TestStep{
+				// The device isn't plugged in, which LXD
+				// accepts as it isn't required.
+				Config: testAccInstance_usbDevice(instanceName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceDevice(&instance, "dongle", "vendorid", "0403"),
+					testAccInstanceNotRecreated(&instance, &created),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "status", "Running"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccInstance_basic(instanceName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceNoDevice(&instance, "dongle"),
+					testAccInstanceNotRecreated(&instance, &created),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstance_unixCharDevice(t *testing.T) {
+	var instance, created api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstance_basic(instanceName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &created),
+				),
+			},
+			resource.TestStep{
+				Config: testAccInstance_unixCharDevice(instanceName, "0666"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceDevice(&instance, "tun", "path", "/dev/net/tun"),
+					testAccInstanceNotRecreated(&instance, &created),
+				),
+			},
+			resource.TestStep{
+				Config:      testAccInstance_unixCharDevice(instanceName, "rw-rw-rw-"),
+				ExpectError: regexp.MustCompile(`invalid mode "rw-rw-rw-"`),
+			},
+			resource.TestStep{
+				Config: testAccInstance_basic(instanceName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceNoDevice(&instance, "tun"),
+					testAccInstanceNotRecreated(&instance, &created),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstance_tpmDevice(t *testing.T) {
+	var instance, created api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstance_virtualMachine(instanceName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &created),
+				),
+			},
+			resource.TestStep{
+				// The virtual machine is stopped to add the TPM.
+				Config: testAccInstance_tpmDevice(instanceName, "virtual-machine", ""),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceDevice(&instance, "tpm0", "type", "tpm"),
+					testAccInstanceNotRecreated(&instance, &created),
+					resource.TestCheckResourceAttr("lxd_instance.instance1", "status", "Running"),
+				),
+			},
+			resource.TestStep{
+				Config:      testAccInstance_tpmDevice(instanceName, "virtual-machine", `path = "/dev/tpm0"`),
+				ExpectError: regexp.MustCompile(`with path can only be used by instances of type container`),
+			},
+			resource.TestStep{
+				Config:      testAccInstance_tpmDevice(instanceName, "container", ""),
+				ExpectError: regexp.MustCompile(`must have the path of the TPM in the container set`),
+			},
+		},
+	})
+}
+
+func TestAccInstance_rootDiskResize(t *testing.T) {
+	var instance, created api.Instance
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccInstance_rootDisk(instanceName, "10GiB"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &created),
+					testAccInstanceDevice(&created, "root", "size", "10GiB"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccInstance_rootDisk(instanceName, "12GiB"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceDevice(&instance, "root", "size", "12GiB"),
+					testAccInstanceNotRecreated(&instance, &created),
+				),
+			},
+			resource.TestStep{
+				Config:      testAccInstance_rootDisk(instanceName, "8GiB"),
+				ExpectError: regexp.MustCompile(`The root disk of a virtual machine can't shrink`),
+			},
+		},
+	})
+}
+
+func TestAccInstance_invalidDiskDevice(t *testing.T) {
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccInstance_isoDevice(instanceName, "container", "10"),
+				ExpectError: regexp.MustCompile(`must have one of these properties set: path`),
+			},
+			resource.TestStep{
+				Config:      testAccInstance_isoDevice(instanceName, "virtual-machine", "first"),
+				ExpectError: regexp.MustCompile(`invalid boot.priority "first"`),
+			},
+		},
+	})
+}
+
+func TestAccInstance_invalidGPUDevice(t *testing.T) {
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccInstance_gpuDevice(instanceName, "virtual-machine", `gputype = "mdev"`),
+				ExpectError: regexp.MustCompile(`must have the mdev profile to use set`),
+			},
+			resource.TestStep{
+				Config:      testAccInstance_gpuDevice(instanceName, "container", `gputype = "mdev"`+"\n"+`mdev = "i915-GVTg_V5_4"`),
+				ExpectError: regexp.MustCompile(`can only be used by instances of type virtual-machine`),
+			},
+			resource.TestStep{
+				Config:      testAccInstance_gpuDevice(instanceName, "container", `pci = "01:00.0"`),
+				ExpectError: regexp.MustCompile(`invalid pci "01:00.0"`),
+			},
+		},
+	})
+}
+
+func TestAccInstance_invalidDevice(t *testing.T) {
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccInstance_invalidDevice(instanceName),
+				ExpectError: regexp.MustCompile(`must have one of these properties set: listen`),
+			},
+		},
+	})
+}
+
+func testAccInstanceRunning(t *testing.T, n string, instance *api.Instance) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No ID is set")
+		}
+
+		client, err := testAccProvider.Meta().(*lxdProvider).GetContainerServer("")
+		if err != nil {
+			return err
+		}
+		inst, _, err := client.GetInstance(rs.Primary.ID)
+		if err != nil {
+			return err
+		}
+
+		if inst != nil {
+			*instance = *inst
+			return nil
+		}
+
+		return fmt.Errorf("Instance not found: %s", rs.Primary.ID)
+	}
+}
+
+// testAccInstanceSetConfig sets a config key of an
+// instance outside of Terraform, as another tool would.
+func testAccInstanceSetConfig(instance *api.Instance, k, v string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		client, err := testAccProvider.Meta().(*lxdProvider).GetContainerServer("")
+		if err != nil {
+			return err
+		}
+
+		inst, etag, err := client.GetInstance(instance.Name)
+		if err != nil {
+			return err
+		}
+
+		put := inst.Writable()
+		put.Config[k] = v
+		op, err := client.UpdateInstance(instance.Name, put, etag)
+		if err != nil {
+			return err
+		}
+
+		return op.Wait()
+	}
+}
+
+func testAccInstanceConfig(instance *api.Instance, k, v string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		if instance.Config == nil {
+			return fmt.Errorf("No config")
+		}
+
+		value, ok := instance.Config[k]
+		if !ok {
+			return fmt.Errorf("Config not found: %s", k)
+		}
+
+		if v != value {
+			return fmt.Errorf("Bad value for %s: %s", k, value)
+		}
+
+		return nil
+	}
+}
+
+func testAccInstanceFileContent(instance *api.Instance, path, content string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		client, err := testAccProvider.Meta().(*lxdProvider).GetContainerServer("")
+		if err != nil {
+			return err
+		}
+
+		f, _, err := client.GetInstanceFile(instance.Name, path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		b, err := ioutil.ReadAll(f)
+		if err != nil {
+			return err
+		}
+
+		if string(b) != content {
+			return fmt.Errorf("Bad content for %s: %q", path, string(b))
+		}
+
+		return nil
+	}
+}
+
+func testAccInstanceDevice(instance *api.Instance, deviceName, k, v string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		device, ok := instance.Devices[deviceName]
+		if !ok {
+			return fmt.Errorf("Device not found: %s", deviceName)
+		}
+
+		if device[k] != v {
+			return fmt.Errorf("Bad value for %s of device %s: %s", k, deviceName, device[k])
+		}
+
+		return nil
+	}
+}
+
+func testAccInstanceNotRecreated(instance, created *api.Instance) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		if !instance.CreatedAt.Equal(created.CreatedAt) {
+			return fmt.Errorf("Instance %s was re-created", instance.Name)
+		}
+
+		return nil
+	}
+}
+
+func testAccInstanceNoDevice(instance *api.Instance, deviceName string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		if _, ok := instance.Devices[deviceName]; ok {
+			return fmt.Errorf("Device still exists: %s", deviceName)
+		}
+
+		return nil
+	}
+}
+
+func testAccInstance_basic(name string) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name = "%s"
+  image = "images:alpine/3.9/amd64"
+  profiles = ["default"]
+}
+	`, name)
+}
+
+func testAccInstance_consoleOutput(name string, size int) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name                = "%s"
+  image               = "images:alpine/3.9/amd64"
+  console_output_size = %d
+}
+	`, name, size)
+}
+
+func testAccInstance_shutdownTimeout(name string, running, ephemeral bool) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name             = "%s"
+  image            = "images:alpine/3.9/amd64"
+  running          = %t
+  ephemeral        = %t
+  shutdown_timeout = "0s"
+}
+	`, name, running, ephemeral)
+}
+
+func testAccInstance_virtualMachine(name string) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name = "%s"
+  type = "virtual-machine"
+  image = "images:ubuntu/focal/cloud"
+  profiles = ["default"]
+
+  config {
+    security.secureboot = "false"
+  }
+}
+	`, name)
+}
+
+func testAccInstance_config(name, k, v string) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name = "%s"
+  image = "images:alpine/3.9/amd64"
+  profiles = ["default"]
+
+  config {
+    %s = "%s"
+  }
+}
+	`, name, k, v)
+}
+
+func testAccInstance_running(name string, running bool) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name = "%s"
+  image = "images:alpine/3.9/amd64"
+  profiles = ["default"]
+  running = %t
+}
+	`, name, running)
+}
+
+func testAccInstance_target(name, target string) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name   = "%s"
+  image  = "images:alpine/3.9/amd64"
+  target = "%s"
+}
+	`, name, target)
+}
+
+func testAccInstance_cloudInit(name string) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name = "%s"
+  image = "images:ubuntu/focal/cloud"
+  profiles = ["default"]
+
+  user_data = "#cloud-config\npackages:\n  - curl  \n\n"
+}
+	`, name)
+}
+
+func testAccInstance_waitFor(name string) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name = "%s"
+  image = "images:ubuntu/focal/cloud"
+  wait_for_network = false
+
+  user_data = "#cloud-config\nruncmd:\n  - touch /tmp/done\n"
+
+  wait_for {
+    type      = "ipv4"
+    interface = "eth0"
+  }
+
+  wait_for {
+    type    = "cloud-init"
+    timeout = "10m"
+  }
+}
+	`, name)
+}
+
+func testAccInstance_metadata(name, owner string) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name        = "%s"
+  image       = "images:alpine/3.9/amd64"
+  description = "Web server"
+
+  metadata {
+    owner = "%s"
+  }
+}
+	`, name, owner)
+}
+
+func testAccInstance_sourceInstance(name, copyName string) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name    = "%s"
+  image   = "images:alpine/3.9/amd64"
+  running = false
+}
+
+resource "lxd_instance" "instance2" {
+  name = "%s"
+
+  source_instance {
+    name          = "${lxd_instance.instance1.name}"
+    instance_only = true
+  }
+
+  config {
+    user.copied = "true"
+  }
+}
+	`, name, copyName)
+}
+
+func testAccInstance_sourceInstanceMode(name, copyName, mode string) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name    = "%s"
+  image   = "images:alpine/3.9/amd64"
+  running = false
+}
+
+resource "lxd_instance" "instance2" {
+  name = "%s"
+
+  source_instance {
+    name = "${lxd_instance.instance1.name}"
+    mode = "%s"
+  }
+}
+	`, name, copyName, mode)
+}
+
+func testAccInstance_sourceBackup(name, copyName, path string) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name    = "%s"
+  image   = "images:alpine/3.9/amd64"
+  running = false
+}
+
+resource "lxd_instance_backup" "backup1" {
+  instance      = "${lxd_instance.instance1.name}"
+  name          = "backup1"
+  path          = "%s"
+  instance_only = true
+}
+
+resource "lxd_instance" "instance2" {
+  name          = "%s"
+  source_backup = "${lxd_instance_backup.backup1.path}"
+
+  config {
+    user.restored = "true"
+  }
+}
+	`, name, path, copyName)
+}
+
+func testAccInstance_rebuild(name, image string) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name                    = "%s"
+  image                   = "%s"
+  rebuild_on_image_change = true
+
+  metadata {
+    tier = "web"
+  }
+
+  file {
+    content     = "rebuilt\n"
+    target_path = "/etc/motd"
+  }
+}
+	`, name, image)
+}
+
+func testAccInstance_profileOrder(profileName, instanceName, profiles string, ignoreOrder bool) string {
+	return fmt.Sprintf(`
+resource "lxd_profile" "profile1" {
+  name = "%s"
+
+  config {
+    limits.cpu = 2
+  }
+}
+
+resource "lxd_instance" "instance1" {
+  name                 = "%s"
+  image                = "images:alpine/3.9/amd64"
+  profiles             = [%s]
+  ignore_profile_order = %t
+}
+	`, profileName, instanceName, profiles, ignoreOrder)
+}
+
+func testAccInstance_file(name, content string) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name = "%s"
+  image = "images:alpine/3.9/amd64"
+
+  file {
+    content            = "%s"
+    target_path        = "/foo/bar.txt"
+    mode               = "0600"
+    create_directories = true
+  }
+}
+	`, name, content)
+}
+
+func testAccInstance_exec(name, command string) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name = "%s"
+  image = "images:alpine/3.9/amd64"
+
+  exec {
+    command     = ["/bin/sh", "-c", "echo $GREETING > hello"]
+    working_dir = "/root"
+
+    environment {
+      GREETING = "hello"
+    }
+  }
+
+  exec {
+    command = ["%s"]
+  }
+}
+	`, name, command)
+}
+
+func testAccInstance_device(name, path string) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name = "%s"
+  image = "images:alpine/3.9/amd64"
+  profiles = ["default"]
+
+  device {
+    name = "shared"
+    type = "disk"
+    properties {
+      source = "/tmp"
+      path = "%s"
+    }
+  }
+}
+	`, name, path)
+}
+
+func testAccInstance_rootDisk(name, size string) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name  = "%s"
+  type  = "virtual-machine"
+  image = "images:ubuntu/focal/cloud"
+
+  device {
+    name = "root"
+    type = "disk"
+
+    properties {
+      path = "/"
+      pool = "default"
+      size = "%s"
+    }
+  }
+}
+	`, name, size)
+}
+
+func testAccInstance_proxyDevice(name, listen string) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name = "%s"
+  image = "images:alpine/3.9/amd64"
+
+  device {
+    name = "web"
+    type = "proxy"
+    properties {
+      listen         = "%s"
+      connect        = "tcp:127.0.0.1:80"
+      bind           = "host"
+      security.uid   = "1000"
+      security.gid   = "1000"
+      proxy_protocol = "false"
+    }
+  }
+}
+	`, name, listen)
+}
+
+func testAccInstance_usbDevice(name string) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name = "%s"
+  image = "images:alpine/3.9/amd64"
+  profiles = ["default"]
+
+  device {
+    name = "dongle"
+    type = "usb"
+    properties {
+      vendorid  = "0403"
+      productid = "6001"
+      required  = "false"
+    }
+  }
+}
+	`, name)
+}
+
+func testAccInstance_unixCharDevice(name, mode string) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name = "%s"
+  image = "images:alpine/3.9/amd64"
+  profiles = ["default"]
+
+  device {
+    name = "tun"
+    type = "unix-char"
+    properties {
+      path     = "/dev/net/tun"
+      major    = "10"
+      minor    = "200"
+      mode     = "%s"
+      required = "true"
+    }
+  }
+}
+	`, name, mode)
+}
+
+func testAccInstance_tpmDevice(name, instType, properties string) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name = "%s"
+  type = "%s"
+  image = "images:ubuntu/focal/cloud"
+  profiles = ["default"]
+
+  config {
+    security.secureboot = "false"
+  }
+
+  device {
+    name = "tpm0"
+    type = "tpm"
+    properties {
+      %s
+    }
+  }
+}
+	`, name, instType, properties)
+}
+
+func testAccInstance_isoDevice(name, instType, priority string) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name = "%s"
+  type = "%s"
+  image = "images:ubuntu/focal/cloud"
+
+  device {
+    name = "install"
+    type = "disk"
+    properties {
+      source        = "/var/lib/isos/ubuntu.iso"
+      boot.priority = "%s"
+    }
+  }
+}
+	`, name, instType, priority)
+}
+
+func testAccInstance_gpuDevice(name, instType, properties string) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name = "%s"
+  type = "%s"
+  image = "images:ubuntu/focal/cloud"
+
+  device {
+    name = "gpu0"
+    type = "gpu"
+    properties {
+      %s
+    }
+  }
+}
+	`, name, instType, properties)
+}
+
+func testAccInstance_invalidDevice(name string) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name = "%s"
+  image = "images:alpine/3.9/amd64"
+  profiles = ["default"]
+
+  device {
+    name = "web"
+    type = "proxy"
+    properties {
+      connect = "tcp:127.0.0.1:80"
+    }
+  }
+}
+	`, name)
+}
+
+func testAccInstance_staticAddress(networkName, name, address string) string {
+	return fmt.Sprintf(`
+resource "lxd_network" "network1" {
+  name = "%s"
+
+  config {
+    ipv4.address = "10.150.19.1/24"
+    ipv4.nat     = "true"
+  }
+}
+
+resource "lxd_instance" "instance1" {
+  name  = "%s"
+  image = "images:alpine/3.9/amd64"
+
+  device {
+    name = "eth0"
+    type = "nic"
+
+    properties {
+      network      = "${lxd_network.network1.name}"
+      hwaddr       = "00:16:3e:13:00:c8"
+      ipv4.address = "%s"
+    }
+  }
+}
+	`, networkName, name, address)
+}
+
+func testAccInstance_security(name string, privileged bool) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name       = "%s"
+  image      = "images:alpine/3.9/amd64"
+  nesting    = true
+  privileged = %t
+}
+	`, name, privileged)
+}
+
+func testAccInstance_protected(name string, protected bool) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name      = "%s"
+  image     = "images:alpine/3.9/amd64"
+  protected = %t
+}
+	`, name, protected)
+}
+
+func testAccInstance_snapshotSchedule(name, schedule, expiry string) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name  = "%s"
+  image = "images:alpine/3.9/amd64"
+
+  snapshot_schedule {
+    schedule = "%s"
+    pattern  = "daily-%%d"
+    expiry   = "%s"
+  }
+}
+	`, name, schedule, expiry)
+}
+
+func testAccInstance_idmap(name, instType string, idRange int) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name  = "%s"
+  type  = "%s"
+  image = "images:alpine/3.9/amd64"
+
+  idmap {
+    type        = "both"
+    host_id     = 1000
+    instance_id = 1000
+    range       = %d
+  }
+}
+	`, name, instType, idRange)
+}
+
+func testAccInstance_restartOnChange(name, policy string, privileged bool, tier string) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name              = "%s"
+  image             = "images:alpine/3.9/amd64"
+  restart_on_change = "%s"
+  privileged        = %t
+
+  config {
+    user.tier = "%s"
+  }
+}
+	`, name, policy, privileged, tier)
+}
+
+func testAccInstance_restartPending(name string, privileged, running bool) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name              = "%s"
+  image             = "images:alpine/3.9/amd64"
+  restart_on_change = "never"
+  privileged        = %t
+  running           = %t
+}
+	`, name, privileged, running)
+}
+
+func testAccInstance_autostart(name string, priority int) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name          = "%s"
+  image         = "images:alpine/3.9/amd64"
+  autostart     = true
+  boot_priority = %d
+  stop_priority = 5
+}
+	`, name, priority)
+}
+
+func testAccInstance_firmware(name, instType string) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name             = "%s"
+  type             = "%s"
+  image            = "images:ubuntu/focal/cloud"
+  csm              = true
+  agent_nic_config = true
+}
+	`, name, instType)
+}
+
+func testAccInstance_instanceType(name, instanceType string) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name          = "%s"
+  image         = "images:alpine/3.9/amd64"
+  instance_type = "%s"
+}
+	`, name, instanceType)
+}
+
+func testAccInstance_memoryLimits(name, memory, enforce string) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name = "%s"
+  image = "images:alpine/3.9/amd64"
+
+  limits {
+    memory         = "%s"
+    memory.enforce = "%s"
+  }
+}
+	`, name, memory, enforce)
+}
+
+func testAccInstance_limits(name, cpu string) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name = "%s"
+  image = "images:alpine/3.9/amd64"
+  profiles = ["default"]
+
+  limits {
+    cpu = "%s"
+  }
+}
+	`, name, cpu)
+}
+
+func testAccInstance_resourceLimits(name, cpu, memory string) string {
+	return fmt.Sprintf(`
+resource "lxd_instance" "instance1" {
+  name = "%s"
+  image = "images:alpine/3.9/amd64"
+  profiles = ["default"]
+
+  resource_limits {
+    cpu            = "%s"
+    memory         = "%s"
+    memory_enforce = "soft"
+  }
+}
+	`, name, cpu, memory)
+}
@@ -1,20 +1,33 @@
 package lxd
 
 import (
+	"bytes"
+	"fmt"
 	"log"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/hashicorp/terraform/helper/schema"
+	lxd "github.com/lxc/lxd/client"
 	"github.com/lxc/lxd/shared/api"
 )
 
 func resourceLxdNetwork() *schema.Resource {
 	return &schema.Resource{
 		Create: resourceLxdNetworkCreate,
-		//Update: resourceLxdNetworkUpdate,
+		Update: resourceLxdNetworkUpdate,
 		Delete: resourceLxdNetworkDelete,
 		Exists: resourceLxdNetworkExists,
 		Read:   resourceLxdNetworkRead,
 
+		CustomizeDiff: resourceLxdNetworkCustomizeDiff,
+
+		Importer: &schema.ResourceImporter{
+			State: resourceLxdNetworkImport,
+		},
+
 		Schema: map[string]*schema.Schema{
 			"name": &schema.Schema{
 				Type:     schema.TypeString,
@@ -25,17 +38,179 @@ func resourceLxdNetwork() *schema.Resource {
 			"description": &schema.Schema{
 				Type:     schema.TypeString,
 				Optional: true,
-				ForceNew: true,
+			},
+
+			"type": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: resourceLxdValidateNetworkType,
 			},
 
 			"config": &schema.Schema{
 				Type:     schema.TypeMap,
-				Required: true,
-				ForceNew: true,
+				Optional: true,
 			},
 
-			"type": &schema.Schema{
-				Type:     schema.TypeString,
+			"fan": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"underlay_subnet": &schema.Schema{
+							Type:     schema.TypeString,
+							Required: true,
+						},
+
+						"overlay_subnet": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+							Default:  "240.0.0.0/8",
+						},
+
+						"type": &schema.Schema{
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "vxlan",
+							ValidateFunc: resourceLxdValidateNetworkFanType,
+						},
+					},
+				},
+			},
+
+			"ipv6": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"address": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+
+						"mode": &schema.Schema{
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "slaac",
+							ValidateFunc: resourceLxdValidateNetworkIPv6Mode,
+						},
+
+						"dhcp": &schema.Schema{
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  true,
+						},
+
+						"dhcp_ranges": &schema.Schema{
+							Type:     schema.TypeList,
+							Optional: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+
+						"dhcp_expiry": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+
+						"nat": &schema.Schema{
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  false,
+						},
+
+						"routes": &schema.Schema{
+							Type:     schema.TypeList,
+							Optional: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+
+			"dhcp_host": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"mac": &schema.Schema{
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: resourceLxdValidateMAC,
+						},
+
+						"address": &schema.Schema{
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: resourceLxdValidateIP,
+						},
+
+						"hostname": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+					},
+				},
+			},
+
+			"dhcp_option": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"option": &schema.Schema{
+							Type:     schema.TypeString,
+							Required: true,
+						},
+
+						"value": &schema.Schema{
+							Type:     schema.TypeString,
+							Required: true,
+						},
+					},
+				},
+			},
+
+			"external_interfaces": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: resourceLxdValidateInterfaceName,
+				},
+			},
+
+			"member_config": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"target": &schema.Schema{
+							Type:     schema.TypeString,
+							Required: true,
+						},
+
+						"config": &schema.Schema{
+							Type:     schema.TypeMap,
+							Optional: true,
+						},
+
+						"external_interfaces": &schema.Schema{
+							Type:     schema.TypeList,
+							Optional: true,
+							Elem: &schema.Schema{
+								Type:         schema.TypeString,
+								ValidateFunc: resourceLxdValidateInterfaceName,
+							},
+						},
+					},
+				},
+			},
+
+			"expanded_config": &schema.Schema{
+				Type:     schema.TypeMap,
 				Computed: true,
 			},
 
@@ -44,29 +219,68 @@ func resourceLxdNetwork() *schema.Resource {
 				Computed: true,
 			},
 
+			"used_by": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
 			"remote": &schema.Schema{
 				Type:     schema.TypeString,
 				Optional: true,
 				ForceNew: true,
 				Default:  "",
 			},
+
+			"project": &schema.Schema{
+				Type:     schema.TypeString,
+				ForceNew: true,
+				Optional: true,
+			},
 		},
 	}
 }
 
 func resourceLxdNetworkCreate(d *schema.ResourceData, meta interface{}) error {
 	p := meta.(*lxdProvider)
-	server, err := p.GetContainerServer(p.selectRemote(d))
+	server, err := p.selectServer(d)
 	if err != nil {
 		return err
 	}
 
 	name := d.Get("name").(string)
 	desc := d.Get("description").(string)
-	config := resourceLxdConfigMap(d.Get("config"))
+	config := networkConfig(d.Get)
+
+	netType := d.Get("type").(string)
+
+	// A network of a cluster is first defined on each member with the
+	// config specific to it, such as its parent interface, and then
+	// created on all of them at once.
+	members := d.Get("member_config").([]interface{})
+	if len(members) > 0 {
+		delete(config, "bridge.external_interfaces")
+	}
+	for _, m := range members {
+		member := m.(map[string]interface{})
+		target := member["target"].(string)
+
+		log.Printf("[DEBUG] Defining network %s on cluster member %s", name, target)
+		req := api.NetworksPost{Name: name, Type: netType}
+		req.Config = networkMemberConfig(member, d.Get("external_interfaces"))
+
+		mutex.Lock()
+		err = server.UseTarget(target).CreateNetwork(req)
+		mutex.Unlock()
+
+		if err != nil {
+			resourceLxdNetworkDeletePending(server, name)
+			return fmt.Errorf("Unable to define network (%s) on cluster member %s: %s", name, target, err)
+		}
+	}
 
 	log.Printf("[DEBUG] Creating network %s with config: %#v", name, config)
-	req := api.NetworksPost{Name: name}
+	req := api.NetworksPost{Name: name, Type: netType}
 	req.Config = config
 	req.Description = desc
 
@@ -79,6 +293,10 @@ func resourceLxdNetworkCreate(d *schema.ResourceData, meta interface{}) error {
 			err = errNetworksNotImplemented
 		}
 
+		if len(members) > 0 {
+			resourceLxdNetworkDeletePending(server, name)
+		}
+
 		return err
 	}
 
@@ -89,7 +307,7 @@ func resourceLxdNetworkCreate(d *schema.ResourceData, meta interface{}) error {
 
 func resourceLxdNetworkRead(d *schema.ResourceData, meta interface{}) error {
 	p := meta.(*lxdProvider)
-	server, err := p.GetContainerServer(p.selectRemote(d))
+	server, err := p.selectServer(d)
 	if err != nil {
 		return err
 	}
@@ -102,34 +320,262 @@ func resourceLxdNetworkRead(d *schema.ResourceData, meta interface{}) error {
 
 	log.Printf("[DEBUG] Retrieved network %s: %#v", name, network)
 
-	d.Set("config", network.Config)
+	// Only the keys set through config are tracked. The ones LXD fills in
+	// itself, such as the addresses of a bridge left to auto, are only
+	// part of expanded_config.
+	declared := d.Get("config").(map[string]interface{})
+	config := make(map[string]string)
+	for k, v := range network.Config {
+		if _, ok := declared[k]; ok {
+			config[k] = v
+		}
+	}
+
+	// The dhcp-host and dhcp-option lines of raw.dnsmasq go to the
+	// dhcp_host and dhcp_option blocks, unless raw.dnsmasq was set
+	// through config alone.
+	_, rawDeclared := declared["raw.dnsmasq"]
+	blocks := len(d.Get("dhcp_host").([]interface{}))+len(d.Get("dhcp_option").([]interface{})) > 0
+	if !rawDeclared || blocks {
+		hosts, options, rest := networkDnsmasq(network.Config["raw.dnsmasq"])
+		if rawDeclared {
+			if strings.HasSuffix(declared["raw.dnsmasq"].(string), "\n") && rest != "" {
+				rest += "\n"
+			}
+			config["raw.dnsmasq"] = rest
+		}
+		d.Set("dhcp_host", hosts)
+		d.Set("dhcp_option", options)
+	} else {
+		d.Set("dhcp_host", nil)
+		d.Set("dhcp_option", nil)
+	}
+
+	d.Set("config", config)
+	d.Set("expanded_config", network.Config)
+
+	// The keys of fan bridges go to the fan block, unless bridge.mode
+	// was set through config.
+	if _, ok := declared["bridge.mode"]; !ok && network.Config["bridge.mode"] == "fan" {
+		fan := map[string]interface{}{
+			"overlay_subnet": "240.0.0.0/8",
+			"type":           "vxlan",
+		}
+		for attr, key := range networkFanKeys {
+			if v, ok := network.Config[key]; ok {
+				fan[attr] = v
+			}
+		}
+		d.Set("fan", []interface{}{fan})
+	} else {
+		d.Set("fan", nil)
+	}
+
+	// So do the IPv6 keys, when the ipv6 block is used. The address is
+	// only tracked when set, LXD picks one otherwise.
+	if blocks := d.Get("ipv6").([]interface{}); len(blocks) > 0 {
+		declaredIPv6, _ := blocks[0].(map[string]interface{})
+		ipv6 := map[string]interface{}{
+			"mode":        "slaac",
+			"dhcp":        network.Config["ipv6.dhcp"] != "false",
+			"dhcp_ranges": networkInterfaces(network.Config["ipv6.dhcp.ranges"]),
+			"dhcp_expiry": network.Config["ipv6.dhcp.expiry"],
+			"nat":         network.Config["ipv6.nat"] == "true",
+			"routes":      networkInterfaces(network.Config["ipv6.routes"]),
+		}
+		if network.Config["ipv6.dhcp.stateful"] == "true" {
+			ipv6["mode"] = "stateful"
+		}
+		if v, _ := declaredIPv6["address"].(string); v != "" {
+			ipv6["address"] = network.Config["ipv6.address"]
+		}
+		d.Set("ipv6", []interface{}{ipv6})
+	}
+
+	// The same goes for the keys specific to each cluster member. The
+	// external interfaces are one of them, only known to the members on
+	// clusters.
+	members := d.Get("member_config").([]interface{})
+	if len(d.Get("external_interfaces").([]interface{})) > 0 && len(members) == 0 {
+		d.Set("external_interfaces", networkInterfaces(network.Config["bridge.external_interfaces"]))
+	}
+
+	for i, m := range members {
+		member := m.(map[string]interface{})
+		target := member["target"].(string)
+
+		memberNetwork, _, err := server.UseTarget(target).GetNetwork(name)
+		if err != nil {
+			return fmt.Errorf("Unable to retrieve network (%s) on cluster member %s: %s", name, target, err)
+		}
+
+		memberConfig := make(map[string]string)
+		for k := range member["config"].(map[string]interface{}) {
+			if v, ok := memberNetwork.Config[k]; ok {
+				memberConfig[k] = v
+			}
+		}
+
+		var externalInterfaces []string
+		if len(member["external_interfaces"].([]interface{})) > 0 {
+			externalInterfaces = networkInterfaces(memberNetwork.Config["bridge.external_interfaces"])
+		}
+
+		members[i] = map[string]interface{}{
+			"target":              target,
+			"config":              memberConfig,
+			"external_interfaces": externalInterfaces,
+		}
+	}
+	d.Set("member_config", members)
 	d.Set("description", network.Description)
 	d.Set("type", network.Type)
 	d.Set("managed", network.Managed)
+	d.Set("used_by", network.UsedBy)
 
 	return nil
 }
 
 func resourceLxdNetworkUpdate(d *schema.ResourceData, meta interface{}) error {
-	// Network is not able to be updated yet.
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	name := d.Id()
+
+	network, etag, err := server.GetNetwork(name)
+	if err != nil {
+		return err
+	}
+
+	newNetwork := network.Writable()
+	if newNetwork.Config == nil {
+		newNetwork.Config = map[string]string{}
+	}
+
+	if d.HasChange("description") {
+		newNetwork.Description = d.Get("description").(string)
+	}
+
+	// Keys no longer set are removed, letting LXD fill them in again
+	// when it does so.
+	if resourceLxdNetworkConfigChanged(d) {
+		oldConfig := networkConfig(func(k string) interface{} { o, _ := d.GetChange(k); return o })
+		newConfig := networkConfig(func(k string) interface{} { _, n := d.GetChange(k); return n })
+
+		// The external interfaces of cluster members are set on each
+		// of them below.
+		if len(d.Get("member_config").([]interface{})) > 0 {
+			delete(oldConfig, "bridge.external_interfaces")
+			delete(newConfig, "bridge.external_interfaces")
+		}
+
+		for k := range oldConfig {
+			delete(newNetwork.Config, k)
+		}
+
+		for k, v := range newConfig {
+			newNetwork.Config[k] = v
+		}
+	}
+
+	log.Printf("[DEBUG] Updating network %s with config: %#v", name, newNetwork.Config)
+	mutex.Lock()
+	err = server.UpdateNetwork(name, newNetwork, etag)
+	mutex.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("Unable to update network (%s): %s", name, err)
+	}
+
+	if d.HasChange("member_config") || d.HasChange("external_interfaces") {
+		if err := resourceLxdNetworkUpdateMembers(d, server); err != nil {
+			return err
+		}
+	}
+
+	return resourceLxdNetworkRead(d, meta)
+}
+
+// resourceLxdNetworkUpdateMembers updates the config specific to each
+// cluster member. The members stay the same, changing them re-creates
+// the network.
+func resourceLxdNetworkUpdateMembers(d *schema.ResourceData, server lxd.ContainerServer) error {
+	name := d.Id()
+	oldMembers, newMembers := d.GetChange("member_config")
+	oldInterfaces, newInterfaces := d.GetChange("external_interfaces")
+
+	old := make(map[string]map[string]string)
+	for _, m := range oldMembers.([]interface{}) {
+		member := m.(map[string]interface{})
+		old[member["target"].(string)] = networkMemberConfig(member, oldInterfaces)
+	}
+
+	for _, m := range newMembers.([]interface{}) {
+		member := m.(map[string]interface{})
+		target := member["target"].(string)
+		targetServer := server.UseTarget(target)
+
+		memberNetwork, etag, err := targetServer.GetNetwork(name)
+		if err != nil {
+			return fmt.Errorf("Unable to retrieve network (%s) on cluster member %s: %s", name, target, err)
+		}
+
+		newNetwork := memberNetwork.Writable()
+		if newNetwork.Config == nil {
+			newNetwork.Config = map[string]string{}
+		}
+
+		for k := range old[target] {
+			delete(newNetwork.Config, k)
+		}
+
+		for k, v := range networkMemberConfig(member, newInterfaces) {
+			newNetwork.Config[k] = v
+		}
+
+		log.Printf("[DEBUG] Updating network %s on cluster member %s with config: %#v", name, target, newNetwork.Config)
+		mutex.Lock()
+		err = targetServer.UpdateNetwork(name, newNetwork, etag)
+		mutex.Unlock()
+
+		if err != nil {
+			return fmt.Errorf("Unable to update network (%s) on cluster member %s: %s", name, target, err)
+		}
+	}
+
 	return nil
 }
 
 func resourceLxdNetworkDelete(d *schema.ResourceData, meta interface{}) (err error) {
 	p := meta.(*lxdProvider)
-	server, err := p.GetContainerServer(p.selectRemote(d))
+	server, err := p.selectServer(d)
 	if err != nil {
 		return err
 	}
 
 	name := d.Id()
 
+	// NICs of instances and profiles may still be attached to the network.
+	gone, err := resourceLxdCheckUnused("Network", name, func() ([]string, error) {
+		network, _, err := server.GetNetwork(name)
+		if err != nil {
+			return nil, err
+		}
+		return network.UsedBy, nil
+	})
+	if err != nil || gone {
+		return err
+	}
+
 	return server.DeleteNetwork(name)
 }
 
 func resourceLxdNetworkExists(d *schema.ResourceData, meta interface{}) (exists bool, err error) {
 	p := meta.(*lxdProvider)
-	server, err := p.GetContainerServer(p.selectRemote(d))
+	server, err := p.selectServer(d)
 	if err != nil {
 		return false, err
 	}
@@ -144,3 +590,652 @@ func resourceLxdNetworkExists(d *schema.ResourceData, meta interface{}) (exists
 
 	return
 }
+
+func resourceLxdNetworkImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	p := meta.(*lxdProvider)
+	log.Printf("[DEBUG] Starting import for %s", d.Id())
+
+	remote, name, err := p.LXDConfig.ParseRemote(d.Id())
+	if err != nil {
+		return nil, err
+	}
+
+	if p.LXDConfig.DefaultRemote != remote {
+		d.Set("remote", remote)
+	}
+
+	if parts := strings.SplitN(name, "/", 2); len(parts) == 2 {
+		d.Set("project", parts[0])
+		name = parts[1]
+	}
+
+	server, err := p.selectServer(d)
+	if err != nil {
+		return nil, err
+	}
+
+	network, _, err := server.GetNetwork(name)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to import network (%s): %s", name, err)
+	}
+
+	if !network.Managed {
+		return nil, fmt.Errorf("Unable to import network (%s): only managed networks can be imported", name)
+	}
+
+	log.Printf("[DEBUG] Import network %#v", network)
+
+	// All the keys of an imported network are managed, but for the
+	// ones of the fan and dhcp blocks, which go to the blocks.
+	config := make(map[string]string)
+	for k, v := range network.Config {
+		if strings.HasPrefix(k, "volatile.") {
+			continue
+		}
+		config[k] = v
+	}
+
+	if config["bridge.mode"] == "fan" {
+		delete(config, "bridge.mode")
+		for _, key := range networkFanKeys {
+			delete(config, key)
+		}
+	}
+
+	// The external interfaces go to external_interfaces.
+	d.Set("external_interfaces", networkInterfaces(config["bridge.external_interfaces"]))
+	delete(config, "bridge.external_interfaces")
+
+	hosts, options, rest := networkDnsmasq(config["raw.dnsmasq"])
+	delete(config, "raw.dnsmasq")
+	if rest != "" {
+		config["raw.dnsmasq"] = rest
+	}
+
+	d.SetId(name)
+	d.Set("name", name)
+	d.Set("config", config)
+	d.Set("dhcp_host", hosts)
+	d.Set("dhcp_option", options)
+
+	if err := resourceLxdNetworkRead(d, meta); err != nil {
+		return nil, fmt.Errorf("Unable to import network (%s): %s", name, err)
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func resourceLxdNetworkCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	netType := d.Get("type").(string)
+
+	// Static reservations and DHCP options go to dnsmasq, which only
+	// serves bridges, and only bridges have external interfaces.
+	if netType != "" && netType != "bridge" {
+		for _, k := range []string{"dhcp_host", "dhcp_option", "external_interfaces"} {
+			if len(d.Get(k).([]interface{})) > 0 {
+				return fmt.Errorf("%s is only supported by bridge networks, not %s networks", k, netType)
+			}
+		}
+	}
+
+	if _, ok := d.Get("config").(map[string]interface{})["bridge.external_interfaces"]; ok && len(d.Get("external_interfaces").([]interface{})) > 0 {
+		return fmt.Errorf("bridge.external_interfaces can't be set through both config and external_interfaces")
+	}
+
+	if len(d.Get("ipv6").([]interface{})) > 0 {
+		if netType != "" && netType != "bridge" && netType != "ovn" {
+			return fmt.Errorf("ipv6 is only supported by bridge and ovn networks, not %s networks", netType)
+		}
+
+		for _, key := range networkIPv6Keys {
+			if _, ok := d.Get("config").(map[string]interface{})[key]; ok {
+				return fmt.Errorf("%s can't be set through both config and ipv6", key)
+			}
+		}
+	}
+
+	// The config of cluster members is updated in place, but the
+	// network is defined on the members when it's created.
+	if d.Id() != "" && d.HasChange("member_config") {
+		old, new := d.GetChange("member_config")
+		if !sameTargets(old.([]interface{}), new.([]interface{})) {
+			if err := d.ForceNew("member_config"); err != nil {
+				return err
+			}
+		}
+	}
+
+	known := true
+	for _, k := range networkConfigAttributes {
+		known = known && d.NewValueKnown(k)
+	}
+	if (d.Id() == "" || resourceLxdNetworkConfigChanged(d)) && known {
+		config := networkConfig(d.Get)
+
+		// On a cluster the parent interface usually differs from a member
+		// to another, each member then has its own.
+		members := d.Get("member_config").([]interface{})
+		if len(members) == 0 || !d.NewValueKnown("member_config") {
+			if err := resourceLxdNetworkCheckConfig(netType, config); err != nil {
+				return err
+			}
+		}
+		for _, m := range members {
+			member := m.(map[string]interface{})
+			memberConfig := networkMemberConfig(member, d.Get("external_interfaces"))
+			for k, v := range config {
+				if _, ok := memberConfig[k]; !ok {
+					memberConfig[k] = v
+				}
+			}
+
+			if err := resourceLxdNetworkCheckConfig(netType, memberConfig); err != nil {
+				return fmt.Errorf("Cluster member %s: %s", member["target"], err)
+			}
+		}
+
+		// The keys each type of network supports differ, LXD creates
+		// bridges unless told otherwise.
+		entity := "network-bridge"
+		if netType != "" {
+			entity = "network-" + netType
+		}
+		if err := resourceLxdCheckConfigKeys(d, meta.(*lxdProvider), entity, config); err != nil {
+			return err
+		}
+	}
+
+	// The uplink of an OVN network is checked when planning, as LXD
+	// only reports a wrong one once the network is half created.
+	if netType == "ovn" && (d.Id() == "" || d.HasChange("config")) && d.NewValueKnown("config.network") {
+		config := d.Get("config").(map[string]interface{})
+		if uplink, ok := config["network"].(string); ok && uplink != "" {
+			server, err := resourceLxdInstanceDiffServer(d, meta.(*lxdProvider))
+			if err != nil {
+				return err
+			}
+
+			project, err := resourceLxdServerProject(server)
+			if err != nil {
+				return err
+			}
+
+			if err := resourceLxdNetworkCheckUplink(server, project, uplink); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// resourceLxdNetworkDeletePending removes a network whose creation
+// failed part way on a cluster, leaving it pending on some members.
+func resourceLxdNetworkDeletePending(server lxd.ContainerServer, name string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if err := server.DeleteNetwork(name); err != nil {
+		log.Printf("[WARN] Unable to remove pending network %s: %s", name, err)
+	}
+}
+
+// resourceLxdNetworkCheckConfig checks the options of the networks
+// attached to a host interface: macvlan, sriov and physical ones, the
+// subnets of fan bridges, and the IPv6 options of bridge and OVN
+// networks.
+func resourceLxdNetworkCheckConfig(netType string, config map[string]string) error {
+	switch netType {
+	case "macvlan", "sriov", "physical":
+	case "", "bridge":
+		if config["bridge.mode"] == "fan" {
+			if err := resourceLxdNetworkCheckFan(config); err != nil {
+				return err
+			}
+		}
+		return resourceLxdNetworkCheckIPv6(config)
+	default:
+		if config["bridge.mode"] == "fan" {
+			return fmt.Errorf("Only bridge networks can be fan bridges, not %s networks", netType)
+		}
+		if netType == "ovn" {
+			return resourceLxdNetworkCheckIPv6(config)
+		}
+		return nil
+	}
+
+	if config["parent"] == "" {
+		return fmt.Errorf("config.parent must be set to the host interface of %s networks", netType)
+	}
+
+	if v, ok := config["vlan"]; ok {
+		if vlan, err := strconv.Atoi(v); err != nil || vlan < 0 || vlan > 4094 {
+			return fmt.Errorf("config.vlan must be a VLAN ID between 0 and 4094, not %q", v)
+		}
+	}
+
+	if v, ok := config["mtu"]; ok {
+		if mtu, err := strconv.Atoi(v); err != nil || mtu < 1 {
+			return fmt.Errorf("config.mtu must be a positive integer, not %q", v)
+		}
+	}
+
+	return nil
+}
+
+// resourceLxdNetworkCheckFan checks the subnets of a fan bridge. The
+// overlay subnet is split in a subnet per host of the underlay subnet,
+// each large enough for a /24 of addresses.
+func resourceLxdNetworkCheckFan(config map[string]string) error {
+	underlaySize := 16
+	if v := config["fan.underlay_subnet"]; v != "" && v != "auto" {
+		_, underlay, err := net.ParseCIDR(v)
+		if err != nil || underlay.IP.To4() == nil {
+			return fmt.Errorf("fan.underlay_subnet must be an IPv4 subnet in CIDR notation, not %q", v)
+		}
+
+		underlaySize, _ = underlay.Mask.Size()
+		if underlaySize != 16 && underlaySize != 24 {
+			return fmt.Errorf("fan.underlay_subnet must be a /16 or /24 subnet, not %q", v)
+		}
+	}
+
+	overlaySize := 8
+	if v := config["fan.overlay_subnet"]; v != "" {
+		_, overlay, err := net.ParseCIDR(v)
+		if err != nil || overlay.IP.To4() == nil {
+			return fmt.Errorf("fan.overlay_subnet must be an IPv4 subnet in CIDR notation, not %q", v)
+		}
+
+		overlaySize, _ = overlay.Mask.Size()
+		if overlaySize != 8 && overlaySize != 16 {
+			return fmt.Errorf("fan.overlay_subnet must be a /8 or /16 subnet, not %q", v)
+		}
+	}
+
+	if overlaySize+(32-underlaySize)+8 > 32 {
+		return fmt.Errorf("fan.overlay_subnet /%d is too small to give a /24 to each host of the /%d fan.underlay_subnet", overlaySize, underlaySize)
+	}
+
+	if v, ok := config["fan.type"]; ok && v != "vxlan" && v != "ipip" {
+		return fmt.Errorf("fan.type must be vxlan or ipip, not %q", v)
+	}
+
+	return nil
+}
+
+// resourceLxdNetworkCheckIPv6 checks that the IPv6 options of a network
+// go together. SLAAC needs a /64 subnet, stateful DHCPv6 a subnet of any
+// size to hand out addresses from, within its DHCP ranges.
+func resourceLxdNetworkCheckIPv6(config map[string]string) error {
+	address := config["ipv6.address"]
+	stateful := config["ipv6.dhcp.stateful"] == "true"
+
+	if address == "none" {
+		for _, key := range []string{"ipv6.dhcp.stateful", "ipv6.dhcp.ranges", "ipv6.nat", "ipv6.routes"} {
+			if v, ok := config[key]; ok && v != "" && v != "false" {
+				return fmt.Errorf("%s needs an IPv6 subnet, ipv6.address is none", key)
+			}
+		}
+		return nil
+	}
+
+	var subnet *net.IPNet
+	if address != "" && address != "auto" {
+		ip, ipNet, err := net.ParseCIDR(address)
+		if err != nil || ip.To4() != nil {
+			return fmt.Errorf("ipv6.address must be an IPv6 address in CIDR notation, auto or none, not %q", address)
+		}
+		subnet = ipNet
+
+		if size, _ := subnet.Mask.Size(); size != 64 && !stateful {
+			return fmt.Errorf("SLAAC needs a /64 ipv6.address, not a /%d: use stateful DHCPv6 for other subnets", size)
+		}
+	}
+
+	if stateful && config["ipv6.dhcp"] == "false" {
+		return fmt.Errorf("ipv6.dhcp.stateful needs ipv6.dhcp")
+	}
+
+	if v := config["ipv6.dhcp.ranges"]; v != "" {
+		if !stateful {
+			return fmt.Errorf("ipv6.dhcp.ranges are only used by stateful DHCPv6, ipv6.dhcp.stateful must be set")
+		}
+
+		for _, r := range networkInterfaces(v) {
+			bounds := strings.SplitN(r, "-", 2)
+			if len(bounds) != 2 {
+				return fmt.Errorf("ipv6.dhcp.ranges must be ranges of the form start-end, not %q", r)
+			}
+
+			start, end := net.ParseIP(bounds[0]), net.ParseIP(bounds[1])
+			if start == nil || end == nil || start.To4() != nil || end.To4() != nil {
+				return fmt.Errorf("ipv6.dhcp.ranges must be ranges of IPv6 addresses, not %q", r)
+			}
+
+			if bytes.Compare(start, end) > 0 {
+				return fmt.Errorf("ipv6.dhcp.ranges must start before they end, not %q", r)
+			}
+
+			if subnet != nil && (!subnet.Contains(start) || !subnet.Contains(end)) {
+				return fmt.Errorf("ipv6.dhcp.ranges must be within ipv6.address %s, not %q", address, r)
+			}
+		}
+	}
+
+	for _, r := range networkInterfaces(config["ipv6.routes"]) {
+		if ip, _, err := net.ParseCIDR(r); err != nil || ip.To4() != nil {
+			return fmt.Errorf("ipv6.routes must be IPv6 subnets in CIDR notation, not %q", r)
+		}
+	}
+
+	return nil
+}
+
+// resourceLxdNetworkCheckUplink checks that a network can be the uplink
+// of the OVN networks of a project. Uplinks are bridge or physical
+// networks of the default project, shared with the projects that aren't
+// restricted to other ones.
+func resourceLxdNetworkCheckUplink(server lxd.ContainerServer, project, uplink string) error {
+	network, _, err := server.UseProject("default").GetNetwork(uplink)
+	if err != nil {
+		if err.Error() == "not found" {
+			return fmt.Errorf("Uplink network (%s) not found in the default project", uplink)
+		}
+		return err
+	}
+
+	if !network.Managed || (network.Type != "bridge" && network.Type != "physical") {
+		return fmt.Errorf("Uplink network (%s) must be a managed bridge or physical network", uplink)
+	}
+
+	if project == "default" {
+		return nil
+	}
+
+	p, _, err := server.GetProject(project)
+	if err != nil {
+		return fmt.Errorf("Unable to retrieve project (%s): %s", project, err)
+	}
+
+	if p.Config["restricted"] == "true" {
+		for _, name := range networkInterfaces(p.Config["restricted.networks.uplinks"]) {
+			if name == uplink {
+				return nil
+			}
+		}
+		return fmt.Errorf("Uplink network (%s) isn't one of the restricted.networks.uplinks of project %s", uplink, project)
+	}
+
+	return nil
+}
+
+// resourceLxdNetworkProject returns the project holding the networks of
+// a project: the project itself when it has features.networks, or the
+// default project it shares the networks of.
+func resourceLxdNetworkProject(server lxd.ContainerServer, project string) (string, error) {
+	if project == "" || project == "default" {
+		return "default", nil
+	}
+
+	p, _, err := server.GetProject(project)
+	if err != nil {
+		return "", fmt.Errorf("Unable to retrieve project (%s): %s", project, err)
+	}
+
+	if p.Config["features.networks"] != "true" {
+		return "default", nil
+	}
+
+	return project, nil
+}
+
+// resourceLxdValidateNetworkFanType validates the underlay type of a fan
+// network: vxlan tunnels or ipip encapsulation.
+func resourceLxdValidateNetworkFanType(v interface{}, k string) (ws []string, errors []error) {
+	switch v.(string) {
+	case "vxlan", "ipip":
+	default:
+		errors = append(errors, fmt.Errorf(
+			"Only vxlan and ipip are supported values for '%s'", k))
+	}
+
+	return
+}
+
+// resourceLxdValidateNetworkIPv6Mode validates the mode of the ipv6 block.
+func resourceLxdValidateNetworkIPv6Mode(v interface{}, k string) (ws []string, errors []error) {
+	switch v.(string) {
+	case "slaac", "stateful":
+	default:
+		errors = append(errors, fmt.Errorf(
+			"Only slaac and stateful are supported values for '%s'", k))
+	}
+
+	return
+}
+
+// networkIPv6Keys maps the attributes of the ipv6 block
+// to the config keys of the network.
+var networkIPv6Keys = map[string]string{
+	"address":     "ipv6.address",
+	"mode":        "ipv6.dhcp.stateful",
+	"dhcp":        "ipv6.dhcp",
+	"dhcp_ranges": "ipv6.dhcp.ranges",
+	"dhcp_expiry": "ipv6.dhcp.expiry",
+	"nat":         "ipv6.nat",
+	"routes":      "ipv6.routes",
+}
+
+// networkFanKeys maps the attributes of the fan block
+// to the config keys of the network.
+var networkFanKeys = map[string]string{
+	"underlay_subnet": "fan.underlay_subnet",
+	"overlay_subnet":  "fan.overlay_subnet",
+	"type":            "fan.type",
+}
+
+// networkConfigAttributes are the attributes networkConfig builds the
+// config of a network from.
+var networkConfigAttributes = []string{"config", "fan", "ipv6", "dhcp_host", "dhcp_option", "external_interfaces"}
+
+// resourceLxdNetworkConfigChanged tells whether the config of a network
+// changes, through one of networkConfigAttributes.
+func resourceLxdNetworkConfigChanged(d interface{ HasChange(string) bool }) bool {
+	for _, k := range networkConfigAttributes {
+		if d.HasChange(k) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// networkConfig returns the config of a network, along with the keys
+// of the fan bridge set by its fan block, the IPv6 keys of its ipv6
+// block, the raw.dnsmasq lines of its dhcp_host and dhcp_option blocks,
+// and its external interfaces. get returns the value of an attribute,
+// e.g. d.Get.
+func networkConfig(get func(string) interface{}) map[string]string {
+	c := resourceLxdConfigMap(get("config"))
+	for _, v := range get("fan").([]interface{}) {
+		block, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		c["bridge.mode"] = "fan"
+		for attr, key := range networkFanKeys {
+			if v, ok := block[attr].(string); ok && v != "" {
+				c[key] = v
+			}
+		}
+	}
+
+	// The switches of the ipv6 block are always set, so that turning
+	// them off doesn't leave LXD to pick.
+	for _, v := range get("ipv6").([]interface{}) {
+		block, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		if v, ok := block["address"].(string); ok && v != "" {
+			c["ipv6.address"] = v
+		}
+		if v, ok := block["dhcp_expiry"].(string); ok && v != "" {
+			c["ipv6.dhcp.expiry"] = v
+		}
+		if v, ok := block["dhcp_ranges"].([]interface{}); ok && len(v) > 0 {
+			c["ipv6.dhcp.ranges"] = networkJoinInterfaces(v)
+		}
+		if v, ok := block["routes"].([]interface{}); ok && len(v) > 0 {
+			c["ipv6.routes"] = networkJoinInterfaces(v)
+		}
+
+		dhcp, _ := block["dhcp"].(bool)
+		nat, _ := block["nat"].(bool)
+		c["ipv6.dhcp"] = strconv.FormatBool(dhcp)
+		c["ipv6.dhcp.stateful"] = strconv.FormatBool(block["mode"] == "stateful")
+		c["ipv6.nat"] = strconv.FormatBool(nat)
+	}
+
+	if interfaces := get("external_interfaces").([]interface{}); len(interfaces) > 0 {
+		c["bridge.external_interfaces"] = networkJoinInterfaces(interfaces)
+	}
+
+	var lines []string
+	for _, v := range get("dhcp_host").([]interface{}) {
+		block, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		fields := []string{block["mac"].(string)}
+		for _, attr := range []string{"address", "hostname"} {
+			if v, ok := block[attr].(string); ok && v != "" {
+				fields = append(fields, v)
+			}
+		}
+		lines = append(lines, "dhcp-host="+strings.Join(fields, ","))
+	}
+
+	for _, v := range get("dhcp_option").([]interface{}) {
+		block, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		lines = append(lines, fmt.Sprintf("dhcp-option=%s,%s", block["option"], block["value"]))
+	}
+
+	if len(lines) > 0 {
+		if raw := strings.TrimRight(c["raw.dnsmasq"], "\n"); raw != "" {
+			lines = append([]string{raw}, lines...)
+		}
+		c["raw.dnsmasq"] = strings.Join(lines, "\n")
+	}
+
+	return c
+}
+
+// networkMemberConfig returns the config specific to a cluster member,
+// along with its external interfaces, which default to the ones of the
+// network.
+func networkMemberConfig(member map[string]interface{}, externalInterfaces interface{}) map[string]string {
+	c := resourceLxdConfigMap(member["config"])
+
+	interfaces, _ := member["external_interfaces"].([]interface{})
+	if len(interfaces) == 0 {
+		interfaces = externalInterfaces.([]interface{})
+	}
+	if len(interfaces) > 0 {
+		c["bridge.external_interfaces"] = networkJoinInterfaces(interfaces)
+	}
+
+	return c
+}
+
+func networkJoinInterfaces(interfaces []interface{}) string {
+	names := make([]string, 0, len(interfaces))
+	for _, v := range interfaces {
+		names = append(names, v.(string))
+	}
+
+	return strings.Join(names, ",")
+}
+
+// networkInterfaces splits a comma separated list of interfaces.
+func networkInterfaces(v string) []string {
+	var interfaces []string
+	for _, name := range strings.Split(v, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			interfaces = append(interfaces, name)
+		}
+	}
+
+	return interfaces
+}
+
+// sameTargets tells whether two lists of member_config blocks are for
+// the same cluster members.
+func sameTargets(a, b []interface{}) bool {
+	targets := func(members []interface{}) []string {
+		var result []string
+		for _, m := range members {
+			if member, ok := m.(map[string]interface{}); ok {
+				result = append(result, member["target"].(string))
+			}
+		}
+		sort.Strings(result)
+		return result
+	}
+
+	return strings.Join(targets(a), ",") == strings.Join(targets(b), ",")
+}
+
+// networkDnsmasq splits the raw.dnsmasq of a network in the blocks of
+// its dhcp-host and dhcp-option lines, and the rest of its lines.
+func networkDnsmasq(raw string) (hosts, options []interface{}, rest string) {
+	var lines []string
+	for _, line := range strings.Split(raw, "\n") {
+		switch {
+		case strings.HasPrefix(line, "dhcp-host="):
+			host := map[string]interface{}{}
+			fields := strings.Split(strings.TrimPrefix(line, "dhcp-host="), ",")
+			host["mac"] = fields[0]
+			for _, field := range fields[1:] {
+				if net.ParseIP(field) != nil {
+					host["address"] = field
+				} else {
+					host["hostname"] = field
+				}
+			}
+			hosts = append(hosts, host)
+
+		case strings.HasPrefix(line, "dhcp-option="):
+			fields := strings.SplitN(strings.TrimPrefix(line, "dhcp-option="), ",", 2)
+			option := map[string]interface{}{"option": fields[0]}
+			if len(fields) > 1 {
+				option["value"] = fields[1]
+			}
+			options = append(options, option)
+
+		case line != "":
+			lines = append(lines, line)
+		}
+	}
+
+	return hosts, options, strings.Join(lines, "\n")
+}
+
+func resourceLxdValidateNetworkType(v interface{}, k string) (ws []string, errors []error) {
+	switch v.(string) {
+	case "bridge", "ovn", "macvlan", "sriov", "physical":
+	default:
+		errors = append(errors, fmt.Errorf(
+			"Only bridge, ovn, macvlan, sriov and physical are supported values for '%s'", k))
+	}
+
+	return
+}
@@ -0,0 +1,613 @@
+package lxd
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/lxc/lxd/shared/api"
+)
+
+func resourceLxdNetworkACL() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceLxdNetworkACLCreate,
+		Update: resourceLxdNetworkACLUpdate,
+		Delete: resourceLxdNetworkACLDelete,
+		Exists: resourceLxdNetworkACLExists,
+		Read:   resourceLxdNetworkACLRead,
+
+		CustomizeDiff: resourceLxdNetworkACLCustomizeDiff,
+
+		Importer: &schema.ResourceImporter{
+			State: resourceLxdNetworkACLImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"description": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"config": &schema.Schema{
+				Type:     schema.TypeMap,
+				Optional: true,
+			},
+
+			"ingress": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem:     resourceLxdNetworkACLRule(),
+			},
+
+			"egress": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem:     resourceLxdNetworkACLRule(),
+			},
+
+			"used_by": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
+			"remote": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Default:  "",
+			},
+
+			"project": &schema.Schema{
+				Type:     schema.TypeString,
+				ForceNew: true,
+				Optional: true,
+			},
+		},
+	}
+}
+
+func resourceLxdNetworkACLRule() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"action": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: resourceLxdValidateNetworkACLAction,
+			},
+
+			"source": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"destination": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"protocol": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: resourceLxdValidateNetworkACLProtocol,
+			},
+
+			"source_port": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"destination_port": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"icmp_type": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"icmp_code": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"description": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"state": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "enabled",
+				ValidateFunc: resourceLxdValidateNetworkACLState,
+			},
+		},
+	}
+}
+
+func resourceLxdNetworkACLCreate(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	name := d.Get("name").(string)
+
+	req := api.NetworkACLsPost{}
+	req.Name = name
+	req.Description = d.Get("description").(string)
+	req.Config = resourceLxdConfigMap(d.Get("config"))
+	req.Ingress = resourceLxdNetworkACLRules(d.Get("ingress"))
+	req.Egress = resourceLxdNetworkACLRules(d.Get("egress"))
+
+	log.Printf("[DEBUG] Creating network ACL %s: %#v", name, req)
+	mutex.Lock()
+	err = server.CreateNetworkACL(req)
+	mutex.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("Unable to create network ACL (%s): %s", name, err)
+	}
+
+	d.SetId(name)
+
+	return resourceLxdNetworkACLRead(d, meta)
+}
+
+func resourceLxdNetworkACLRead(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+	name := d.Id()
+
+	acl, _, err := server.GetNetworkACL(name)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[DEBUG] Retrieved network ACL %s: %#v", name, acl)
+
+	d.Set("name", acl.Name)
+	d.Set("description", acl.Description)
+	d.Set("config", acl.Config)
+	d.Set("used_by", acl.UsedBy)
+
+	if err := d.Set("ingress", resourceLxdFlattenNetworkACLRules(acl.Ingress)); err != nil {
+		return err
+	}
+
+	if err := d.Set("egress", resourceLxdFlattenNetworkACLRules(acl.Egress)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func resourceLxdNetworkACLUpdate(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	name := d.Id()
+
+	acl, etag, err := server.GetNetworkACL(name)
+	if err != nil {
+		return err
+	}
+
+	// The rules are replaced as a whole, in order: LXD evaluates them
+	// by action rather than by position, but keeping them as declared
+	// avoids spurious diffs.
+	newACL := acl.Writable()
+	newACL.Description = d.Get("description").(string)
+	newACL.Config = resourceLxdConfigMap(d.Get("config"))
+	newACL.Ingress = resourceLxdNetworkACLRules(d.Get("ingress"))
+	newACL.Egress = resourceLxdNetworkACLRules(d.Get("egress"))
+
+	log.Printf("[DEBUG] Updating network ACL %s: %#v", name, newACL)
+	mutex.Lock()
+	err = server.UpdateNetworkACL(name, newACL, etag)
+	mutex.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("Unable to update network ACL (%s): %s", name, err)
+	}
+
+	return resourceLxdNetworkACLRead(d, meta)
+}
+
+func resourceLxdNetworkACLDelete(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	name := d.Id()
+
+	// Removing an ACL still applied to NICs or networks would leave them
+	// without their rules.
+	gone, err := resourceLxdCheckUnused("Network ACL", name, func() ([]string, error) {
+		acl, _, err := server.GetNetworkACL(name)
+		if err != nil {
+			return nil, err
+		}
+		return acl.UsedBy, nil
+	})
+	if err != nil || gone {
+		return err
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	return server.DeleteNetworkACL(name)
+}
+
+func resourceLxdNetworkACLExists(d *schema.ResourceData, meta interface{}) (exists bool, err error) {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return false, err
+	}
+
+	name := d.Id()
+
+	exists = false
+
+	if _, _, err := server.GetNetworkACL(name); err == nil {
+		exists = true
+	}
+
+	return
+}
+
+func resourceLxdNetworkACLImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	p := meta.(*lxdProvider)
+	log.Printf("[DEBUG] Starting import for %s", d.Id())
+
+	remote, name, err := p.LXDConfig.ParseRemote(d.Id())
+	if err != nil {
+		return nil, err
+	}
+
+	if p.LXDConfig.DefaultRemote != remote {
+		d.Set("remote", remote)
+	}
+
+	if parts := strings.SplitN(name, "/", 2); len(parts) == 2 {
+		d.Set("project", parts[0])
+		name = parts[1]
+	}
+
+	// The rules are rebuilt from the ACL by Read.
+	d.SetId(name)
+	if err := resourceLxdNetworkACLRead(d, meta); err != nil {
+		return nil, fmt.Errorf("Unable to import network ACL (%s): %s", name, err)
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func resourceLxdNetworkACLCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	p := meta.(*lxdProvider)
+
+	// Rules of ACLs planned after this one, because they refer to it,
+	// may name it before it exists.
+	if (d.Id() == "" || d.HasChange("name")) && d.NewValueKnown("name") {
+		p.planNetworkACL(resourceLxdNetworkACLPlanKey(d, p, d.Get("name").(string)))
+	}
+
+	if d.Id() != "" && !d.HasChange("ingress") && !d.HasChange("egress") {
+		return nil
+	}
+
+	// LXD rejects the whole ACL when one of its rules is wrong, so the
+	// rules are checked when planning, along with the ACLs they refer to.
+	references := map[string]string{}
+	for _, direction := range []string{"ingress", "egress"} {
+		for i, r := range d.Get(direction).([]interface{}) {
+			rule, ok := r.(map[string]interface{})
+			if !ok || !resourceLxdNetworkACLRuleKnown(d, direction, i) {
+				continue
+			}
+
+			names, err := resourceLxdNetworkACLCheckRule(rule)
+			if err != nil {
+				return fmt.Errorf("%s.%d: %s", direction, i, err)
+			}
+
+			for _, name := range names {
+				references[name] = fmt.Sprintf("%s.%d", direction, i)
+			}
+		}
+	}
+
+	if len(references) == 0 {
+		return nil
+	}
+
+	server, err := resourceLxdInstanceDiffServer(d, p)
+	if err != nil {
+		return err
+	}
+
+	acls, err := server.GetNetworkACLNames()
+	if err != nil {
+		return err
+	}
+
+	existing := map[string]bool{d.Get("name").(string): true}
+	for _, name := range acls {
+		existing[name] = true
+	}
+
+	// An ACL missing now may be created by another lxd_network_acl of the
+	// same apply, which has then been planned before this one.
+	for name, rule := range references {
+		if !existing[name] && !p.networkACLPlanned(resourceLxdNetworkACLPlanKey(d, p, name)) {
+			return fmt.Errorf("%s: network ACL (%s) not found", rule, name)
+		}
+	}
+
+	return nil
+}
+
+// resourceLxdNetworkACLPlanKey returns the key under which a network ACL
+// of the remote and project of d is recorded as planned for creation.
+func resourceLxdNetworkACLPlanKey(d *schema.ResourceDiff, p *lxdProvider, name string) string {
+	remote := d.Get("remote").(string)
+	if remote == "" {
+		remote = p.LXDConfig.DefaultRemote
+	}
+
+	return fmt.Sprintf("%s/%s/%s", remote, d.Get("project").(string), name)
+}
+
+// resourceLxdNetworkACLRuleKnown tells whether all the fields of a rule
+// are known when planning.
+func resourceLxdNetworkACLRuleKnown(d *schema.ResourceDiff, direction string, i int) bool {
+	for field := range resourceLxdNetworkACLRule().Schema {
+		if !d.NewValueKnown(fmt.Sprintf("%s.%d.%s", direction, i, field)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// resourceLxdNetworkACLCheckRule checks the fields of a rule against
+// each other, returning the names of the ACLs its subjects refer to.
+func resourceLxdNetworkACLCheckRule(rule map[string]interface{}) ([]string, error) {
+	protocol := rule["protocol"].(string)
+
+	var names []string
+	families := map[int]bool{}
+	for _, field := range []string{"source", "destination"} {
+		subjects := rule[field].(string)
+		if subjects == "" {
+			continue
+		}
+
+		for _, subject := range strings.Split(subjects, ",") {
+			subject = strings.TrimSpace(subject)
+			family, err := resourceLxdNetworkACLSubjectFamily(subject)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s", field, err)
+			}
+
+			switch family {
+			case 4, 6:
+				families[family] = true
+			case 0:
+				if !strings.HasPrefix(subject, "@") {
+					names = append(names, subject)
+				}
+			}
+		}
+	}
+
+	for _, field := range []string{"source_port", "destination_port"} {
+		ports := rule[field].(string)
+		if ports == "" {
+			continue
+		}
+
+		if protocol != "tcp" && protocol != "udp" {
+			return nil, fmt.Errorf("%s needs protocol tcp or udp", field)
+		}
+
+		for _, port := range strings.Split(ports, ",") {
+			if err := resourceLxdNetworkACLCheckPortRange(strings.TrimSpace(port)); err != nil {
+				return nil, fmt.Errorf("%s %s", field, err)
+			}
+		}
+	}
+
+	for _, field := range []string{"icmp_type", "icmp_code"} {
+		v := rule[field].(string)
+		if v == "" {
+			continue
+		}
+
+		if protocol != "icmp4" && protocol != "icmp6" {
+			return nil, fmt.Errorf("%s needs protocol icmp4 or icmp6", field)
+		}
+
+		if n, err := strconv.Atoi(v); err != nil || n < 0 || n > 255 {
+			return nil, fmt.Errorf("%s must be between 0 and 255, not %q", field, v)
+		}
+	}
+
+	if protocol == "icmp4" && families[6] {
+		return nil, fmt.Errorf("icmp4 rules can't match IPv6 subjects")
+	}
+
+	if protocol == "icmp6" && families[4] {
+		return nil, fmt.Errorf("icmp6 rules can't match IPv4 subjects")
+	}
+
+	return names, nil
+}
+
+// resourceLxdNetworkACLSubjectFamily returns the address family of the
+// subject of a rule: an address, a CIDR subnet or an address range. It
+// returns 0 for the names of ACLs and the @internal and @external
+// subjects of OVN networks.
+func resourceLxdNetworkACLSubjectFamily(subject string) (int, error) {
+	family := func(ip net.IP) int {
+		if ip.To4() != nil {
+			return 4
+		}
+		return 6
+	}
+
+	switch {
+	case subject == "":
+		return 0, fmt.Errorf("has an empty subject")
+
+	case subject == "@internal" || subject == "@external":
+		return 0, nil
+
+	case strings.Contains(subject, "/"):
+		ip, _, err := net.ParseCIDR(subject)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a valid CIDR subnet", subject)
+		}
+		return family(ip), nil
+
+	case strings.Contains(subject, "-") && strings.ContainsAny(subject, ".:") && strings.Trim(subject, "0123456789abcdefABCDEF.:-") == "":
+		parts := strings.SplitN(subject, "-", 2)
+		start, end := net.ParseIP(parts[0]), net.ParseIP(parts[1])
+		if start == nil || end == nil || family(start) != family(end) {
+			return 0, fmt.Errorf("%q is not a valid address range", subject)
+		}
+		if bytes.Compare(start.To16(), end.To16()) > 0 {
+			return 0, fmt.Errorf("%q is not a valid address range, it ends before it starts", subject)
+		}
+		return family(start), nil
+
+	case strings.Trim(subject, "0123456789.") == "" || strings.Contains(subject, ":"):
+		ip := net.ParseIP(subject)
+		if ip == nil {
+			return 0, fmt.Errorf("%q is not a valid IP address", subject)
+		}
+		return family(ip), nil
+	}
+
+	return 0, nil
+}
+
+// resourceLxdNetworkACLCheckPortRange checks a port, e.g. 22, or a range
+// of ports, e.g. 8000-8080.
+func resourceLxdNetworkACLCheckPortRange(ports string) error {
+	parts := strings.SplitN(ports, "-", 2)
+
+	var bounds []int
+	for _, part := range parts {
+		port, err := strconv.Atoi(part)
+		if err != nil || port < 1 || port > 65535 {
+			return fmt.Errorf("%q is not a valid port or port range", ports)
+		}
+		bounds = append(bounds, port)
+	}
+
+	if len(bounds) == 2 && bounds[0] > bounds[1] {
+		return fmt.Errorf("%q is not a valid port range, it ends before it starts", ports)
+	}
+
+	return nil
+}
+
+// resourceLxdNetworkACLRules converts ingress or egress blocks to the
+// rules of an ACL, in the order they are declared.
+func resourceLxdNetworkACLRules(v interface{}) []api.NetworkACLRule {
+	rules := []api.NetworkACLRule{}
+	for _, r := range v.([]interface{}) {
+		rule := r.(map[string]interface{})
+		rules = append(rules, api.NetworkACLRule{
+			Action:          rule["action"].(string),
+			Source:          rule["source"].(string),
+			Destination:     rule["destination"].(string),
+			Protocol:        rule["protocol"].(string),
+			SourcePort:      rule["source_port"].(string),
+			DestinationPort: rule["destination_port"].(string),
+			ICMPType:        rule["icmp_type"].(string),
+			ICMPCode:        rule["icmp_code"].(string),
+			Description:     rule["description"].(string),
+			State:           rule["state"].(string),
+		})
+	}
+
+	return rules
+}
+
+func resourceLxdFlattenNetworkACLRules(rules []api.NetworkACLRule) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(rules))
+	for _, rule := range rules {
+		result = append(result, map[string]interface{}{
+			"action":           rule.Action,
+			"source":           rule.Source,
+			"destination":      rule.Destination,
+			"protocol":         rule.Protocol,
+			"source_port":      rule.SourcePort,
+			"destination_port": rule.DestinationPort,
+			"icmp_type":        rule.ICMPType,
+			"icmp_code":        rule.ICMPCode,
+			"description":      rule.Description,
+			"state":            rule.State,
+		})
+	}
+
+	return result
+}
+
+func resourceLxdValidateNetworkACLAction(v interface{}, k string) (ws []string, errors []error) {
+	switch v.(string) {
+	case "allow", "reject", "drop":
+	default:
+		errors = append(errors, fmt.Errorf(
+			"Only allow, reject and drop are supported values for '%s'", k))
+	}
+
+	return
+}
+
+func resourceLxdValidateNetworkACLProtocol(v interface{}, k string) (ws []string, errors []error) {
+	switch v.(string) {
+	case "", "tcp", "udp", "icmp4", "icmp6":
+	default:
+		errors = append(errors, fmt.Errorf(
+			"Only tcp, udp, icmp4 and icmp6 are supported values for '%s'", k))
+	}
+
+	return
+}
+
+func resourceLxdValidateNetworkACLState(v interface{}, k string) (ws []string, errors []error) {
+	switch v.(string) {
+	case "enabled", "disabled", "logged":
+	default:
+		errors = append(errors, fmt.Errorf(
+			"Only enabled, disabled and logged are supported values for '%s'", k))
+	}
+
+	return
+}
@@ -0,0 +1,311 @@
+package lxd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	petname "github.com/dustinkirkland/golang-petname"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+func TestAccNetworkACL_basic(t *testing.T) {
+	var acl api.NetworkACL
+	aclName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccNetworkACL_basic(aclName, "22"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccNetworkACLExists(t, "lxd_network_acl.acl1", &acl),
+					resource.TestCheckResourceAttr("lxd_network_acl.acl1", "name", aclName),
+					resource.TestCheckResourceAttr("lxd_network_acl.acl1", "ingress.#", "2"),
+					resource.TestCheckResourceAttr("lxd_network_acl.acl1", "ingress.0.destination_port", "22"),
+					resource.TestCheckResourceAttr("lxd_network_acl.acl1", "ingress.1.action", "drop"),
+					resource.TestCheckResourceAttr("lxd_network_acl.acl1", "egress.0.state", "enabled"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccNetworkACL_basic(aclName, "2222"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccNetworkACLExists(t, "lxd_network_acl.acl1", &acl),
+					testAccNetworkACLRule(&acl, 0, "2222"),
+					resource.TestCheckResourceAttr("lxd_network_acl.acl1", "ingress.0.destination_port", "2222"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccNetworkACL_usedBy(t *testing.T) {
+	var acl api.NetworkACL
+	aclName := strings.ToLower(petname.Generate(2, "-"))
+	networkName := strings.ToLower(petname.Generate(1, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccNetworkACL_network(aclName, networkName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccNetworkACLExists(t, "lxd_network_acl.acl1", &acl),
+				),
+			},
+			resource.TestStep{
+				Config: testAccNetworkACL_network(aclName, networkName),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("lxd_network_acl.acl1", "used_by.#", "1"),
+					resource.TestCheckResourceAttr("lxd_network_acl.acl1", "used_by.0", "/1.0/networks/"+networkName),
+				),
+			},
+		},
+	})
+}
+
+func TestAccNetworkACL_invalidAction(t *testing.T) {
+	aclName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccNetworkACL_action(aclName, "accept"),
+				ExpectError: regexp.MustCompile(`Only allow, reject and drop are supported values`),
+			},
+		},
+	})
+}
+
+func TestAccNetworkACL_ruleValidation(t *testing.T) {
+	aclName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccNetworkACL_rule(aclName, `destination_port = "22"`),
+				ExpectError: regexp.MustCompile(`ingress.0: destination_port needs protocol tcp or udp`),
+			},
+			resource.TestStep{
+				Config:      testAccNetworkACL_rule(aclName, `protocol = "tcp"`+"\n"+`destination_port = "8080-8000"`),
+				ExpectError: regexp.MustCompile(`ingress.0: destination_port "8080-8000" is not a valid port range`),
+			},
+			resource.TestStep{
+				Config:      testAccNetworkACL_rule(aclName, `source = "10.0.0.0/33"`),
+				ExpectError: regexp.MustCompile(`ingress.0: source "10.0.0.0/33" is not a valid CIDR subnet`),
+			},
+			resource.TestStep{
+				Config:      testAccNetworkACL_rule(aclName, `protocol = "icmp4"`+"\n"+`source = "fd00::/8"`),
+				ExpectError: regexp.MustCompile(`ingress.0: icmp4 rules can't match IPv6 subjects`),
+			},
+			resource.TestStep{
+				Config:      testAccNetworkACL_rule(aclName, `source = "tf-no-such-acl"`),
+				ExpectError: regexp.MustCompile(`ingress.0: network ACL \(tf-no-such-acl\) not found`),
+			},
+		},
+	})
+}
+
+func TestAccNetworkACL_reference(t *testing.T) {
+	aclName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccNetworkACL_reference(aclName),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("lxd_network_acl.acl2", "ingress.0.source", aclName),
+				),
+			},
+		},
+	})
+}
+
+func TestAccNetworkACL_referenceByName(t *testing.T) {
+	aclName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				// The ACL referred to is created by the same apply.
+				Config: testAccNetworkACL_referenceByName(aclName),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("lxd_network_acl.acl2", "ingress.0.source", aclName),
+				),
+			},
+		},
+	})
+}
+
+func testAccNetworkACLExists(t *testing.T, n string, acl *api.NetworkACL) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No ID is set")
+		}
+
+		client, err := testAccProvider.Meta().(*lxdProvider).GetContainerServer("")
+		if err != nil {
+			return err
+		}
+		a, _, err := client.GetNetworkACL(rs.Primary.ID)
+		if err != nil {
+			return err
+		}
+
+		*acl = *a
+
+		return nil
+	}
+}
+
+func testAccNetworkACLRule(acl *api.NetworkACL, i int, port string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		if len(acl.Ingress) <= i {
+			return fmt.Errorf("No ingress rule %d", i)
+		}
+
+		if acl.Ingress[i].DestinationPort != port {
+			return fmt.Errorf("Bad destination port for ingress rule %d: %s", i, acl.Ingress[i].DestinationPort)
+		}
+
+		return nil
+	}
+}
+
+func testAccNetworkACL_basic(name, port string) string {
+	return fmt.Sprintf(`
+resource "lxd_network_acl" "acl1" {
+  name        = "%s"
+  description = "Web servers"
+
+  ingress {
+    action           = "allow"
+    source           = "10.0.0.0/8"
+    protocol         = "tcp"
+    destination_port = "%s"
+    description      = "SSH from the LAN"
+  }
+
+  ingress {
+    action = "drop"
+    state  = "logged"
+  }
+
+  egress {
+    action = "allow"
+  }
+}
+`, name, port)
+}
+
+func testAccNetworkACL_network(aclName, networkName string) string {
+	return fmt.Sprintf(`
+resource "lxd_network_acl" "acl1" {
+  name = "%s"
+
+  ingress {
+    action   = "allow"
+    protocol = "icmp4"
+  }
+}
+
+resource "lxd_network" "net1" {
+  name = "%s"
+
+  config {
+    ipv4.address = "10.150.21.1/24"
+    ipv6.address = "none"
+    security.acls = "${lxd_network_acl.acl1.name}"
+  }
+}
+`, aclName, networkName)
+}
+
+func testAccNetworkACL_rule(name, rule string) string {
+	return fmt.Sprintf(`
+resource "lxd_network_acl" "acl1" {
+  name = "%s"
+
+  ingress {
+    action = "allow"
+    %s
+  }
+}
+`, name, rule)
+}
+
+func testAccNetworkACL_reference(name string) string {
+	return fmt.Sprintf(`
+resource "lxd_network_acl" "acl1" {
+  name = "%s"
+
+  egress {
+    action = "allow"
+  }
+}
+
+resource "lxd_network_acl" "acl2" {
+  name = "%s-web"
+
+  ingress {
+    action           = "allow"
+    source           = "${lxd_network_acl.acl1.name}"
+    protocol         = "tcp"
+    destination_port = "80,443,8000-8080"
+  }
+}
+`, name, name)
+}
+
+func testAccNetworkACL_referenceByName(name string) string {
+	return fmt.Sprintf(`
+resource "lxd_network_acl" "acl1" {
+  name = "%s"
+
+  egress {
+    action = "allow"
+  }
+}
+
+resource "lxd_network_acl" "acl2" {
+  name       = "%s-web"
+  depends_on = ["lxd_network_acl.acl1"]
+
+  ingress {
+    action = "allow"
+    source = "%s"
+  }
+}
+`, name, name, name)
+}
+
+func testAccNetworkACL_action(name, action string) string {
+	return fmt.Sprintf(`
+resource "lxd_network_acl" "acl1" {
+  name = "%s"
+
+  ingress {
+    action = "%s"
+  }
+}
+`, name, action)
+}
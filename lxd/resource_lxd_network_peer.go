@@ -0,0 +1,394 @@
+package lxd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	lxd "github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/shared/api"
+)
+
+func resourceLxdNetworkPeer() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceLxdNetworkPeerCreate,
+		Update: resourceLxdNetworkPeerUpdate,
+		Delete: resourceLxdNetworkPeerDelete,
+		Exists: resourceLxdNetworkPeerExists,
+		Read:   resourceLxdNetworkPeerRead,
+
+		CustomizeDiff: resourceLxdNetworkPeerCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"network": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"description": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"config": &schema.Schema{
+				Type:     schema.TypeMap,
+				Optional: true,
+			},
+
+			"target_network": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"target_project": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"target_name": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+
+			"status": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"target_created": &schema.Schema{
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+
+			"remote": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Default:  "",
+			},
+
+			"project": &schema.Schema{
+				Type:     schema.TypeString,
+				ForceNew: true,
+				Optional: true,
+			},
+		},
+	}
+}
+
+func resourceLxdNetworkPeerCreate(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	name := d.Get("name").(string)
+	network := d.Get("network").(string)
+	targetNetwork := d.Get("target_network").(string)
+
+	targetName := name
+	if v, ok := d.GetOk("target_name"); ok {
+		targetName = v.(string)
+	}
+
+	project, targetProject, err := resourceLxdNetworkPeerProjects(d, server)
+	if err != nil {
+		return err
+	}
+	targetServer := server.UseProject(targetProject)
+
+	// A peering is only established once both networks have a peer
+	// pointing at the other one: the first side stays pending until the
+	// second side accepts it by pointing back.
+	req := api.NetworkPeersPost{
+		Name:          name,
+		TargetProject: targetProject,
+		TargetNetwork: targetNetwork,
+	}
+	req.Description = d.Get("description").(string)
+	req.Config = resourceLxdConfigMap(d.Get("config"))
+
+	log.Printf("[DEBUG] Creating network peer %s of network %s: %#v", name, network, req)
+	mutex.Lock()
+	err = server.CreateNetworkPeer(network, req)
+	mutex.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("Unable to create network peer (%s) of network %s: %s", name, network, err)
+	}
+
+	// The other side may already point back, when it is managed by
+	// another resource or was accepted by hand. It is then left to them.
+	created, err := resourceLxdNetworkPeerAccept(targetServer, targetNetwork, targetName, project, network)
+	if err != nil {
+		mutex.Lock()
+		server.DeleteNetworkPeer(network, name)
+		mutex.Unlock()
+
+		return err
+	}
+
+	d.SetId(fmt.Sprintf("%s/%s", network, name))
+	d.Set("target_name", targetName)
+	d.Set("target_created", created)
+
+	return resourceLxdNetworkPeerRead(d, meta)
+}
+
+func resourceLxdNetworkPeerRead(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	name := d.Get("name").(string)
+	network := d.Get("network").(string)
+
+	peer, _, err := server.GetNetworkPeer(network, name)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[DEBUG] Retrieved network peer %s of network %s: %#v", name, network, peer)
+
+	d.Set("description", peer.Description)
+	d.Set("config", peer.Config)
+	d.Set("target_network", peer.TargetNetwork)
+	d.Set("status", peer.Status)
+
+	// The target project is only tracked when set, it defaults to the
+	// project of the peer. A project sharing the networks of the default
+	// project is the same as the default project.
+	if v, ok := d.GetOk("target_project"); ok {
+		targetProject, err := resourceLxdNetworkProject(server, v.(string))
+		if err != nil {
+			return err
+		}
+
+		if peer.TargetProject != targetProject {
+			d.Set("target_project", peer.TargetProject)
+		}
+	}
+
+	return nil
+}
+
+func resourceLxdNetworkPeerUpdate(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	name := d.Get("name").(string)
+	network := d.Get("network").(string)
+
+	peer, etag, err := server.GetNetworkPeer(network, name)
+	if err != nil {
+		return err
+	}
+
+	newPeer := peer.Writable()
+	newPeer.Description = d.Get("description").(string)
+	newPeer.Config = resourceLxdConfigMap(d.Get("config"))
+
+	log.Printf("[DEBUG] Updating network peer %s of network %s: %#v", name, network, newPeer)
+	mutex.Lock()
+	err = server.UpdateNetworkPeer(network, name, newPeer, etag)
+	mutex.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("Unable to update network peer (%s) of network %s: %s", name, network, err)
+	}
+
+	return resourceLxdNetworkPeerRead(d, meta)
+}
+
+func resourceLxdNetworkPeerDelete(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	name := d.Get("name").(string)
+	network := d.Get("network").(string)
+
+	_, targetProject, err := resourceLxdNetworkPeerProjects(d, server)
+	if err != nil {
+		return err
+	}
+	targetServer := server.UseProject(targetProject)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if err := server.DeleteNetworkPeer(network, name); err != nil {
+		return err
+	}
+
+	if !d.Get("target_created").(bool) {
+		return nil
+	}
+
+	// Both sides were created together, and so are removed together.
+	targetNetwork := d.Get("target_network").(string)
+	targetName := d.Get("target_name").(string)
+	if err := targetServer.DeleteNetworkPeer(targetNetwork, targetName); err != nil && err.Error() != "not found" {
+		return fmt.Errorf("Unable to delete network peer (%s) of network %s: %s", targetName, targetNetwork, err)
+	}
+
+	return nil
+}
+
+func resourceLxdNetworkPeerExists(d *schema.ResourceData, meta interface{}) (exists bool, err error) {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return false, err
+	}
+
+	name := d.Get("name").(string)
+	network := d.Get("network").(string)
+
+	exists = false
+
+	if _, _, err := server.GetNetworkPeer(network, name); err == nil {
+		exists = true
+	}
+
+	return
+}
+
+func resourceLxdNetworkPeerCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() != "" {
+		return nil
+	}
+
+	for _, k := range []string{"network", "target_network", "target_project"} {
+		if !d.NewValueKnown(k) {
+			return nil
+		}
+	}
+
+	server, err := resourceLxdInstanceDiffServer(d, meta.(*lxdProvider))
+	if err != nil {
+		return err
+	}
+
+	project, err := resourceLxdServerProject(server)
+	if err != nil {
+		return err
+	}
+
+	if v, ok := d.GetOk("target_project"); ok {
+		project = v.(string)
+	}
+
+	// The target network may be created along with the peer, it's only
+	// checked when it already exists.
+	targetProject, err := resourceLxdNetworkProject(server, project)
+	if err != nil {
+		return err
+	}
+
+	targetNetwork := d.Get("target_network").(string)
+	network, _, err := server.UseProject(targetProject).GetNetwork(targetNetwork)
+	if err != nil {
+		if err.Error() == "not found" {
+			return nil
+		}
+		return err
+	}
+
+	if network.Type != "ovn" {
+		return fmt.Errorf("Target network (%s) of project %s must be an OVN network, not a %s network", targetNetwork, targetProject, network.Type)
+	}
+
+	return nil
+}
+
+// resourceLxdNetworkPeerProjects returns the project of the network of
+// a peer and the one of its target network. Both are the projects holding
+// the networks, the default one for the projects sharing its networks.
+func resourceLxdNetworkPeerProjects(d *schema.ResourceData, server lxd.ContainerServer) (string, string, error) {
+	project, err := resourceLxdServerProject(server)
+	if err != nil {
+		return "", "", err
+	}
+
+	project, err = resourceLxdNetworkProject(server, project)
+	if err != nil {
+		return "", "", err
+	}
+
+	targetProject := project
+	if v, ok := d.GetOk("target_project"); ok {
+		targetProject, err = resourceLxdNetworkProject(server, v.(string))
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	return project, targetProject, nil
+}
+
+// resourceLxdNetworkPeerAccept creates the peer of the target network
+// pointing back at the network of the peer, unless it already exists.
+// It tells whether it created it.
+func resourceLxdNetworkPeerAccept(server lxd.ContainerServer, network, name, targetProject, targetNetwork string) (bool, error) {
+	peer, _, err := server.GetNetworkPeer(network, name)
+	if err == nil {
+		if peer.TargetProject != targetProject || peer.TargetNetwork != targetNetwork {
+			return false, fmt.Errorf("Network peer (%s) of network %s already exists and points at network %s of project %s",
+				name, network, peer.TargetNetwork, peer.TargetProject)
+		}
+
+		return false, nil
+	}
+
+	req := api.NetworkPeersPost{
+		Name:          name,
+		TargetProject: targetProject,
+		TargetNetwork: targetNetwork,
+	}
+
+	log.Printf("[DEBUG] Accepting network peer %s of network %s: %#v", name, network, req)
+	mutex.Lock()
+	err = server.CreateNetworkPeer(network, req)
+	mutex.Unlock()
+
+	if err != nil {
+		return false, fmt.Errorf("Unable to create network peer (%s) of network %s: %s", name, network, err)
+	}
+
+	return true, nil
+}
+
+// resourceLxdServerProject returns the project a client uses, the one of
+// the resource, of its remote, or the default project.
+func resourceLxdServerProject(server lxd.ContainerServer) (string, error) {
+	info, err := server.GetConnectionInfo()
+	if err != nil {
+		return "", err
+	}
+
+	if info.Project == "" {
+		return "default", nil
+	}
+
+	return info.Project, nil
+}
@@ -0,0 +1,185 @@
+package lxd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	petname "github.com/dustinkirkland/golang-petname"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+func TestAccNetworkPeer_basic(t *testing.T) {
+	var peer api.NetworkPeer
+	peerName := strings.ToLower(petname.Generate(2, "-"))
+	network1 := strings.ToLower(petname.Generate(1, "-"))
+	network2 := strings.ToLower(petname.Generate(1, "-"))
+
+	uplink := os.Getenv("LXD_OVN_UPLINK")
+	if uplink == "" {
+		t.Skip("LXD_OVN_UPLINK must name the uplink network of OVN networks")
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccNetworkPeer_basic(peerName, network1, network2, uplink, "Peering"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccNetworkPeerExists(t, "lxd_network_peer.peer1", &peer),
+					resource.TestCheckResourceAttr("lxd_network_peer.peer1", "status", "Created"),
+					resource.TestCheckResourceAttr("lxd_network_peer.peer1", "target_name", peerName),
+					resource.TestCheckResourceAttr("lxd_network_peer.peer1", "target_network", network2),
+					resource.TestCheckResourceAttr("lxd_network_peer.peer1", "target_created", "true"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccNetworkPeer_basic(peerName, network1, network2, uplink, "Updated"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccNetworkPeerExists(t, "lxd_network_peer.peer1", &peer),
+					resource.TestCheckResourceAttr("lxd_network_peer.peer1", "description", "Updated"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccNetworkPeer_crossProject(t *testing.T) {
+	var peer api.NetworkPeer
+	peerName := strings.ToLower(petname.Generate(2, "-"))
+	network1 := strings.ToLower(petname.Generate(1, "-"))
+	network2 := strings.ToLower(petname.Generate(1, "-"))
+
+	uplink := os.Getenv("LXD_OVN_UPLINK")
+	if uplink == "" {
+		t.Skip("LXD_OVN_UPLINK must name the uplink network of OVN networks")
+	}
+
+	// The project must have its own networks.
+	project := os.Getenv("LXD_TEST_PROJECT")
+	if project == "" {
+		t.Skip("LXD_TEST_PROJECT must name a project with features.networks")
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccNetworkPeer_crossProject(peerName, network1, network2, uplink, project),
+				Check: resource.ComposeTestCheckFunc(
+					testAccNetworkPeerExists(t, "lxd_network_peer.peer1", &peer),
+					resource.TestCheckResourceAttr("lxd_network_peer.peer1", "status", "Created"),
+					resource.TestCheckResourceAttr("lxd_network_peer.peer1", "target_project", project),
+				),
+			},
+		},
+	})
+}
+
+func testAccNetworkPeerExists(t *testing.T, n string, peer *api.NetworkPeer) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No ID is set")
+		}
+
+		client, err := testAccProvider.Meta().(*lxdProvider).GetContainerServer("")
+		if err != nil {
+			return err
+		}
+		p, _, err := client.GetNetworkPeer(rs.Primary.Attributes["network"], rs.Primary.Attributes["name"])
+		if err != nil {
+			return err
+		}
+
+		// The other side must point back for the peering to be created.
+		if project := rs.Primary.Attributes["target_project"]; project != "" {
+			client = client.UseProject(project)
+		}
+		_, _, err = client.GetNetworkPeer(rs.Primary.Attributes["target_network"], rs.Primary.Attributes["target_name"])
+		if err != nil {
+			return fmt.Errorf("Target network peer: %s", err)
+		}
+
+		*peer = *p
+
+		return nil
+	}
+}
+
+func testAccNetworkPeer_basic(name, network1, network2, uplink, description string) string {
+	return fmt.Sprintf(`
+resource "lxd_network" "ovn1" {
+  name = "%s"
+  type = "ovn"
+
+  config {
+    network      = "%s"
+    ipv4.address = "10.150.22.1/24"
+    ipv6.address = "none"
+  }
+}
+
+resource "lxd_network" "ovn2" {
+  name = "%s"
+  type = "ovn"
+
+  config {
+    network      = "%s"
+    ipv4.address = "10.150.23.1/24"
+    ipv6.address = "none"
+  }
+}
+
+resource "lxd_network_peer" "peer1" {
+  name           = "%s"
+  description    = "%s"
+  network        = "${lxd_network.ovn1.name}"
+  target_network = "${lxd_network.ovn2.name}"
+}
+`, network1, uplink, network2, uplink, name, description)
+}
+
+func testAccNetworkPeer_crossProject(name, network1, network2, uplink, project string) string {
+	return fmt.Sprintf(`
+resource "lxd_network" "ovn1" {
+  name = "%s"
+  type = "ovn"
+
+  config {
+    network      = "%s"
+    ipv4.address = "10.150.22.1/24"
+    ipv6.address = "none"
+  }
+}
+
+resource "lxd_network" "ovn2" {
+  name    = "%s"
+  type    = "ovn"
+  project = "%s"
+
+  config {
+    network      = "%s"
+    ipv4.address = "10.150.23.1/24"
+    ipv6.address = "none"
+  }
+}
+
+resource "lxd_network_peer" "peer1" {
+  name           = "%s"
+  network        = "${lxd_network.ovn1.name}"
+  target_network = "${lxd_network.ovn2.name}"
+  target_project = "${lxd_network.ovn2.project}"
+}
+`, network1, uplink, network2, project, uplink, name)
+}
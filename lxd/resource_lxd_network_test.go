@@ -2,6 +2,8 @@ package lxd
 
 import (
 	"fmt"
+	"os"
+	"regexp"
 	"strings"
 	"testing"
 
@@ -81,6 +83,375 @@ func TestAccNetwork_attach(t *testing.T) {
 	})
 }
 
+func TestAccNetwork_updateConfig(t *testing.T) {
+	var network api.Network
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccNetwork_bridge("10.150.19.1/24", "first"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccNetworkExists(t, "lxd_network.eth1", &network),
+					testAccNetworkConfig(&network, "ipv4.address", "10.150.19.1/24"),
+					testAccNetworkConfig(&network, "dns.domain", "first"),
+					resource.TestCheckResourceAttr("lxd_network.eth1", "type", "bridge"),
+					resource.TestCheckResourceAttrSet("lxd_network.eth1", "expanded_config.ipv6.address"),
+					resource.TestCheckNoResourceAttr("lxd_network.eth1", "config.ipv6.address"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccNetwork_bridge("10.150.20.1/24", "second"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccNetworkExists(t, "lxd_network.eth1", &network),
+					testAccNetworkConfig(&network, "ipv4.address", "10.150.20.1/24"),
+					testAccNetworkConfig(&network, "ipv4.dhcp.ranges", "10.150.20.100-10.150.20.200"),
+					testAccNetworkConfig(&network, "dns.domain", "second"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccNetwork_ovn(t *testing.T) {
+	var network api.Network
+	networkName := strings.ToLower(petname.Generate(1, "-"))
+
+	// The uplink must be a bridge or physical network set up for OVN.
+	uplink := os.Getenv("LXD_OVN_UPLINK")
+	if uplink == "" {
+		t.Skip("LXD_OVN_UPLINK must name the uplink network of OVN networks")
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccNetwork_ovn(networkName, uplink),
+				Check: resource.ComposeTestCheckFunc(
+					testAccNetworkExists(t, "lxd_network.ovn1", &network),
+					testAccNetworkConfig(&network, "network", uplink),
+					testAccNetworkConfig(&network, "ipv4.nat", "true"),
+					resource.TestCheckResourceAttr("lxd_network.ovn1", "type", "ovn"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccNetwork_ovnUplinkNotFound(t *testing.T) {
+	networkName := strings.ToLower(petname.Generate(1, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccNetwork_ovn(networkName, "tf-no-such-uplink"),
+				ExpectError: regexp.MustCompile(`Uplink network \(tf-no-such-uplink\) not found`),
+			},
+		},
+	})
+}
+
+func TestAccNetwork_macvlan(t *testing.T) {
+	var network api.Network
+	networkName := strings.ToLower(petname.Generate(1, "-"))
+
+	parent := os.Getenv("LXD_NETWORK_PARENT")
+	if parent == "" {
+		t.Skip("LXD_NETWORK_PARENT must name a host interface for macvlan networks")
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccNetwork_parent(networkName, "macvlan", parent, "10"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccNetworkExists(t, "lxd_network.net1", &network),
+					testAccNetworkConfig(&network, "parent", parent),
+					testAccNetworkConfig(&network, "vlan", "10"),
+					resource.TestCheckResourceAttr("lxd_network.net1", "type", "macvlan"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccNetwork_parentValidation(t *testing.T) {
+	networkName := strings.ToLower(petname.Generate(1, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccNetwork_parent(networkName, "physical", "", "10"),
+				ExpectError: regexp.MustCompile(`config.parent must be set`),
+			},
+			resource.TestStep{
+				Config:      testAccNetwork_parent(networkName, "sriov", "eth0", "5000"),
+				ExpectError: regexp.MustCompile(`config.vlan must be a VLAN ID between 0 and 4094`),
+			},
+		},
+	})
+}
+
+func TestAccNetwork_cluster(t *testing.T) {
+	var network api.Network
+	networkName := strings.ToLower(petname.Generate(1, "-"))
+
+	members := strings.Split(os.Getenv("LXD_CLUSTER_MEMBERS"), ",")
+	if len(members) < 2 {
+		t.Skip("LXD_CLUSTER_MEMBERS must list at least two cluster members")
+	}
+
+	parent := os.Getenv("LXD_NETWORK_PARENT")
+	if parent == "" {
+		t.Skip("LXD_NETWORK_PARENT must name a host interface of the cluster members")
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccNetwork_cluster(networkName, parent, members),
+				Check: resource.ComposeTestCheckFunc(
+					testAccNetworkExists(t, "lxd_network.net1", &network),
+					testAccNetworkConfig(&network, "vlan", "10"),
+					resource.TestCheckResourceAttr("lxd_network.net1", "member_config.#", fmt.Sprintf("%d", len(members))),
+					resource.TestCheckResourceAttr("lxd_network.net1", "member_config.0.target", members[0]),
+					resource.TestCheckResourceAttr("lxd_network.net1", "member_config.0.config.parent", parent),
+				),
+			},
+		},
+	})
+}
+
+func TestAccNetwork_clusterParentValidation(t *testing.T) {
+	networkName := strings.ToLower(petname.Generate(1, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccNetwork_cluster(networkName, "", []string{"node1", "node2"}),
+				ExpectError: regexp.MustCompile(`Cluster member node1: config.parent must be set`),
+			},
+		},
+	})
+}
+
+func TestAccNetwork_fan(t *testing.T) {
+	var network api.Network
+	networkName := strings.ToLower(petname.Generate(1, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccNetwork_fan(networkName, "auto", "240.0.0.0/8"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccNetworkExists(t, "lxd_network.fan1", &network),
+					testAccNetworkConfig(&network, "bridge.mode", "fan"),
+					testAccNetworkConfig(&network, "fan.underlay_subnet", "auto"),
+					resource.TestCheckResourceAttr("lxd_network.fan1", "fan.0.overlay_subnet", "240.0.0.0/8"),
+					resource.TestCheckResourceAttr("lxd_network.fan1", "fan.0.type", "vxlan"),
+					resource.TestCheckNoResourceAttr("lxd_network.fan1", "config.bridge.mode"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccNetwork_fanValidation(t *testing.T) {
+	networkName := strings.ToLower(petname.Generate(1, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccNetwork_fan(networkName, "10.0.0.0/12", "240.0.0.0/8"),
+				ExpectError: regexp.MustCompile(`fan.underlay_subnet must be a /16 or /24 subnet`),
+			},
+			resource.TestStep{
+				Config:      testAccNetwork_fan(networkName, "10.0.0.0/16", "240.0.0.0/16"),
+				ExpectError: regexp.MustCompile(`fan.overlay_subnet /16 is too small`),
+			},
+		},
+	})
+}
+
+func TestAccNetwork_dhcp(t *testing.T) {
+	var network api.Network
+	networkName := strings.ToLower(petname.Generate(1, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccNetwork_dhcp(networkName, "10.150.27.10"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccNetworkExists(t, "lxd_network.net1", &network),
+					testAccNetworkConfig(&network, "raw.dnsmasq", "log-queries\n"+
+						"dhcp-host=00:16:3e:00:27:10,10.150.27.10,web1\n"+
+						"dhcp-host=00:16:3e:00:27:11,db1\n"+
+						"dhcp-option=option:ntp-server,10.150.27.1"),
+					resource.TestCheckResourceAttr("lxd_network.net1", "config.raw.dnsmasq", "log-queries"),
+					resource.TestCheckResourceAttr("lxd_network.net1", "dhcp_host.#", "2"),
+					resource.TestCheckResourceAttr("lxd_network.net1", "dhcp_host.1.hostname", "db1"),
+					resource.TestCheckResourceAttr("lxd_network.net1", "dhcp_option.0.value", "10.150.27.1"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccNetwork_dhcp(networkName, "10.150.27.20"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccNetworkExists(t, "lxd_network.net1", &network),
+					resource.TestCheckResourceAttr("lxd_network.net1", "dhcp_host.0.address", "10.150.27.20"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccNetwork_dhcpMacvlan(t *testing.T) {
+	networkName := strings.ToLower(petname.Generate(1, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccNetwork_dhcpMacvlan(networkName),
+				ExpectError: regexp.MustCompile(`dhcp_host is only supported by bridge networks`),
+			},
+		},
+	})
+}
+
+func TestAccNetwork_ipv6(t *testing.T) {
+	var network api.Network
+	networkName := strings.ToLower(petname.Generate(1, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccNetwork_ipv6(networkName, "fd42:474b:622d:259d::1/64", "slaac", ""),
+				Check: resource.ComposeTestCheckFunc(
+					testAccNetworkExists(t, "lxd_network.net1", &network),
+					testAccNetworkConfig(&network, "ipv6.address", "fd42:474b:622d:259d::1/64"),
+					testAccNetworkConfig(&network, "ipv6.dhcp.stateful", "false"),
+					resource.TestCheckResourceAttr("lxd_network.net1", "ipv6.0.mode", "slaac"),
+					resource.TestCheckResourceAttr("lxd_network.net1", "ipv6.0.dhcp", "true"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccNetwork_ipv6(networkName, "fd42:474b:622d:259d::1/80", "stateful",
+					"fd42:474b:622d:259d::100-fd42:474b:622d:259d::200"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccNetworkExists(t, "lxd_network.net1", &network),
+					testAccNetworkConfig(&network, "ipv6.dhcp.stateful", "true"),
+					testAccNetworkConfig(&network, "ipv6.dhcp.ranges", "fd42:474b:622d:259d::100-fd42:474b:622d:259d::200"),
+					resource.TestCheckResourceAttr("lxd_network.net1", "ipv6.0.mode", "stateful"),
+					resource.TestCheckResourceAttr("lxd_network.net1", "ipv6.0.dhcp_ranges.#", "1"),
+					resource.TestCheckNoResourceAttr("lxd_network.net1", "config.ipv6.address"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccNetwork_ipv6Validation(t *testing.T) {
+	networkName := strings.ToLower(petname.Generate(1, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccNetwork_ipv6(networkName, "none", "stateful", ""),
+				ExpectError: regexp.MustCompile(`ipv6.dhcp.stateful needs an IPv6 subnet`),
+			},
+			resource.TestStep{
+				Config:      testAccNetwork_ipv6(networkName, "fd42:474b:622d:259d::1/56", "slaac", ""),
+				ExpectError: regexp.MustCompile(`SLAAC needs a /64 ipv6.address`),
+			},
+			resource.TestStep{
+				Config:      testAccNetwork_ipv6(networkName, "fd42:474b:622d:259d::1/64", "slaac", "fd42:474b:622d:259d::100-fd42:474b:622d:259d::200"),
+				ExpectError: regexp.MustCompile(`ipv6.dhcp.ranges are only used by stateful DHCPv6`),
+			},
+			resource.TestStep{
+				Config:      testAccNetwork_ipv6(networkName, "fd42:474b:622d:259d::1/64", "stateful", "fd42:474b:622d:25ff::100-fd42:474b:622d:25ff::200"),
+				ExpectError: regexp.MustCompile(`ipv6.dhcp.ranges must be within ipv6.address`),
+			},
+		},
+	})
+}
+
+func TestAccNetwork_externalInterfaces(t *testing.T) {
+	var network api.Network
+	networkName := strings.ToLower(petname.Generate(1, "-"))
+
+	// The interface is taken over by the bridge, it must be a spare one.
+	external := os.Getenv("LXD_EXTERNAL_INTERFACE")
+	if external == "" {
+		t.Skip("LXD_EXTERNAL_INTERFACE must name a spare host interface")
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccNetwork_externalInterfaces(networkName, `"`+external+`"`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccNetworkExists(t, "lxd_network.net1", &network),
+					testAccNetworkConfig(&network, "bridge.external_interfaces", external),
+					resource.TestCheckResourceAttr("lxd_network.net1", "external_interfaces.#", "1"),
+					resource.TestCheckResourceAttr("lxd_network.net1", "external_interfaces.0", external),
+				),
+			},
+			resource.TestStep{
+				Config: testAccNetwork_externalInterfaces(networkName, ""),
+				Check: resource.ComposeTestCheckFunc(
+					testAccNetworkExists(t, "lxd_network.net1", &network),
+					resource.TestCheckResourceAttr("lxd_network.net1", "external_interfaces.#", "0"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccNetwork_externalInterfacesValidation(t *testing.T) {
+	networkName := strings.ToLower(petname.Generate(1, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccNetwork_externalInterfacesConflict(networkName),
+				ExpectError: regexp.MustCompile(`bridge.external_interfaces can't be set through both config and external_interfaces`),
+			},
+			resource.TestStep{
+				Config:      testAccNetwork_externalInterfaces(networkName, `"eth1,eth2"`),
+				ExpectError: regexp.MustCompile(`must be the name of a network interface`),
+			},
+		},
+	})
+}
+
 func testAccNetworkExists(t *testing.T, n string, network *api.Network) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rs, ok := s.RootModule().Resources[n]
@@ -160,6 +531,194 @@ resource "lxd_network" "eth1" {
 `)
 }
 
+func testAccNetwork_bridge(address, domain string) string {
+	start := strings.TrimSuffix(address, "1/24")
+	return fmt.Sprintf(`
+resource "lxd_network" "eth1" {
+  name = "eth1"
+  type = "bridge"
+
+  config {
+    ipv4.address     = "%s"
+    ipv4.nat         = "true"
+    ipv4.dhcp.ranges = "%s100-%s200"
+    dns.domain       = "%s"
+  }
+}
+`, address, start, start, domain)
+}
+
+func testAccNetwork_ovn(name, uplink string) string {
+	return fmt.Sprintf(`
+resource "lxd_network" "ovn1" {
+  name = "%s"
+  type = "ovn"
+
+  config {
+    network      = "%s"
+    ipv4.address = "10.150.21.1/24"
+    ipv4.nat     = "true"
+    ipv6.address = "none"
+  }
+}
+`, name, uplink)
+}
+
+func testAccNetwork_fan(name, underlay, overlay string) string {
+	return fmt.Sprintf(`
+resource "lxd_network" "fan1" {
+  name = "%s"
+
+  fan {
+    underlay_subnet = "%s"
+    overlay_subnet  = "%s"
+  }
+}
+`, name, underlay, overlay)
+}
+
+func testAccNetwork_dhcp(name, address string) string {
+	return fmt.Sprintf(`
+resource "lxd_network" "net1" {
+  name = "%s"
+
+  config {
+    ipv4.address = "10.150.27.1/24"
+    ipv6.address = "none"
+    raw.dnsmasq  = "log-queries"
+  }
+
+  dhcp_host {
+    mac      = "00:16:3e:00:27:10"
+    address  = "%s"
+    hostname = "web1"
+  }
+
+  dhcp_host {
+    mac      = "00:16:3e:00:27:11"
+    hostname = "db1"
+  }
+
+  dhcp_option {
+    option = "option:ntp-server"
+    value  = "10.150.27.1"
+  }
+}
+`, name, address)
+}
+
+func testAccNetwork_dhcpMacvlan(name string) string {
+	return fmt.Sprintf(`
+resource "lxd_network" "net1" {
+  name = "%s"
+  type = "macvlan"
+
+  config {
+    parent = "eth0"
+  }
+
+  dhcp_host {
+    mac     = "00:16:3e:00:27:10"
+    address = "10.150.27.10"
+  }
+}
+`, name)
+}
+
+func testAccNetwork_ipv6(name, address, mode, ranges string) string {
+	var dhcpRanges string
+	if ranges != "" {
+		dhcpRanges = fmt.Sprintf("dhcp_ranges = [\"%s\"]", ranges)
+	}
+
+	return fmt.Sprintf(`
+resource "lxd_network" "net1" {
+  name = "%s"
+
+  config {
+    ipv4.address = "10.150.29.1/24"
+  }
+
+  ipv6 {
+    address = "%s"
+    mode    = "%s"
+    %s
+  }
+}
+`, name, address, mode, dhcpRanges)
+}
+
+func testAccNetwork_externalInterfaces(name, interfaces string) string {
+	return fmt.Sprintf(`
+resource "lxd_network" "net1" {
+  name = "%s"
+
+  config {
+    ipv4.address = "10.150.28.1/24"
+    ipv6.address = "none"
+  }
+
+  external_interfaces = [%s]
+}
+`, name, interfaces)
+}
+
+func testAccNetwork_externalInterfacesConflict(name string) string {
+	return fmt.Sprintf(`
+resource "lxd_network" "net1" {
+  name = "%s"
+
+  config {
+    bridge.external_interfaces = "eth1"
+  }
+
+  external_interfaces = ["eth2"]
+}
+`, name)
+}
+
+func testAccNetwork_parent(name, netType, parent, vlan string) string {
+	return fmt.Sprintf(`
+resource "lxd_network" "net1" {
+  name = "%s"
+  type = "%s"
+
+  config {
+    parent = "%s"
+    vlan   = "%s"
+    mtu    = "1500"
+  }
+}
+`, name, netType, parent, vlan)
+}
+
+func testAccNetwork_cluster(name, parent string, members []string) string {
+	var memberConfig string
+	for _, member := range members {
+		memberConfig += fmt.Sprintf(`
+  member_config {
+    target = "%s"
+
+    config {
+      parent = "%s"
+    }
+  }
+`, member, parent)
+	}
+
+	return fmt.Sprintf(`
+resource "lxd_network" "net1" {
+  name = "%s"
+  type = "macvlan"
+
+  config {
+    vlan = "10"
+  }
+%s
+}
+`, name, memberConfig)
+}
+
 func testAccNetwork_attach(profileName, containerName string) string {
 	return fmt.Sprintf(`
 resource "lxd_network" "eth1" {
@@ -0,0 +1,213 @@
+package lxd
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/lxc/lxd/shared/api"
+)
+
+func resourceLxdNetworkZone() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceLxdNetworkZoneCreate,
+		Update: resourceLxdNetworkZoneUpdate,
+		Delete: resourceLxdNetworkZoneDelete,
+		Exists: resourceLxdNetworkZoneExists,
+		Read:   resourceLxdNetworkZoneRead,
+
+		Importer: &schema.ResourceImporter{
+			State: resourceLxdNetworkZoneImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"description": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"config": &schema.Schema{
+				Type:     schema.TypeMap,
+				Optional: true,
+			},
+
+			"used_by": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
+			"remote": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Default:  "",
+			},
+
+			"project": &schema.Schema{
+				Type:     schema.TypeString,
+				ForceNew: true,
+				Optional: true,
+			},
+		},
+	}
+}
+
+func resourceLxdNetworkZoneCreate(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	name := d.Get("name").(string)
+
+	req := api.NetworkZonesPost{}
+	req.Name = name
+	req.Description = d.Get("description").(string)
+	req.Config = resourceLxdConfigMap(d.Get("config"))
+
+	log.Printf("[DEBUG] Creating network zone %s: %#v", name, req)
+	mutex.Lock()
+	err = server.CreateNetworkZone(req)
+	mutex.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("Unable to create network zone (%s): %s", name, err)
+	}
+
+	d.SetId(name)
+
+	return resourceLxdNetworkZoneRead(d, meta)
+}
+
+func resourceLxdNetworkZoneRead(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+	name := d.Id()
+
+	zone, _, err := server.GetNetworkZone(name)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[DEBUG] Retrieved network zone %s: %#v", name, zone)
+
+	d.Set("name", zone.Name)
+	d.Set("description", zone.Description)
+	d.Set("config", zone.Config)
+	d.Set("used_by", zone.UsedBy)
+
+	return nil
+}
+
+func resourceLxdNetworkZoneUpdate(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	name := d.Id()
+
+	zone, etag, err := server.GetNetworkZone(name)
+	if err != nil {
+		return err
+	}
+
+	newZone := zone.Writable()
+	newZone.Description = d.Get("description").(string)
+	newZone.Config = resourceLxdConfigMap(d.Get("config"))
+
+	log.Printf("[DEBUG] Updating network zone %s: %#v", name, newZone)
+	mutex.Lock()
+	err = server.UpdateNetworkZone(name, newZone, etag)
+	mutex.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("Unable to update network zone (%s): %s", name, err)
+	}
+
+	return resourceLxdNetworkZoneRead(d, meta)
+}
+
+func resourceLxdNetworkZoneDelete(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	name := d.Id()
+
+	// Networks may still point their DNS zone keys at this zone.
+	gone, err := resourceLxdCheckUnused("Network zone", name, func() ([]string, error) {
+		zone, _, err := server.GetNetworkZone(name)
+		if err != nil {
+			return nil, err
+		}
+		return zone.UsedBy, nil
+	})
+	if err != nil || gone {
+		return err
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	return server.DeleteNetworkZone(name)
+}
+
+func resourceLxdNetworkZoneExists(d *schema.ResourceData, meta interface{}) (exists bool, err error) {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return false, err
+	}
+
+	name := d.Id()
+
+	exists = false
+
+	if _, _, err := server.GetNetworkZone(name); err == nil {
+		exists = true
+	}
+
+	return
+}
+
+func resourceLxdNetworkZoneImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	p := meta.(*lxdProvider)
+	log.Printf("[DEBUG] Starting import for %s", d.Id())
+
+	remote, name, err := p.LXDConfig.ParseRemote(d.Id())
+	if err != nil {
+		return nil, err
+	}
+
+	if p.LXDConfig.DefaultRemote != remote {
+		d.Set("remote", remote)
+	}
+
+	if parts := strings.SplitN(name, "/", 2); len(parts) == 2 {
+		d.Set("project", parts[0])
+		name = parts[1]
+	}
+
+	d.SetId(name)
+	if err := resourceLxdNetworkZoneRead(d, meta); err != nil {
+		return nil, fmt.Errorf("Unable to import network zone (%s): %s", name, err)
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
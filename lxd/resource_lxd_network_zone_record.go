@@ -0,0 +1,270 @@
+package lxd
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/lxc/lxd/shared/api"
+)
+
+func resourceLxdNetworkZoneRecord() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceLxdNetworkZoneRecordCreate,
+		Update: resourceLxdNetworkZoneRecordUpdate,
+		Delete: resourceLxdNetworkZoneRecordDelete,
+		Exists: resourceLxdNetworkZoneRecordExists,
+		Read:   resourceLxdNetworkZoneRecordRead,
+
+		Importer: &schema.ResourceImporter{
+			State: resourceLxdNetworkZoneRecordImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"zone": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"description": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"config": &schema.Schema{
+				Type:     schema.TypeMap,
+				Optional: true,
+			},
+
+			"entry": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"type": &schema.Schema{
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: resourceLxdValidateNetworkZoneRecordType,
+						},
+
+						"value": &schema.Schema{
+							Type:     schema.TypeString,
+							Required: true,
+						},
+
+						"ttl": &schema.Schema{
+							Type:     schema.TypeInt,
+							Optional: true,
+							Default:  300,
+						},
+					},
+				},
+			},
+
+			"remote": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Default:  "",
+			},
+
+			"project": &schema.Schema{
+				Type:     schema.TypeString,
+				ForceNew: true,
+				Optional: true,
+			},
+		},
+	}
+}
+
+func resourceLxdNetworkZoneRecordCreate(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	name := d.Get("name").(string)
+	zone := d.Get("zone").(string)
+
+	req := api.NetworkZoneRecordsPost{}
+	req.Name = name
+	req.Description = d.Get("description").(string)
+	req.Config = resourceLxdConfigMap(d.Get("config"))
+	req.Entries = resourceLxdNetworkZoneRecordEntries(d.Get("entry"))
+
+	log.Printf("[DEBUG] Creating record %s of network zone %s: %#v", name, zone, req)
+	mutex.Lock()
+	err = server.CreateNetworkZoneRecord(zone, req)
+	mutex.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("Unable to create record (%s) of network zone %s: %s", name, zone, err)
+	}
+
+	d.SetId(fmt.Sprintf("%s/%s", zone, name))
+
+	return resourceLxdNetworkZoneRecordRead(d, meta)
+}
+
+func resourceLxdNetworkZoneRecordRead(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	name := d.Get("name").(string)
+	zone := d.Get("zone").(string)
+
+	record, _, err := server.GetNetworkZoneRecord(zone, name)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[DEBUG] Retrieved record %s of network zone %s: %#v", name, zone, record)
+
+	entries := make([]map[string]interface{}, 0, len(record.Entries))
+	for _, entry := range record.Entries {
+		entries = append(entries, map[string]interface{}{
+			"type":  entry.Type,
+			"value": entry.Value,
+			"ttl":   int(entry.TTL),
+		})
+	}
+
+	d.Set("description", record.Description)
+	d.Set("config", record.Config)
+	if err := d.Set("entry", entries); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func resourceLxdNetworkZoneRecordUpdate(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	name := d.Get("name").(string)
+	zone := d.Get("zone").(string)
+
+	record, etag, err := server.GetNetworkZoneRecord(zone, name)
+	if err != nil {
+		return err
+	}
+
+	newRecord := record.Writable()
+	newRecord.Description = d.Get("description").(string)
+	newRecord.Config = resourceLxdConfigMap(d.Get("config"))
+	newRecord.Entries = resourceLxdNetworkZoneRecordEntries(d.Get("entry"))
+
+	log.Printf("[DEBUG] Updating record %s of network zone %s: %#v", name, zone, newRecord)
+	mutex.Lock()
+	err = server.UpdateNetworkZoneRecord(zone, name, newRecord, etag)
+	mutex.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("Unable to update record (%s) of network zone %s: %s", name, zone, err)
+	}
+
+	return resourceLxdNetworkZoneRecordRead(d, meta)
+}
+
+func resourceLxdNetworkZoneRecordDelete(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	return server.DeleteNetworkZoneRecord(d.Get("zone").(string), d.Get("name").(string))
+}
+
+func resourceLxdNetworkZoneRecordExists(d *schema.ResourceData, meta interface{}) (exists bool, err error) {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return false, err
+	}
+
+	exists = false
+
+	if _, _, err := server.GetNetworkZoneRecord(d.Get("zone").(string), d.Get("name").(string)); err == nil {
+		exists = true
+	}
+
+	return
+}
+
+func resourceLxdNetworkZoneRecordImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	p := meta.(*lxdProvider)
+	log.Printf("[DEBUG] Starting import for %s", d.Id())
+
+	remote, name, err := p.LXDConfig.ParseRemote(d.Id())
+	if err != nil {
+		return nil, err
+	}
+
+	if p.LXDConfig.DefaultRemote != remote {
+		d.Set("remote", remote)
+	}
+
+	parts := strings.Split(name, "/")
+	switch len(parts) {
+	case 3:
+		d.Set("project", parts[0])
+		parts = parts[1:]
+	case 2:
+	default:
+		return nil, fmt.Errorf("Invalid network zone record ID %q, must be [remote:][project/]zone/record", d.Id())
+	}
+
+	d.SetId(fmt.Sprintf("%s/%s", parts[0], parts[1]))
+	d.Set("zone", parts[0])
+	d.Set("name", parts[1])
+	if err := resourceLxdNetworkZoneRecordRead(d, meta); err != nil {
+		return nil, fmt.Errorf("Unable to import record (%s) of network zone %s: %s", parts[1], parts[0], err)
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func resourceLxdNetworkZoneRecordEntries(v interface{}) []api.NetworkZoneRecordEntry {
+	entries := []api.NetworkZoneRecordEntry{}
+	for _, e := range v.([]interface{}) {
+		entry := e.(map[string]interface{})
+		entries = append(entries, api.NetworkZoneRecordEntry{
+			Type:  entry["type"].(string),
+			Value: entry["value"].(string),
+			TTL:   uint64(entry["ttl"].(int)),
+		})
+	}
+
+	return entries
+}
+
+func resourceLxdValidateNetworkZoneRecordType(v interface{}, k string) (ws []string, errors []error) {
+	switch v.(string) {
+	case "A", "AAAA", "CNAME", "TXT", "SRV", "MX":
+	default:
+		errors = append(errors, fmt.Errorf(
+			"Only A, AAAA, CNAME, TXT, SRV and MX are supported values for '%s'", k))
+	}
+
+	return
+}
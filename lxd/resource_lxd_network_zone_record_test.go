@@ -0,0 +1,121 @@
+package lxd
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+func TestAccNetworkZoneRecord_basic(t *testing.T) {
+	var record api.NetworkZoneRecord
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccNetworkZoneRecord_basic("10.150.24.10"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccNetworkZoneRecordExists(t, "lxd_network_zone_record.www", &record),
+					resource.TestCheckResourceAttr("lxd_network_zone_record.www", "entry.#", "2"),
+					resource.TestCheckResourceAttr("lxd_network_zone_record.www", "entry.0.type", "A"),
+					resource.TestCheckResourceAttr("lxd_network_zone_record.www", "entry.0.value", "10.150.24.10"),
+					resource.TestCheckResourceAttr("lxd_network_zone_record.www", "entry.1.ttl", "3600"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccNetworkZoneRecord_basic("10.150.24.20"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccNetworkZoneRecordExists(t, "lxd_network_zone_record.www", &record),
+					resource.TestCheckResourceAttr("lxd_network_zone_record.www", "entry.0.value", "10.150.24.20"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccNetworkZoneRecord_invalidType(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccNetworkZoneRecord_type("PTR"),
+				ExpectError: regexp.MustCompile(`Only A, AAAA, CNAME, TXT, SRV and MX are supported values`),
+			},
+		},
+	})
+}
+
+func testAccNetworkZoneRecordExists(t *testing.T, n string, record *api.NetworkZoneRecord) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No ID is set")
+		}
+
+		client, err := testAccProvider.Meta().(*lxdProvider).GetContainerServer("")
+		if err != nil {
+			return err
+		}
+		r, _, err := client.GetNetworkZoneRecord(rs.Primary.Attributes["zone"], rs.Primary.Attributes["name"])
+		if err != nil {
+			return err
+		}
+
+		*record = *r
+
+		return nil
+	}
+}
+
+func testAccNetworkZoneRecord_basic(address string) string {
+	return fmt.Sprintf(`
+resource "lxd_network_zone" "zone1" {
+  name = "lab.example.net"
+}
+
+resource "lxd_network_zone_record" "www" {
+  name = "www"
+  zone = "${lxd_network_zone.zone1.name}"
+
+  entry {
+    type  = "A"
+    value = "%s"
+  }
+
+  entry {
+    type  = "TXT"
+    value = "\"web server\""
+    ttl   = 3600
+  }
+}
+`, address)
+}
+
+func testAccNetworkZoneRecord_type(recordType string) string {
+	return fmt.Sprintf(`
+resource "lxd_network_zone" "zone1" {
+  name = "lab.example.net"
+}
+
+resource "lxd_network_zone_record" "www" {
+  name = "www"
+  zone = "${lxd_network_zone.zone1.name}"
+
+  entry {
+    type  = "%s"
+    value = "www.lab.example.net."
+  }
+}
+`, recordType)
+}
@@ -0,0 +1,121 @@
+package lxd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	petname "github.com/dustinkirkland/golang-petname"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+func TestAccNetworkZone_basic(t *testing.T) {
+	var zone api.NetworkZone
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccNetworkZone_basic("Lab servers"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccNetworkZoneExists(t, "lxd_network_zone.zone1", &zone),
+					resource.TestCheckResourceAttr("lxd_network_zone.zone1", "name", "lab.example.net"),
+					resource.TestCheckResourceAttr("lxd_network_zone.zone1", "config.dns.nameservers", "ns1.example.net"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccNetworkZone_basic("Lab servers and desktops"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccNetworkZoneExists(t, "lxd_network_zone.zone1", &zone),
+					resource.TestCheckResourceAttr("lxd_network_zone.zone1", "description", "Lab servers and desktops"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccNetworkZone_network(t *testing.T) {
+	var zone api.NetworkZone
+	networkName := strings.ToLower(petname.Generate(1, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccNetworkZone_network(networkName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccNetworkZoneExists(t, "lxd_network_zone.zone1", &zone),
+				),
+			},
+			resource.TestStep{
+				Config: testAccNetworkZone_network(networkName),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("lxd_network_zone.zone1", "used_by.#", "1"),
+					resource.TestCheckResourceAttr("lxd_network_zone.zone1", "used_by.0", "/1.0/networks/"+networkName),
+				),
+			},
+		},
+	})
+}
+
+func testAccNetworkZoneExists(t *testing.T, n string, zone *api.NetworkZone) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No ID is set")
+		}
+
+		client, err := testAccProvider.Meta().(*lxdProvider).GetContainerServer("")
+		if err != nil {
+			return err
+		}
+		z, _, err := client.GetNetworkZone(rs.Primary.ID)
+		if err != nil {
+			return err
+		}
+
+		*zone = *z
+
+		return nil
+	}
+}
+
+func testAccNetworkZone_basic(description string) string {
+	return fmt.Sprintf(`
+resource "lxd_network_zone" "zone1" {
+  name        = "lab.example.net"
+  description = "%s"
+
+  config {
+    dns.nameservers = "ns1.example.net"
+  }
+}
+`, description)
+}
+
+func testAccNetworkZone_network(networkName string) string {
+	return fmt.Sprintf(`
+resource "lxd_network_zone" "zone1" {
+  name = "lab.example.net"
+}
+
+resource "lxd_network" "net1" {
+  name = "%s"
+
+  config {
+    ipv4.address     = "10.150.24.1/24"
+    ipv6.address     = "none"
+    dns.zone.forward = "${lxd_network_zone.zone1.name}"
+  }
+}
+`, networkName)
+}
@@ -1,10 +1,13 @@
 package lxd
 
 import (
+	"fmt"
 	"log"
+	"strings"
 
 	"github.com/hashicorp/terraform/helper/schema"
 
+	lxd "github.com/lxc/lxd/client"
 	"github.com/lxc/lxd/shared/api"
 )
 
@@ -15,6 +18,9 @@ func resourceLxdProfile() *schema.Resource {
 		Delete: resourceLxdProfileDelete,
 		Exists: resourceLxdProfileExists,
 		Read:   resourceLxdProfileRead,
+
+		CustomizeDiff: resourceLxdProfileCustomizeDiff,
+
 		Importer: &schema.ResourceImporter{
 			State: resourceLxdProfileImport,
 		},
@@ -22,7 +28,6 @@ func resourceLxdProfile() *schema.Resource {
 		Schema: map[string]*schema.Schema{
 			"name": &schema.Schema{
 				Type:     schema.TypeString,
-				ForceNew: true,
 				Required: true,
 			},
 
@@ -60,28 +65,89 @@ func resourceLxdProfile() *schema.Resource {
 				Optional: true,
 			},
 
+			"cloud_init": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"user_data": &schema.Schema{
+							Type:             schema.TypeString,
+							Optional:         true,
+							DiffSuppressFunc: suppressCloudInitDifferences,
+						},
+
+						"vendor_data": &schema.Schema{
+							Type:             schema.TypeString,
+							Optional:         true,
+							DiffSuppressFunc: suppressCloudInitDifferences,
+						},
+
+						"network_config": &schema.Schema{
+							Type:             schema.TypeString,
+							Optional:         true,
+							DiffSuppressFunc: suppressCloudInitDifferences,
+						},
+					},
+				},
+			},
+
+			"adopt": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"keep_on_destroy": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+
 			"remote": &schema.Schema{
 				Type:     schema.TypeString,
 				ForceNew: true,
 				Optional: true,
 				Default:  "",
 			},
+
+			"project": &schema.Schema{
+				Type:     schema.TypeString,
+				ForceNew: true,
+				Optional: true,
+			},
+
+			"used_by": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
 		},
 	}
 }
 
 func resourceLxdProfileCreate(d *schema.ResourceData, meta interface{}) error {
 	p := meta.(*lxdProvider)
-	server, err := p.GetContainerServer(p.selectRemote(d))
+	server, err := p.selectServer(d)
 	if err != nil {
 		return err
 	}
 
 	name := d.Get("name").(string)
 	description := d.Get("description").(string)
-	config := resourceLxdConfigMap(d.Get("config"))
+	config := profileConfig(d.Get("config"), d.Get("cloud_init"))
 	devices := resourceLxdDevices(d.Get("device"))
 
+	if d.Get("adopt").(bool) {
+		if _, _, err := server.GetProfile(name); err == nil {
+			if err := resourceLxdProfileAdopt(d, server); err != nil {
+				return err
+			}
+
+			return resourceLxdProfileRead(d, meta)
+		}
+	}
+
 	req := api.ProfilesPost{Name: name}
 	req.Config = config
 	req.Devices = devices
@@ -98,7 +164,7 @@ func resourceLxdProfileCreate(d *schema.ResourceData, meta interface{}) error {
 
 func resourceLxdProfileRead(d *schema.ResourceData, meta interface{}) error {
 	p := meta.(*lxdProvider)
-	server, err := p.GetContainerServer(p.selectRemote(d))
+	server, err := p.selectServer(d)
 	if err != nil {
 		return err
 	}
@@ -112,8 +178,50 @@ func resourceLxdProfileRead(d *schema.ResourceData, meta interface{}) error {
 
 	log.Printf("[DEBUG] Retrieved profile %s: %#v", name, profile)
 
-	d.Set("description", profile.Description)
+	// Adopted profiles only track what is declared of them.
+	adopted := d.Get("adopt").(bool)
+	if adopted {
+		config := make(map[string]string)
+		for k := range profileConfig(d.Get("config"), d.Get("cloud_init")) {
+			if v, ok := profile.Config[k]; ok {
+				config[k] = v
+			}
+		}
+		profile.Config = config
+
+		declared := resourceLxdDevices(d.Get("device"))
+		for n := range profile.Devices {
+			if _, ok := declared[n]; !ok {
+				delete(profile.Devices, n)
+			}
+		}
+	}
+
+	d.Set("name", profile.Name)
+	if !adopted || d.Get("description").(string) != "" {
+		d.Set("description", profile.Description)
+	}
+	// cloud-init keys go to the cloud_init block,
+	// unless they were set through config.
+	configured := d.Get("config").(map[string]interface{})
+	cloudInit := make(map[string]interface{})
+	for attr, key := range instanceCloudInitKeys {
+		if _, ok := configured[key]; ok {
+			continue
+		}
+		if v, ok := profile.Config[key]; ok {
+			cloudInit[attr] = v
+			delete(profile.Config, key)
+		}
+	}
+	if len(cloudInit) > 0 {
+		d.Set("cloud_init", []interface{}{cloudInit})
+	} else {
+		d.Set("cloud_init", nil)
+	}
+
 	d.Set("config", profile.Config)
+	d.Set("used_by", profile.UsedBy)
 
 	devices := make([]map[string]interface{}, 0)
 	for name, lxddevice := range profile.Devices {
@@ -131,11 +239,17 @@ func resourceLxdProfileRead(d *schema.ResourceData, meta interface{}) error {
 
 func resourceLxdProfileUpdate(d *schema.ResourceData, meta interface{}) error {
 	p := meta.(*lxdProvider)
-	server, err := p.GetContainerServer(p.selectRemote(d))
+	server, err := p.selectServer(d)
 	if err != nil {
 		return err
 	}
 
+	if d.HasChange("name") {
+		if err := resourceLxdProfileRename(d, server); err != nil {
+			return err
+		}
+	}
+
 	name := d.Id()
 
 	var changed bool
@@ -158,10 +272,24 @@ func resourceLxdProfileUpdate(d *schema.ResourceData, meta interface{}) error {
 		newProfile.Description = newDescription.(string)
 	}
 
-	if d.HasChange("config") {
+	// Only the keys known to the provider are changed, leaving the others
+	// of adopted profiles alone.
+	if d.HasChange("config") || d.HasChange("cloud_init") {
 		changed = true
-		_, newConfig := d.GetChange("config")
-		newProfile.Config = resourceLxdConfigMap(newConfig)
+		oldConfig, newConfig := d.GetChange("config")
+		oldCloudInit, newCloudInit := d.GetChange("cloud_init")
+
+		if newProfile.Config == nil {
+			newProfile.Config = map[string]string{}
+		}
+
+		for k := range profileConfig(oldConfig, oldCloudInit) {
+			delete(newProfile.Config, k)
+		}
+
+		for k, v := range profileConfig(newConfig, newCloudInit) {
+			newProfile.Config[k] = v
+		}
 	}
 
 	if d.HasChange("device") {
@@ -170,6 +298,10 @@ func resourceLxdProfileUpdate(d *schema.ResourceData, meta interface{}) error {
 		oldDevices := resourceLxdDevices(old)
 		newDevices := resourceLxdDevices(new)
 
+		if newProfile.Devices == nil {
+			newProfile.Devices = map[string]map[string]string{}
+		}
+
 		for n := range oldDevices {
 			delete(newProfile.Devices, n)
 		}
@@ -193,21 +325,134 @@ func resourceLxdProfileUpdate(d *schema.ResourceData, meta interface{}) error {
 	return resourceLxdProfileRead(d, meta)
 }
 
+func resourceLxdProfileCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	// Profiles have no type, so devices are checked
+	// as they would be on any instance.
+	if err := resourceLxdValidateDevicesDiff(d); err != nil {
+		return err
+	}
+
+	// Unknown keys are reported when planning, rather than by LXD
+	// when applying.
+	if (d.Id() == "" || d.HasChange("config")) && d.NewValueKnown("config") {
+		config := resourceLxdConfigMap(d.Get("config"))
+		if err := resourceLxdCheckConfigKeys(d, meta.(*lxdProvider), "instance", config); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// profileConfig returns the config of a profile, along with the
+// cloud-init keys set by its cloud_init block.
+func profileConfig(config, cloudInit interface{}) map[string]string {
+	c := resourceLxdConfigMap(config)
+	for _, v := range cloudInit.([]interface{}) {
+		block, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		for attr, key := range instanceCloudInitKeys {
+			if v, ok := block[attr].(string); ok && v != "" {
+				c[key] = v
+			}
+		}
+	}
+
+	return c
+}
+
+// resourceLxdProfileAdopt takes over an existing profile, setting the
+// description, config keys and devices declared for it and leaving the
+// rest as it is.
+func resourceLxdProfileAdopt(d *schema.ResourceData, server lxd.ContainerServer) error {
+	name := d.Get("name").(string)
+
+	profile, etag, err := server.GetProfile(name)
+	if err != nil {
+		return err
+	}
+
+	newProfile := profile.Writable()
+	if newProfile.Config == nil {
+		newProfile.Config = map[string]string{}
+	}
+	if newProfile.Devices == nil {
+		newProfile.Devices = map[string]map[string]string{}
+	}
+
+	if v := d.Get("description").(string); v != "" {
+		newProfile.Description = v
+	}
+
+	for k, v := range profileConfig(d.Get("config"), d.Get("cloud_init")) {
+		newProfile.Config[k] = v
+	}
+
+	for n, device := range resourceLxdDevices(d.Get("device")) {
+		newProfile.Devices[n] = device
+	}
+
+	log.Printf("[DEBUG] Adopting profile %s", name)
+	if err := server.UpdateProfile(name, newProfile, etag); err != nil {
+		return fmt.Errorf("Unable to adopt profile (%s): %s", name, err)
+	}
+
+	d.SetId(name)
+
+	return nil
+}
+
+// resourceLxdProfileRename renames a profile in place. The instances
+// using it follow the new name.
+func resourceLxdProfileRename(d *schema.ResourceData, server lxd.ContainerServer) error {
+	oldName := d.Id()
+	newName := d.Get("name").(string)
+
+	log.Printf("[DEBUG] Renaming profile %s to %s", oldName, newName)
+	if err := server.RenameProfile(oldName, api.ProfilePost{Name: newName}); err != nil {
+		return fmt.Errorf("Unable to rename profile (%s): %s", oldName, err)
+	}
+
+	d.SetId(newName)
+
+	return nil
+}
+
 func resourceLxdProfileDelete(d *schema.ResourceData, meta interface{}) (err error) {
 	p := meta.(*lxdProvider)
-	server, err := p.GetContainerServer(p.selectRemote(d))
+	server, err := p.selectServer(d)
 	if err != nil {
 		return err
 	}
 
 	name := d.Id()
 
+	if d.Get("adopt").(bool) && d.Get("keep_on_destroy").(bool) {
+		log.Printf("[DEBUG] Keeping adopted profile %s", name)
+		return nil
+	}
+
+	// Instances and other profiles may still list this profile.
+	gone, err := resourceLxdCheckUnused("Profile", name, func() ([]string, error) {
+		profile, _, err := server.GetProfile(name)
+		if err != nil {
+			return nil, err
+		}
+		return profile.UsedBy, nil
+	})
+	if err != nil || gone {
+		return err
+	}
+
 	return server.DeleteProfile(name)
 }
 
 func resourceLxdProfileExists(d *schema.ResourceData, meta interface{}) (exists bool, err error) {
 	p := meta.(*lxdProvider)
-	server, err := p.GetContainerServer(p.selectRemote(d))
+	server, err := p.selectServer(d)
 	if err != nil {
 		return false, err
 	}
@@ -224,6 +469,10 @@ func resourceLxdProfileExists(d *schema.ResourceData, meta interface{}) (exists
 	return
 }
 
+// resourceLxdProfileImport imports a profile with its description, config
+// and devices as they are on the server, so that existing profiles can be
+// managed without being re-created. Its ID is of the form
+// [remote:][project/]name.
 func resourceLxdProfileImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
 	p := meta.(*lxdProvider)
 	remote, name, err := p.LXDConfig.ParseRemote(d.Id())
@@ -232,23 +481,23 @@ func resourceLxdProfileImport(d *schema.ResourceData, meta interface{}) ([]*sche
 		return nil, err
 	}
 
+	if parts := strings.SplitN(name, "/", 2); len(parts) == 2 {
+		d.Set("project", parts[0])
+		name = parts[1]
+	}
+
 	d.SetId(name)
 	if p.LXDConfig.DefaultRemote != remote {
 		d.Set("remote", remote)
 	}
 
-	server, err := p.GetContainerServer(p.selectRemote(d))
-	if err != nil {
-		return nil, err
-	}
+	// An imported profile is managed as a whole.
+	d.Set("adopt", false)
+	d.Set("keep_on_destroy", true)
 
-	profile, _, err := server.GetProfile(name)
-	if err != nil {
-		return nil, err
+	if err := resourceLxdProfileRead(d, meta); err != nil {
+		return nil, fmt.Errorf("Unable to import profile (%s): %s", name, err)
 	}
 
-	log.Printf("[DEBUG] Import Retrieved profile %s: %#v", name, profile)
-
-	d.Set("name", name)
 	return []*schema.ResourceData{d}, nil
 }
@@ -2,7 +2,9 @@ package lxd
 
 import (
 	"fmt"
+	"os"
 	"reflect"
+	"regexp"
 	"strings"
 	"testing"
 
@@ -54,6 +56,180 @@ func TestAccProfile_config(t *testing.T) {
 	})
 }
 
+func TestAccProfile_description(t *testing.T) {
+	var profile api.Profile
+	profileName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccProfile_description(profileName, "Web servers"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("lxd_profile.profile1", "description", "Web servers"),
+					testAccProfileRunning(t, "lxd_profile.profile1", &profile),
+				),
+			},
+			resource.TestStep{
+				Config: testAccProfile_description(profileName, "Web and proxy servers"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("lxd_profile.profile1", "description", "Web and proxy servers"),
+					testAccProfileRunning(t, "lxd_profile.profile1", &profile),
+				),
+			},
+		},
+	})
+}
+
+func TestAccProfile_rename(t *testing.T) {
+	var profile api.Profile
+	profileName := strings.ToLower(petname.Generate(2, "-"))
+	newProfileName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccProfile_config(profileName),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("lxd_profile.profile1", "name", profileName),
+					testAccProfileRunning(t, "lxd_profile.profile1", &profile),
+				),
+			},
+			resource.TestStep{
+				Config: testAccProfile_config(newProfileName),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("lxd_profile.profile1", "name", newProfileName),
+					resource.TestCheckResourceAttr("lxd_profile.profile1", "id", newProfileName),
+					testAccProfileRunning(t, "lxd_profile.profile1", &profile),
+					testAccProfileConfig(&profile, "limits.cpu", "2"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccProfile_adoptDefault(t *testing.T) {
+	var profile api.Profile
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccProfile_adoptDefault("yes"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccProfileRunning(t, "lxd_profile.default", &profile),
+					testAccProfileConfig(&profile, "user.tf-adopted", "yes"),
+					testAccProfileHasDevice(&profile, "root"),
+					resource.TestCheckResourceAttr("lxd_profile.default", "config.%", "1"),
+					resource.TestCheckResourceAttr("lxd_profile.default", "device.#", "0"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccProfile_adoptDefault("still"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccProfileRunning(t, "lxd_profile.default", &profile),
+					testAccProfileConfig(&profile, "user.tf-adopted", "still"),
+					testAccProfileHasDevice(&profile, "root"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccProfile_project(t *testing.T) {
+	profileName := strings.ToLower(petname.Generate(2, "-"))
+
+	// The project must have its own profiles.
+	project := os.Getenv("LXD_TEST_PROJECT")
+	if project == "" {
+		t.Skip("LXD_TEST_PROJECT must name a project with features.profiles")
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccProfile_project(profileName, project),
+				Check: resource.ComposeTestCheckFunc(
+					testAccProfileInProject(profileName, project),
+					resource.TestCheckResourceAttr("lxd_profile.profile1", "project", project),
+					resource.TestCheckResourceAttr("lxd_profile.profile1", "config.limits.cpu", "2"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccProfile_cloudInit(t *testing.T) {
+	var profile api.Profile
+	profileName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccProfile_cloudInit(profileName, "#cloud-config\npackages:\n  - curl\npackage_update: true\n"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccProfileRunning(t, "lxd_profile.profile1", &profile),
+					testAccProfileConfig(&profile, "cloud-init.user-data", "#cloud-config\npackages:\n  - curl\npackage_update: true\n"),
+					resource.TestCheckNoResourceAttr("lxd_profile.profile1", "config.cloud-init.user-data"),
+				),
+			},
+			// Reordered keys and extra blank lines don't change anything.
+			resource.TestStep{
+				Config: testAccProfile_cloudInit(profileName, "#cloud-config\npackage_update: true\npackages:\n  - curl\n\n\n"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccProfileRunning(t, "lxd_profile.profile1", &profile),
+					testAccProfileConfig(&profile, "cloud-init.user-data", "#cloud-config\npackages:\n  - curl\npackage_update: true\n"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccProfile_unknownConfigKey(t *testing.T) {
+	profileName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testAccConfigKeysPreCheck(t)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccProfile_configKey(profileName, "limits.cpus", "2"),
+				ExpectError: regexp.MustCompile(`Unknown config key limits.cpus, did you mean one of: .*limits.cpu`),
+			},
+			resource.TestStep{
+				Config:      testAccProfile_configKey(profileName, "security.nesting", "maybe"),
+				ExpectError: regexp.MustCompile(`Config key security.nesting must be a boolean`),
+			},
+		},
+	})
+}
+
+func TestAccProfile_invalidDevice(t *testing.T) {
+	profileName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccProfile_invalidDevice(profileName),
+				ExpectError: regexp.MustCompile(`Device eth1 of type nic must have one of these properties set: nictype, network`),
+			},
+		},
+	})
+}
+
 func TestAccProfile_device(t *testing.T) {
 	var profile api.Profile
 	profileName := strings.ToLower(petname.Generate(2, "-"))
@@ -207,6 +383,30 @@ func TestAccProfile_containerConfig(t *testing.T) {
 	})
 }
 
+func TestAccProfile_usedBy(t *testing.T) {
+	profileName := strings.ToLower(petname.Generate(2, "-"))
+	containerName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccProfile_containerConfig(profileName, containerName),
+			},
+			// used_by is only up to date once refreshed after the
+			// container was created.
+			resource.TestStep{
+				Config: testAccProfile_containerConfig(profileName, containerName),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("lxd_profile.profile1", "used_by.#", "1"),
+					resource.TestCheckResourceAttr("lxd_profile.profile1", "used_by.0", "/1.0/instances/"+containerName),
+				),
+			},
+		},
+	})
+}
+
 func TestAccProfile_containerDevice(t *testing.T) {
 	var profile api.Profile
 	var container api.Container
@@ -268,6 +468,42 @@ func testAccProfileRunning(t *testing.T, n string, profile *api.Profile) resourc
 	}
 }
 
+// testAccConfigKeysPreCheck skips tests of config key validation on
+// servers that don't document their config keys.
+func testAccConfigKeysPreCheck(t *testing.T) {
+	if err := testAccProvider.Configure(terraform.NewResourceConfig(nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := testAccProvider.Meta().(*lxdProvider).GetContainerServer("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !client.HasExtension("metadata_configuration") {
+		t.Skip("The LXD server doesn't document its config keys")
+	}
+}
+
+func testAccProfileInProject(name, project string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		client, err := testAccProvider.Meta().(*lxdProvider).GetContainerServer("")
+		if err != nil {
+			return err
+		}
+
+		if _, _, err := client.UseProject(project).GetProfile(name); err != nil {
+			return fmt.Errorf("Profile %s not found in project %s: %s", name, project, err)
+		}
+
+		if _, _, err := client.UseProject("default").GetProfile(name); err == nil {
+			return fmt.Errorf("Profile %s was created in the default project", name)
+		}
+
+		return nil
+	}
+}
+
 func testAccProfileConfig(profile *api.Profile, k, v string) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		if profile.Config == nil {
@@ -306,6 +542,16 @@ func testAccProfileDevice(profile *api.Profile, deviceName string, device map[st
 	}
 }
 
+func testAccProfileHasDevice(profile *api.Profile, deviceName string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		if _, ok := profile.Devices[deviceName]; !ok {
+			return fmt.Errorf("Device not found: %s", deviceName)
+		}
+
+		return nil
+	}
+}
+
 func testAccProfileNoDevice(profile *api.Profile, deviceName string) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		if profile.Devices == nil {
@@ -339,6 +585,65 @@ resource "lxd_profile" "profile1" {
 	`, name)
 }
 
+func testAccProfile_description(name, description string) string {
+	return fmt.Sprintf(`
+resource "lxd_profile" "profile1" {
+  name        = "%s"
+  description = "%s"
+}
+	`, name, description)
+}
+
+func testAccProfile_adoptDefault(value string) string {
+	return fmt.Sprintf(`
+resource "lxd_profile" "default" {
+  name  = "default"
+  adopt = true
+
+  config {
+    user.tf-adopted = "%s"
+  }
+}
+	`, value)
+}
+
+func testAccProfile_project(name, project string) string {
+	return fmt.Sprintf(`
+resource "lxd_profile" "profile1" {
+  name    = "%s"
+  project = "%s"
+
+  config {
+    limits.cpu = 2
+  }
+}
+	`, name, project)
+}
+
+func testAccProfile_cloudInit(name, userData string) string {
+	return fmt.Sprintf(`
+resource "lxd_profile" "profile1" {
+  name = "%s"
+
+  cloud_init {
+    user_data = "%s"
+  }
+}
+	`, name, strings.Replace(userData, "\n", "\\n", -1))
+}
+
+func testAccProfile_configKey(name, key, value string) string {
+	return fmt.Sprintf(`
+resource "lxd_profile" "profile1" {
+  name = "%s"
+
+  config {
+    %s = "%s"
+  }
+}
+	`, name, key, value)
+}
+
 func testAccProfile_device_1(name string) string {
 	return fmt.Sprintf(`
 resource "lxd_profile" "profile1" {
@@ -487,3 +792,20 @@ resource "lxd_container" "container1" {
 }
 	`, profileName, containerName)
 }
+
+func testAccProfile_invalidDevice(name string) string {
+	return fmt.Sprintf(`
+resource "lxd_profile" "profile1" {
+  name = "%s"
+
+  device {
+    name = "eth1"
+    type = "nic"
+
+    properties {
+      parent = "lxdbr0"
+    }
+  }
+}
+	`, name)
+}
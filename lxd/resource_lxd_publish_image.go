@@ -0,0 +1,175 @@
+package lxd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/lxc/lxd/shared/api"
+)
+
+func resourceLxdPublishImage() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceLxdPublishImageCreate,
+		Update: resourceLxdLocalImageUpdate,
+		Delete: resourceLxdLocalImageDelete,
+		Exists: resourceLxdLocalImageExists,
+		Read:   resourceLxdLocalImageRead,
+
+		Schema: map[string]*schema.Schema{
+			"container_name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"snapshot_name": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"aliases": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
+			"properties": {
+				Type:     schema.TypeMap,
+				Optional: true,
+			},
+
+			"expires_at": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Computed:         true,
+				ValidateFunc:     resourceLxdValidateTimestamp,
+				DiffSuppressFunc: suppressTimestampDifferences,
+			},
+
+			"profiles": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
+			"public": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"compression_algorithm": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"remote": {
+				Type:     schema.TypeString,
+				ForceNew: true,
+				Optional: true,
+				Default:  "",
+			},
+
+			"project": {
+				Type:     schema.TypeString,
+				ForceNew: true,
+				Optional: true,
+			},
+
+			// Computed attributes
+
+			"architecture": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"created_at": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"fingerprint": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceLxdPublishImageCreate(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+	refreshInterval := p.RefreshInterval
+
+	ctrName := d.Get("container_name").(string)
+	snapName := d.Get("snapshot_name").(string)
+
+	aliases, err := resourceLxdImageNewAliases(d, server)
+	if err != nil {
+		return err
+	}
+
+	req := api.ImagesPost{
+		Source: &api.ImagesPostSource{},
+	}
+	req.Aliases = aliases
+	req.Public = d.Get("public").(bool)
+	req.Properties = resourceLxdConfigMap(d.Get("properties"))
+	req.Profiles = resourceLxdImageProfiles(d)
+	req.ExpiresAt = resourceLxdImageExpiresAt(d)
+	req.CompressionAlgorithm = d.Get("compression_algorithm").(string)
+
+	if snapName != "" {
+		req.Source.Type = "snapshot"
+		req.Source.Name = fmt.Sprintf("%s/%s", ctrName, snapName)
+	} else {
+		req.Source.Type = "container"
+		req.Source.Name = ctrName
+
+		// A running container can't be published, so stop it for
+		// the duration of the publish and start it again afterwards.
+		ct, _, err := server.GetInstanceState(ctrName)
+		if err != nil {
+			return err
+		}
+
+		if ct.Status == "Running" {
+			if err := resourceLxdInstanceSetState(server, ctrName, "stop", false, refreshInterval); err != nil {
+				return err
+			}
+
+			defer func() {
+				if err := resourceLxdInstanceSetState(server, ctrName, "start", false, refreshInterval); err != nil {
+					log.Printf("[WARN] Unable to restart container %s after publish: %s", ctrName, err)
+				}
+			}()
+		}
+	}
+
+	log.Printf("[DEBUG] Publishing image from %s", req.Source.Name)
+	op, err := server.CreateImage(req, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := op.Wait(); err != nil {
+		return fmt.Errorf("Failed to publish image from %s: %s", req.Source.Name, err)
+	}
+
+	opAPI := op.Get()
+	fingerprint, ok := opAPI.Metadata["fingerprint"].(string)
+	if !ok {
+		return fmt.Errorf("Unable to determine fingerprint of image published from %s", req.Source.Name)
+	}
+
+	d.SetId(fingerprint)
+
+	return resourceLxdLocalImageRead(d, meta)
+}
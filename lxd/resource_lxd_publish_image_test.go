@@ -0,0 +1,122 @@
+package lxd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dustinkirkland/golang-petname"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+func TestAccPublishImage_basic(t *testing.T) {
+	var img api.Image
+	containerName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccPublishImage_basic(containerName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccImageExists(t, "lxd_publish_image.pimg", &img),
+					resource.TestCheckResourceAttr("lxd_publish_image.pimg", "container_name", containerName),
+					resource.TestCheckResourceAttrSet("lxd_publish_image.pimg", "fingerprint"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccPublishImage_snapshot(t *testing.T) {
+	var img api.Image
+	containerName := strings.ToLower(petname.Generate(2, "-"))
+	alias := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccPublishImage_snapshot(containerName, alias),
+				Check: resource.ComposeTestCheckFunc(
+					testAccImageExists(t, "lxd_publish_image.pimg", &img),
+					testAccCachedImageContainsAlias(&img, alias),
+					resource.TestCheckResourceAttr("lxd_publish_image.pimg", "properties.os", "alpine"),
+				),
+			},
+		},
+	})
+}
+
+func testAccImageExists(t *testing.T, n string, image *api.Image) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found in state: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No ID is set")
+		}
+
+		client, err := testAccProvider.Meta().(*lxdProvider).GetContainerServer("")
+		if err != nil {
+			return err
+		}
+		img, _, err := client.GetImage(rs.Primary.ID)
+		if err != nil {
+			return err
+		}
+
+		if img != nil {
+			*image = *img
+			return nil
+		}
+
+		return fmt.Errorf("Image not found: %s", rs.Primary.ID)
+	}
+}
+
+func testAccPublishImage_basic(name string) string {
+	return fmt.Sprintf(`
+resource "lxd_container" "container1" {
+  name = "%s"
+  image = "images:alpine/3.9/amd64"
+  profiles = ["default"]
+}
+
+resource "lxd_publish_image" "pimg" {
+  container_name = "${lxd_container.container1.name}"
+}
+	`, name)
+}
+
+func testAccPublishImage_snapshot(name, alias string) string {
+	return fmt.Sprintf(`
+resource "lxd_container" "container1" {
+  name = "%s"
+  image = "images:alpine/3.9/amd64"
+  profiles = ["default"]
+}
+
+resource "lxd_snapshot" "snapshot1" {
+  container_name = "${lxd_container.container1.name}"
+  name = "snap1"
+}
+
+resource "lxd_publish_image" "pimg" {
+  container_name = "${lxd_container.container1.name}"
+  snapshot_name = "${lxd_snapshot.snapshot1.name}"
+  aliases = ["%s"]
+
+  properties {
+    os = "alpine"
+  }
+}
+	`, name, alias)
+}
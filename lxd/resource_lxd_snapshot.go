@@ -55,6 +55,12 @@ func resourceLxdSnapshot() *schema.Resource {
 				Optional: true,
 				Default:  "",
 			},
+
+			"project": &schema.Schema{
+				Type:     schema.TypeString,
+				ForceNew: true,
+				Optional: true,
+			},
 		},
 	}
 }
@@ -63,7 +69,7 @@ func resourceLxdSnapshotCreate(d *schema.ResourceData, meta interface{}) error {
 	p := meta.(*lxdProvider)
 
 	remote := p.selectRemote(d)
-	server, err := p.GetContainerServer(remote)
+	server, err := p.selectServer(d)
 	if err != nil {
 		return err
 	}
@@ -74,34 +80,16 @@ func resourceLxdSnapshotCreate(d *schema.ResourceData, meta interface{}) error {
 	snapPost.Name = d.Get("name").(string)
 	snapPost.Stateful = d.Get("stateful").(bool)
 
-	// stateful snapshots usually fail straight after container creation
-	// add a retry loop for creating snapshots
-	var i int
-	for i = 0; i < 5; i++ {
-
+	err = resourceLxdSnapshotRetry(ctrName, snapPost.Name, snapPost.Stateful, func() error {
 		op, err := server.CreateContainerSnapshot(ctrName, snapPost)
 		if err != nil {
 			return err
 		}
 
-		// Wait for snapshot operation to complete
-		err = op.Wait()
-		if err != nil {
-			if snapPost.Stateful && strings.Contains(err.Error(), "Dumping FAILED") {
-				log.Printf("[DEBUG] error creating stateful snapshot [%d]: %v", i, err)
-				time.Sleep(3 * time.Second)
-			} else if strings.Contains(err.Error(), "file has vanished") {
-				// ignore, try again
-				time.Sleep(3 * time.Second)
-			} else {
-				return err
-			}
-		} else {
-			break
-		}
-	}
+		return op.Wait()
+	})
 	if err != nil {
-		return fmt.Errorf("Failed to create snapshot after %d attempts, last error: %v", i, err)
+		return err
 	}
 
 	snapID := newSnapshotID(remote, ctrName, snapPost.Name)
@@ -112,7 +100,7 @@ func resourceLxdSnapshotCreate(d *schema.ResourceData, meta interface{}) error {
 
 func resourceLxdSnapshotRead(d *schema.ResourceData, meta interface{}) error {
 	p := meta.(*lxdProvider)
-	server, err := p.GetContainerServer(p.selectRemote(d))
+	server, err := p.selectServer(d)
 	if err != nil {
 		return err
 	}
@@ -139,7 +127,7 @@ func resourceLxdSnapshotRead(d *schema.ResourceData, meta interface{}) error {
 
 func resourceLxdSnapshotDelete(d *schema.ResourceData, meta interface{}) error {
 	p := meta.(*lxdProvider)
-	server, err := p.GetContainerServer(p.selectRemote(d))
+	server, err := p.selectServer(d)
 	if err != nil {
 		return err
 	}
@@ -152,7 +140,7 @@ func resourceLxdSnapshotDelete(d *schema.ResourceData, meta interface{}) error {
 
 func resourceLxdSnapshotExists(d *schema.ResourceData, meta interface{}) (bool, error) {
 	p := meta.(*lxdProvider)
-	server, err := p.GetContainerServer(p.selectRemote(d))
+	server, err := p.selectServer(d)
 	if err != nil {
 		return false, err
 	}
@@ -170,6 +158,34 @@ func resourceLxdSnapshotExists(d *schema.ResourceData, meta interface{}) (bool,
 	return false, err
 }
 
+// resourceLxdSnapshotRetry takes a snapshot of an instance through take.
+// Stateful snapshots often fail right after the instance started, so they
+// are attempted a few times.
+func resourceLxdSnapshotRetry(instance, name string, stateful bool, take func() error) error {
+	for i := 1; ; i++ {
+		err := take()
+		if err == nil {
+			return nil
+		}
+
+		if stateful {
+			err = resourceLxdStatefulError(err)
+		}
+
+		retry := (stateful && strings.Contains(err.Error(), "Dumping FAILED")) ||
+			strings.Contains(err.Error(), "file has vanished")
+		if !retry {
+			return err
+		}
+		if i == 5 {
+			return fmt.Errorf("Failed to create snapshot after %d attempts, last error: %v", i, err)
+		}
+
+		log.Printf("[DEBUG] Error creating snapshot %s of instance %s [%d]: %v", name, instance, i, err)
+		time.Sleep(3 * time.Second)
+	}
+}
+
 type snapshotID struct {
 	remote    string
 	container string
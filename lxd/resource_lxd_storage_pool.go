@@ -3,9 +3,12 @@ package lxd
 import (
 	"fmt"
 	"log"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/hashicorp/terraform/helper/schema"
+	lxd "github.com/lxc/lxd/client"
 	"github.com/lxc/lxd/shared/api"
 )
 
@@ -16,6 +19,9 @@ func resourceLxdStoragePool() *schema.Resource {
 		Delete: resourceLxdStoragePoolDelete,
 		Exists: resourceLxdStoragePoolExists,
 		Read:   resourceLxdStoragePoolRead,
+
+		CustomizeDiff: resourceLxdStoragePoolCustomizeDiff,
+
 		Importer: &schema.ResourceImporter{
 			State: resourceLxdStoragePoolImport,
 		},
@@ -28,23 +34,106 @@ func resourceLxdStoragePool() *schema.Resource {
 			},
 
 			"driver": &schema.Schema{
+				Type:         schema.TypeString,
+				ForceNew:     true,
+				Required:     true,
+				ValidateFunc: resourceLxdValidateStoragePoolDriver,
+			},
+
+			"description": &schema.Schema{
 				Type:     schema.TypeString,
-				ForceNew: true,
-				Required: true,
-				ValidateFunc: func(v interface{}, k string) (ws []string, errors []error) {
-					value := v.(string)
-					if value != "dir" && value != "lvm" && value != "btrfs" && value != "zfs" {
-						errors = append(errors, fmt.Errorf(
-							"Only dir, lvm, btrfs, and zfs are supported values for 'driver'"))
-					}
-					return
-				},
+				Optional: true,
 			},
 
 			"config": &schema.Schema{
 				Type:     schema.TypeMap,
-				Required: true,
-				ForceNew: false,
+				Optional: true,
+			},
+
+			"ceph": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"cluster_name": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+							Default:  "ceph",
+						},
+
+						"user_name": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+							Default:  "admin",
+						},
+
+						"osd_pool_name": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+
+						"path": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+
+						"pg_num": &schema.Schema{
+							Type:     schema.TypeInt,
+							Optional: true,
+						},
+
+						"data_pool_name": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+
+						"meta_pool_name": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+
+						"existing": &schema.Schema{
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  false,
+						},
+					},
+				},
+			},
+
+			"member_config": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"target": &schema.Schema{
+							Type:     schema.TypeString,
+							Required: true,
+						},
+
+						"config": &schema.Schema{
+							Type:     schema.TypeMap,
+							Optional: true,
+						},
+					},
+				},
+			},
+
+			"expanded_config": &schema.Schema{
+				Type:     schema.TypeMap,
+				Computed: true,
+			},
+
+			"status": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"used_by": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
 			},
 
 			"remote": &schema.Schema{
@@ -66,16 +155,53 @@ func resourceLxdStoragePoolCreate(d *schema.ResourceData, meta interface{}) erro
 
 	name := d.Get("name").(string)
 	driver := d.Get("driver").(string)
-	config := resourceLxdConfigMap(d.Get("config"))
+	config := storagePoolConfig(driver, d.Get)
+
+	// A pool of a cluster is first defined on each member with the
+	// config specific to it, such as its source, and then created on
+	// all of them at once.
+	members := d.Get("member_config").([]interface{})
+	for _, m := range members {
+		member := m.(map[string]interface{})
+		target := member["target"].(string)
+
+		log.Printf("[DEBUG] Defining storage pool %s on cluster member %s", name, target)
+		post := api.StoragePoolsPost{Name: name, Driver: driver}
+		post.Config = storagePoolMemberConfig(member, config)
+
+		mutex.Lock()
+		err = server.UseTarget(target).CreateStoragePool(post)
+		mutex.Unlock()
 
-	log.Printf("Attempting to create storage pool %s", name)
+		if err != nil {
+			resourceLxdStoragePoolDeletePending(server, name)
+			return fmt.Errorf("Unable to define storage pool (%s) on cluster member %s: %s", name, target, err)
+		}
+	}
+
+	if len(members) > 0 {
+		for k := range storagePoolMemberKeys {
+			delete(config, k)
+		}
+	}
+
+	log.Printf("[DEBUG] Creating storage pool %s with config: %#v", name, config)
 	post := api.StoragePoolsPost{}
 	post.Name = name
 	post.Driver = driver
 	post.Config = config
+	post.Description = d.Get("description").(string)
 
-	if err := server.CreateStoragePool(post); err != nil {
-		return err
+	mutex.Lock()
+	err = server.CreateStoragePool(post)
+	mutex.Unlock()
+
+	if err != nil {
+		if len(members) > 0 {
+			resourceLxdStoragePoolDeletePending(server, name)
+		}
+
+		return fmt.Errorf("Unable to create storage pool (%s): %s", name, err)
 	}
 
 	d.SetId(name)
@@ -96,17 +222,85 @@ func resourceLxdStoragePoolRead(d *schema.ResourceData, meta interface{}) error
 	if err != nil {
 		return err
 	}
-	d.Set("driver", pool.Driver)
-	config := pool.Config
-	delete(config, "name")
-	for k := range config {
-		if strings.HasPrefix(k, "volatile") {
-			delete(config, k)
+
+	log.Printf("[DEBUG] Retrieved storage pool %s: %#v", name, pool)
+
+	// Only the keys set through config are tracked. The ones LXD fills in
+	// itself, such as the source and size of a loop backed pool, are only
+	// part of expanded_config.
+	declared := d.Get("config").(map[string]interface{})
+	config := make(map[string]string)
+	expandedConfig := make(map[string]string)
+	for k, v := range pool.Config {
+		if k == "name" || strings.HasPrefix(k, "volatile.") {
+			continue
+		}
+
+		expandedConfig[k] = v
+		if _, ok := declared[k]; ok {
+			config[k] = v
 		}
 	}
-	d.Set("config", config)
 
-	log.Printf("[DEBUG] Retrieved storage pool %s: %#v", name, pool)
+	// The keys of Ceph pools go to the ceph block, when it is used. What
+	// LXD was asked to create with, such as the placement groups, is kept
+	// as declared.
+	if blocks := d.Get("ceph").([]interface{}); len(blocks) > 0 {
+		ceph, _ := blocks[0].(map[string]interface{})
+		if ceph == nil {
+			ceph = map[string]interface{}{}
+		}
+
+		prefix := pool.Driver + "."
+		if v, ok := pool.Config[prefix+"cluster_name"]; ok {
+			ceph["cluster_name"] = v
+		}
+		if v, ok := pool.Config[prefix+"user.name"]; ok {
+			ceph["user_name"] = v
+		}
+
+		// LXD names the OSD pool after the storage pool when not told.
+		if declared, _ := ceph["osd_pool_name"].(string); declared != "" {
+			if pool.Driver == "ceph" {
+				ceph["osd_pool_name"] = pool.Config["ceph.osd.pool_name"]
+			} else {
+				ceph["osd_pool_name"] = strings.SplitN(pool.Config["source"], "/", 2)[0]
+			}
+		}
+		d.Set("ceph", []interface{}{ceph})
+	}
+
+	// The same goes for the keys specific to each cluster member.
+	members := d.Get("member_config").([]interface{})
+	for i, m := range members {
+		member := m.(map[string]interface{})
+		target := member["target"].(string)
+
+		memberPool, _, err := server.UseTarget(target).GetStoragePool(name)
+		if err != nil {
+			return fmt.Errorf("Unable to retrieve storage pool (%s) on cluster member %s: %s", name, target, err)
+		}
+
+		memberConfig := make(map[string]string)
+		for k := range member["config"].(map[string]interface{}) {
+			if v, ok := memberPool.Config[k]; ok {
+				memberConfig[k] = v
+			}
+		}
+
+		members[i] = map[string]interface{}{
+			"target": target,
+			"config": memberConfig,
+		}
+	}
+	d.Set("member_config", members)
+
+	d.Set("driver", pool.Driver)
+	d.Set("description", pool.Description)
+	d.Set("config", config)
+	d.Set("expanded_config", expandedConfig)
+	d.Set("status", pool.Status)
+	d.Set("used_by", pool.UsedBy)
 
 	return nil
 }
@@ -120,27 +314,123 @@ func resourceLxdStoragePoolUpdate(d *schema.ResourceData, meta interface{}) erro
 
 	name := d.Id()
 
-	if d.HasChange("config") {
-		pool, etag, err := server.GetStoragePool(name)
-		if err != nil {
-			return err
+	pool, etag, err := server.GetStoragePool(name)
+	if err != nil {
+		return err
+	}
+
+	newPool := pool.Writable()
+	if newPool.Config == nil {
+		newPool.Config = map[string]string{}
+	}
+
+	if d.HasChange("description") {
+		newPool.Description = d.Get("description").(string)
+	}
+
+	// Keys no longer set are removed, letting LXD fill them in again
+	// when it does so.
+	if d.HasChange("config") || d.HasChange("ceph") {
+		driver := d.Get("driver").(string)
+		oldConfig := storagePoolConfig(driver, func(k string) interface{} { o, _ := d.GetChange(k); return o })
+		newConfig := storagePoolConfig(driver, func(k string) interface{} { _, n := d.GetChange(k); return n })
+
+		// The keys specific to cluster members are set on each of them.
+		if len(d.Get("member_config").([]interface{})) > 0 {
+			for k := range storagePoolMemberKeys {
+				delete(oldConfig, k)
+				delete(newConfig, k)
+			}
 		}
 
-		config := resourceLxdConfigMap(d.Get("config"))
-		pool.Config = config
+		for k := range oldConfig {
+			delete(newPool.Config, k)
+		}
 
-		log.Printf("[DEBUG] Updated storage pool %s config: %#v", name, pool)
+		for k, v := range newConfig {
+			newPool.Config[k] = v
+		}
+	}
+
+	log.Printf("[DEBUG] Updating storage pool %s with config: %#v", name, newPool.Config)
+	mutex.Lock()
+	err = server.UpdateStoragePool(name, newPool, etag)
+	mutex.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("Unable to update storage pool (%s): %s", name, err)
+	}
 
-		post := api.StoragePoolPut{}
-		post.Config = config
-		if err := server.UpdateStoragePool(name, post, etag); err != nil {
+	if d.HasChange("member_config") {
+		if err := resourceLxdStoragePoolUpdateMembers(d, server); err != nil {
 			return err
 		}
 	}
 
+	return resourceLxdStoragePoolRead(d, meta)
+}
+
+// resourceLxdStoragePoolUpdateMembers updates the config specific to
+// each cluster member. The members stay the same, changing them
+// re-creates the pool.
+func resourceLxdStoragePoolUpdateMembers(d *schema.ResourceData, server lxd.ContainerServer) error {
+	name := d.Id()
+	oldMembers, newMembers := d.GetChange("member_config")
+
+	old := make(map[string]map[string]string)
+	for _, m := range oldMembers.([]interface{}) {
+		member := m.(map[string]interface{})
+		old[member["target"].(string)] = resourceLxdConfigMap(member["config"])
+	}
+
+	for _, m := range newMembers.([]interface{}) {
+		member := m.(map[string]interface{})
+		target := member["target"].(string)
+		targetServer := server.UseTarget(target)
+
+		memberPool, etag, err := targetServer.GetStoragePool(name)
+		if err != nil {
+			return fmt.Errorf("Unable to retrieve storage pool (%s) on cluster member %s: %s", name, target, err)
+		}
+
+		newPool := memberPool.Writable()
+		if newPool.Config == nil {
+			newPool.Config = map[string]string{}
+		}
+
+		for k := range old[target] {
+			delete(newPool.Config, k)
+		}
+
+		for k, v := range resourceLxdConfigMap(member["config"]) {
+			newPool.Config[k] = v
+		}
+
+		log.Printf("[DEBUG] Updating storage pool %s on cluster member %s with config: %#v", name, target, newPool.Config)
+		mutex.Lock()
+		err = targetServer.UpdateStoragePool(name, newPool, etag)
+		mutex.Unlock()
+
+		if err != nil {
+			return fmt.Errorf("Unable to update storage pool (%s) on cluster member %s: %s", name, target, err)
+		}
+	}
+
 	return nil
 }
 
+// resourceLxdStoragePoolDeletePending removes a storage pool whose
+// creation failed part way on a cluster, leaving it pending on some
+// members.
+func resourceLxdStoragePoolDeletePending(server lxd.ContainerServer, name string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if err := server.DeleteStoragePool(name); err != nil {
+		log.Printf("[WARN] Unable to remove pending storage pool %s: %s", name, err)
+	}
+}
+
 func resourceLxdStoragePoolDelete(d *schema.ResourceData, meta interface{}) (err error) {
 	p := meta.(*lxdProvider)
 	server, err := p.GetContainerServer(p.selectRemote(d))
@@ -150,6 +440,21 @@ func resourceLxdStoragePoolDelete(d *schema.ResourceData, meta interface{}) (err
 
 	name := d.Id()
 
+	// Deleting a pool would take its volumes with it.
+	gone, err := resourceLxdCheckUnused("Storage pool", name, func() ([]string, error) {
+		pool, _, err := server.GetStoragePool(name)
+		if err != nil {
+			return nil, err
+		}
+		return pool.UsedBy, nil
+	})
+	if err != nil || gone {
+		return err
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
 	return server.DeleteStoragePool(name)
 }
 
@@ -196,6 +501,346 @@ func resourceLxdStoragePoolImport(d *schema.ResourceData, meta interface{}) ([]*
 	}
 	log.Printf("[DEBUG] Import Retrieved storage pool %s: %#v", name, pool)
 
+	// All the keys of an imported pool are managed.
+	config := make(map[string]string)
+	for k, v := range pool.Config {
+		if k == "name" || strings.HasPrefix(k, "volatile.") {
+			continue
+		}
+		config[k] = v
+	}
+
 	d.Set("name", name)
+	d.Set("config", config)
 	return []*schema.ResourceData{d}, nil
 }
+
+func resourceLxdStoragePoolCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	// The config of cluster members is updated in place, but the pool
+	// is defined on the members when it's created.
+	if d.Id() != "" && d.HasChange("member_config") {
+		old, new := d.GetChange("member_config")
+		if !sameTargets(old.([]interface{}), new.([]interface{})) {
+			if err := d.ForceNew("member_config"); err != nil {
+				return err
+			}
+		}
+	}
+
+	if d.Id() != "" && !d.HasChange("config") && !d.HasChange("ceph") && !d.HasChange("member_config") {
+		return nil
+	}
+
+	for _, k := range []string{"config", "driver", "ceph", "member_config"} {
+		if !d.NewValueKnown(k) {
+			return nil
+		}
+	}
+
+	driver := d.Get("driver").(string)
+	if err := resourceLxdStoragePoolCheckCeph(d, driver); err != nil {
+		return err
+	}
+
+	// The keys of a driver are checked when planning, as LXD only
+	// reports a wrong one once the pool is half created on clusters.
+	config := storagePoolConfig(driver, d.Get)
+	if err := resourceLxdStoragePoolCheckConfig(driver, config); err != nil {
+		return err
+	}
+
+	if err := resourceLxdCheckConfigKeys(d, meta.(*lxdProvider), "storage-"+driver, config); err != nil {
+		return err
+	}
+
+	// On a cluster the keys specific to each member, such as the source
+	// of the pool, can only be set per member.
+	members := d.Get("member_config").([]interface{})
+	if len(members) > 0 {
+		for k := range d.Get("config").(map[string]interface{}) {
+			if storagePoolMemberKeys[k] {
+				return fmt.Errorf("config.%s is specific to each cluster member, it must be set through member_config", k)
+			}
+		}
+	}
+	for _, m := range members {
+		member := m.(map[string]interface{})
+		memberConfig := resourceLxdConfigMap(member["config"])
+		for k := range memberConfig {
+			if !storagePoolMemberKeys[k] {
+				return fmt.Errorf("Cluster member %s: config.%s isn't specific to cluster members, it must be set through config", member["target"], k)
+			}
+		}
+
+		if err := resourceLxdStoragePoolCheckConfig(driver, memberConfig); err != nil {
+			return fmt.Errorf("Cluster member %s: %s", member["target"], err)
+		}
+	}
+
+	if d.Id() == "" {
+		return nil
+	}
+
+	// Only some keys can be changed once the pool is created, the ones
+	// naming what the pool is made of can't, whether they were set
+	// through config or filled in by LXD.
+	oldConfig := resourceLxdConfigMap(d.Get("expanded_config"))
+	for k, v := range config {
+		if !storagePoolImmutableKeys[k] {
+			continue
+		}
+
+		if o, ok := oldConfig[k]; ok && o != v {
+			return fmt.Errorf("config.%s of storage pool (%s) can't be changed once it is created", k, d.Id())
+		}
+	}
+
+	old, _ := d.GetChange("member_config")
+	oldMembers := make(map[string]map[string]string)
+	for _, m := range old.([]interface{}) {
+		member := m.(map[string]interface{})
+		oldMembers[member["target"].(string)] = resourceLxdConfigMap(member["config"])
+	}
+	for _, m := range members {
+		member := m.(map[string]interface{})
+		for k, v := range resourceLxdConfigMap(member["config"]) {
+			if !storagePoolImmutableKeys[k] {
+				continue
+			}
+
+			if o, ok := oldMembers[member["target"].(string)][k]; ok && o != v {
+				return fmt.Errorf("Cluster member %s: config.%s of storage pool (%s) can't be changed once it is created", member["target"], k, d.Id())
+			}
+		}
+	}
+
+	return nil
+}
+
+// resourceLxdStoragePoolCheckCeph checks the ceph block of a storage
+// pool. Pools LXD creates and existing ones take different settings.
+func resourceLxdStoragePoolCheckCeph(d *schema.ResourceDiff, driver string) error {
+	blocks := d.Get("ceph").([]interface{})
+	if len(blocks) == 0 {
+		return nil
+	}
+
+	if driver != "ceph" && driver != "cephfs" {
+		return fmt.Errorf("ceph is only supported by ceph and cephfs storage pools, not %s ones", driver)
+	}
+
+	ceph, ok := blocks[0].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	declared := d.Get("config").(map[string]interface{})
+	for k := range storagePoolCephConfig(driver, ceph) {
+		if _, ok := declared[k]; ok {
+			return fmt.Errorf("%s can't be set through both config and ceph", k)
+		}
+	}
+
+	if driver == "cephfs" && ceph["osd_pool_name"].(string) == "" {
+		return fmt.Errorf("ceph.osd_pool_name must be set to the file system of cephfs storage pools")
+	}
+
+	if driver == "ceph" {
+		for _, k := range []string{"path", "meta_pool_name"} {
+			if ceph[k].(string) != "" {
+				return fmt.Errorf("ceph.%s is only supported by cephfs storage pools", k)
+			}
+		}
+	}
+
+	if ceph["existing"].(bool) {
+		if ceph["osd_pool_name"].(string) == "" {
+			return fmt.Errorf("ceph.osd_pool_name must be set to the existing pool to use")
+		}
+
+		for _, k := range []string{"data_pool_name", "meta_pool_name"} {
+			if ceph[k].(string) != "" {
+				return fmt.Errorf("ceph.%s only applies to pools LXD creates, not existing ones", k)
+			}
+		}
+		if ceph["pg_num"].(int) != 0 {
+			return fmt.Errorf("ceph.pg_num only applies to pools LXD creates, not existing ones")
+		}
+	}
+
+	return nil
+}
+
+// resourceLxdStoragePoolCheckConfig checks that the driver specific keys
+// of a storage pool, such as zfs.pool_name or ceph.cluster_name, are the
+// ones of its driver.
+func resourceLxdStoragePoolCheckConfig(driver string, config map[string]string) error {
+	keys := make([]string, 0, len(config))
+	for k := range config {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		prefix := ""
+		for p := range storagePoolDriverKeys {
+			if strings.HasPrefix(k, p) && len(p) > len(prefix) {
+				prefix = p
+			}
+		}
+		if prefix == "" {
+			continue
+		}
+
+		drivers := storagePoolDriverKeys[prefix]
+		supported := false
+		for _, v := range drivers {
+			supported = supported || v == driver
+		}
+
+		if !supported {
+			names := drivers[len(drivers)-1]
+			if len(drivers) > 1 {
+				names = strings.Join(drivers[:len(drivers)-1], ", ") + " and " + names
+			}
+			return fmt.Errorf("config.%s is only supported by %s storage pools, not %s ones", k, names, driver)
+		}
+	}
+
+	return nil
+}
+
+// storagePoolDriverKeys maps the prefixes of the driver specific config
+// keys of storage pools to the drivers supporting them.
+var storagePoolDriverKeys = map[string][]string{
+	"btrfs.":         {"btrfs"},
+	"ceph.":          {"ceph"},
+	"cephfs.":        {"cephfs"},
+	"lvm.":           {"lvm"},
+	"zfs.":           {"zfs"},
+	"volume.block.":  {"ceph", "lvm", "zfs"},
+	"volume.lvm.":    {"lvm"},
+	"volume.zfs.":    {"zfs"},
+	"volume.cephfs.": {"cephfs"},
+}
+
+// storagePoolImmutableKeys are the config keys of storage pools LXD
+// only accepts when the pool is created.
+var storagePoolImmutableKeys = map[string]bool{
+	"source":                  true,
+	"zfs.pool_name":           true,
+	"lvm.vg_name":             true,
+	"lvm.use_thinpool":        true,
+	"ceph.cluster_name":       true,
+	"ceph.osd.pg_num":         true,
+	"ceph.osd.pool_name":      true,
+	"ceph.user.name":          true,
+	"ceph.osd.data_pool_name": true,
+	"cephfs.cluster_name":     true,
+	"cephfs.path":             true,
+	"cephfs.user.name":        true,
+	"cephfs.osd_pg_num":       true,
+	"cephfs.data_pool":        true,
+	"cephfs.meta_pool":        true,
+}
+
+// storagePoolMemberKeys are the config keys of storage pools specific to
+// each member of a cluster.
+var storagePoolMemberKeys = map[string]bool{
+	"source":            true,
+	"size":              true,
+	"zfs.pool_name":     true,
+	"lvm.vg_name":       true,
+	"lvm.thinpool_name": true,
+}
+
+// storagePoolMemberConfig returns the config specific to a cluster
+// member, along with the keys specific to members the ceph block sets for
+// all of them.
+func storagePoolMemberConfig(member map[string]interface{}, config map[string]string) map[string]string {
+	c := resourceLxdConfigMap(member["config"])
+	for k, v := range config {
+		if _, ok := c[k]; !ok && storagePoolMemberKeys[k] {
+			c[k] = v
+		}
+	}
+
+	return c
+}
+
+// storagePoolConfig returns the config of a storage pool, along with the
+// keys of its ceph block for the driver. get returns the value of an
+// attribute, e.g. d.Get.
+func storagePoolConfig(driver string, get func(string) interface{}) map[string]string {
+	c := resourceLxdConfigMap(get("config"))
+	for _, v := range get("ceph").([]interface{}) {
+		if ceph, ok := v.(map[string]interface{}); ok {
+			for k, v := range storagePoolCephConfig(driver, ceph) {
+				c[k] = v
+			}
+		}
+	}
+
+	return c
+}
+
+// storagePoolCephConfig returns the config keys of the ceph block of a
+// storage pool. LXD uses the pools and file systems named by source as
+// they are, and creates the other ones.
+func storagePoolCephConfig(driver string, ceph map[string]interface{}) map[string]string {
+	c := make(map[string]string)
+	set := func(key, attr string) {
+		if v, ok := ceph[attr].(string); ok && v != "" {
+			c[key] = v
+		}
+	}
+
+	existing, _ := ceph["existing"].(bool)
+	pgNum, _ := ceph["pg_num"].(int)
+	osdPool, _ := ceph["osd_pool_name"].(string)
+	path, _ := ceph["path"].(string)
+
+	switch driver {
+	case "ceph":
+		set("ceph.cluster_name", "cluster_name")
+		set("ceph.user.name", "user_name")
+		set("ceph.osd.data_pool_name", "data_pool_name")
+		if existing {
+			c["source"] = osdPool
+		} else if osdPool != "" {
+			c["ceph.osd.pool_name"] = osdPool
+		}
+		if pgNum > 0 {
+			c["ceph.osd.pg_num"] = strconv.Itoa(pgNum)
+		}
+
+	case "cephfs":
+		set("cephfs.cluster_name", "cluster_name")
+		set("cephfs.user.name", "user_name")
+		if path != "" {
+			osdPool = osdPool + "/" + strings.TrimPrefix(path, "/")
+		}
+		c["source"] = osdPool
+		if !existing {
+			c["cephfs.create_missing"] = "true"
+			set("cephfs.data_pool", "data_pool_name")
+			set("cephfs.meta_pool", "meta_pool_name")
+			if pgNum > 0 {
+				c["cephfs.osd_pg_num"] = strconv.Itoa(pgNum)
+			}
+		}
+	}
+
+	return c
+}
+
+func resourceLxdValidateStoragePoolDriver(v interface{}, k string) (ws []string, errors []error) {
+	switch v.(string) {
+	case "dir", "zfs", "btrfs", "lvm", "ceph", "cephfs":
+	default:
+		errors = append(errors, fmt.Errorf(
+			"Only dir, zfs, btrfs, lvm, ceph and cephfs are supported values for '%s'", k))
+	}
+
+	return
+}
@@ -2,6 +2,8 @@ package lxd
 
 import (
 	"fmt"
+	"os"
+	"regexp"
 	"strings"
 	"testing"
 
@@ -32,6 +34,152 @@ func TestAccStoragePool_basic(t *testing.T) {
 	})
 }
 
+func TestAccStoragePool_update(t *testing.T) {
+	var pool api.StoragePool
+	poolName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccStoragePool_update(poolName, "/tmp/foo", "Pool", "10MB"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccStoragePoolExists(t, "lxd_storage_pool.storage_pool1", &pool),
+					testAccStoragePoolConfig(&pool, "rsync.bwlimit", "10MB"),
+					resource.TestCheckResourceAttr("lxd_storage_pool.storage_pool1", "status", "Created"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccStoragePool_update(poolName, "/tmp/foo", "Updated", "20MB"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccStoragePoolExists(t, "lxd_storage_pool.storage_pool1", &pool),
+					testAccStoragePoolConfig(&pool, "rsync.bwlimit", "20MB"),
+					testAccStoragePoolConfig(&pool, "source", "/tmp/foo"),
+					resource.TestCheckResourceAttr("lxd_storage_pool.storage_pool1", "description", "Updated"),
+				),
+			},
+			resource.TestStep{
+				Config:      testAccStoragePool_update(poolName, "/tmp/bar", "Updated", "20MB"),
+				ExpectError: regexp.MustCompile(`config.source of storage pool .* can't be changed once it is created`),
+			},
+		},
+	})
+}
+
+func TestAccStoragePool_driverValidation(t *testing.T) {
+	poolName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccStoragePool_driverKey(poolName, "dir", "zfs.pool_name", "tank"),
+				ExpectError: regexp.MustCompile(`config.zfs.pool_name is only supported by zfs storage pools, not dir ones`),
+			},
+			resource.TestStep{
+				Config:      testAccStoragePool_driverKey(poolName, "zfs", "ceph.cluster_name", "ceph"),
+				ExpectError: regexp.MustCompile(`config.ceph.cluster_name is only supported by ceph storage pools, not zfs ones`),
+			},
+		},
+	})
+}
+
+func TestAccStoragePool_ceph(t *testing.T) {
+	var pool api.StoragePool
+	poolName := strings.ToLower(petname.Generate(2, "-"))
+
+	// LXD must be set up to reach the cluster, with the ceph tools.
+	cluster := os.Getenv("LXD_CEPH_CLUSTER")
+	if cluster == "" {
+		t.Skip("LXD_CEPH_CLUSTER must name a Ceph cluster LXD can use")
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccStoragePool_ceph(poolName, cluster),
+				Check: resource.ComposeTestCheckFunc(
+					testAccStoragePoolExists(t, "lxd_storage_pool.storage_pool1", &pool),
+					testAccStoragePoolConfig(&pool, "ceph.cluster_name", cluster),
+					testAccStoragePoolConfig(&pool, "ceph.osd.pool_name", poolName+"-osd"),
+					testAccStoragePoolConfig(&pool, "ceph.osd.pg_num", "32"),
+					resource.TestCheckResourceAttr("lxd_storage_pool.storage_pool1", "ceph.0.osd_pool_name", poolName+"-osd"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccStoragePool_cephValidation(t *testing.T) {
+	poolName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccStoragePool_cephBlock(poolName, "dir", `osd_pool_name = "lxd"`),
+				ExpectError: regexp.MustCompile(`ceph is only supported by ceph and cephfs storage pools`),
+			},
+			resource.TestStep{
+				Config:      testAccStoragePool_cephBlock(poolName, "cephfs", `path = "/lxd"`),
+				ExpectError: regexp.MustCompile(`ceph.osd_pool_name must be set to the file system`),
+			},
+			resource.TestStep{
+				Config: testAccStoragePool_cephBlock(poolName, "ceph", `osd_pool_name = "lxd"
+    existing      = true
+    pg_num        = 64`),
+				ExpectError: regexp.MustCompile(`ceph.pg_num only applies to pools LXD creates`),
+			},
+		},
+	})
+}
+
+func TestAccStoragePool_cluster(t *testing.T) {
+	var pool api.StoragePool
+	poolName := strings.ToLower(petname.Generate(2, "-"))
+
+	members := strings.Split(os.Getenv("LXD_CLUSTER_MEMBERS"), ",")
+	if len(members) < 2 {
+		t.Skip("LXD_CLUSTER_MEMBERS must list at least two cluster members")
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccStoragePool_cluster(poolName, "source", members),
+				Check: resource.ComposeTestCheckFunc(
+					testAccStoragePoolExists(t, "lxd_storage_pool.storage_pool1", &pool),
+					resource.TestCheckResourceAttr("lxd_storage_pool.storage_pool1", "status", "Created"),
+					resource.TestCheckResourceAttr("lxd_storage_pool.storage_pool1", "member_config.#", fmt.Sprintf("%d", len(members))),
+					resource.TestCheckResourceAttr("lxd_storage_pool.storage_pool1", "member_config.0.config.source", "/tmp/"+poolName+"-"+members[0]),
+				),
+			},
+		},
+	})
+}
+
+func TestAccStoragePool_clusterValidation(t *testing.T) {
+	poolName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccStoragePool_cluster(poolName, "rsync.bwlimit", []string{"node1", "node2"}),
+				ExpectError: regexp.MustCompile(`Cluster member node1: config.rsync.bwlimit isn't specific to cluster members`),
+			},
+		},
+	})
+}
+
 func testAccStoragePoolExists(t *testing.T, n string, pool *api.StoragePool) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rs, ok := s.RootModule().Resources[n]
@@ -93,3 +241,81 @@ resource "lxd_storage_pool" "storage_pool1" {
 }
 	`, name)
 }
+
+func testAccStoragePool_update(name, source, description, bwlimit string) string {
+	return fmt.Sprintf(`
+resource "lxd_storage_pool" "storage_pool1" {
+  name        = "%s"
+  driver      = "dir"
+  description = "%s"
+
+  config {
+    source        = "%s"
+    rsync.bwlimit = "%s"
+  }
+}
+`, name, description, source, bwlimit)
+}
+
+func testAccStoragePool_driverKey(name, driver, key, value string) string {
+	return fmt.Sprintf(`
+resource "lxd_storage_pool" "storage_pool1" {
+  name   = "%s"
+  driver = "%s"
+
+  config {
+    %s = "%s"
+  }
+}
+`, name, driver, key, value)
+}
+
+func testAccStoragePool_ceph(name, cluster string) string {
+	return fmt.Sprintf(`
+resource "lxd_storage_pool" "storage_pool1" {
+  name   = "%s"
+  driver = "ceph"
+
+  ceph {
+    cluster_name  = "%s"
+    osd_pool_name = "%s-osd"
+    pg_num        = 32
+  }
+}
+`, name, cluster, name)
+}
+
+func testAccStoragePool_cephBlock(name, driver, ceph string) string {
+	return fmt.Sprintf(`
+resource "lxd_storage_pool" "storage_pool1" {
+  name   = "%s"
+  driver = "%s"
+
+  ceph {
+    %s
+  }
+}
+`, name, driver, ceph)
+}
+
+func testAccStoragePool_cluster(name, key string, members []string) string {
+	var memberConfig string
+	for _, member := range members {
+		memberConfig += fmt.Sprintf(`
+  member_config {
+    target = "%s"
+
+    config {
+      %s = "/tmp/%s-%s"
+    }
+  }
+`, member, key, name, member)
+	}
+
+	return fmt.Sprintf(`
+resource "lxd_storage_pool" "storage_pool1" {
+  name   = "%s"
+  driver = "dir"
+%s}
+`, name, memberConfig)
+}
@@ -1,11 +1,21 @@
 package lxd
 
 import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
 	"log"
+	"os"
+	"path"
 	"strings"
 
 	"github.com/hashicorp/terraform/helper/schema"
+	lxd "github.com/lxc/lxd/client"
 	"github.com/lxc/lxd/shared/api"
+	"github.com/mitchellh/go-homedir"
+	"github.com/pkg/sftp"
 )
 
 func resourceLxdVolume() *schema.Resource {
@@ -16,6 +26,8 @@ func resourceLxdVolume() *schema.Resource {
 		Exists: resourceLxdVolumeExists,
 		Read:   resourceLxdVolumeRead,
 
+		CustomizeDiff: resourceLxdVolumeCustomizeDiff,
+
 		Schema: map[string]*schema.Schema{
 			"name": &schema.Schema{
 				Type:     schema.TypeString,
@@ -42,24 +54,57 @@ func resourceLxdVolume() *schema.Resource {
 				ForceNew: false,
 			},
 
+			"source_backup": &schema.Schema{
+				Type:          schema.TypeString,
+				ForceNew:      true,
+				Optional:      true,
+				ConflictsWith: []string{"source_iso", "source_tarball"},
+			},
+
+			"source_iso": &schema.Schema{
+				Type:          schema.TypeString,
+				ForceNew:      true,
+				Optional:      true,
+				ConflictsWith: []string{"source_backup", "source_tarball"},
+			},
+
+			"source_tarball": &schema.Schema{
+				Type:          schema.TypeString,
+				ForceNew:      true,
+				Optional:      true,
+				ConflictsWith: []string{"source_backup", "source_iso"},
+			},
+
 			"expanded_config": &schema.Schema{
 				Type:     schema.TypeMap,
 				Computed: true,
 			},
 
+			"used_by": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
 			"remote": &schema.Schema{
 				Type:     schema.TypeString,
 				ForceNew: true,
 				Optional: true,
 				Default:  "",
 			},
+
+			"project": &schema.Schema{
+				Type:     schema.TypeString,
+				ForceNew: true,
+				Optional: true,
+			},
 		},
 	}
 }
 
 func resourceLxdVolumeCreate(d *schema.ResourceData, meta interface{}) error {
 	p := meta.(*lxdProvider)
-	server, err := p.GetContainerServer(p.selectRemote(d))
+	server, err := p.selectServer(d)
 	if err != nil {
 		return err
 	}
@@ -69,13 +114,32 @@ func resourceLxdVolumeCreate(d *schema.ResourceData, meta interface{}) error {
 	volType := d.Get("type").(string)
 	config := resourceLxdConfigMap(d.Get("config"))
 
-	log.Printf("Attempting to create volume %s", name)
-	volume := api.StorageVolumesPost{}
-	volume.Name = name
-	volume.Type = volType
-	volume.Config = config
-	if err := server.CreateStoragePoolVolume(pool, volume); err != nil {
-		return err
+	backup := d.Get("source_backup").(string)
+	iso := d.Get("source_iso").(string)
+	if backup != "" || iso != "" {
+		if err := resourceLxdVolumeCreateFromFile(server, pool, name, backup, iso, config); err != nil {
+			return err
+		}
+	} else {
+		log.Printf("Attempting to create volume %s", name)
+		volume := api.StorageVolumesPost{}
+		volume.Name = name
+		volume.Type = volType
+		volume.Config = config
+		if err := server.CreateStoragePoolVolume(pool, volume); err != nil {
+			return err
+		}
+	}
+
+	// Volumes seeded from a tarball are deleted when the tarball can't
+	// be extracted, rather than being left half populated.
+	if tarball := d.Get("source_tarball").(string); tarball != "" {
+		if err := resourceLxdVolumeExtractTarball(server, pool, name, tarball); err != nil {
+			if err := server.DeleteStoragePoolVolume(pool, volType, name); err != nil {
+				log.Printf("[WARN] Unable to delete volume %s of pool %s: %s", name, pool, err)
+			}
+			return err
+		}
 	}
 
 	v := newVolumeID(pool, name, volType)
@@ -84,9 +148,172 @@ func resourceLxdVolumeCreate(d *schema.ResourceData, meta interface{}) error {
 	return resourceLxdVolumeRead(d, meta)
 }
 
+// resourceLxdVolumeCreateFromFile imports a backup tarball or an ISO image
+// as a new custom volume. The config of the volume is then applied on top
+// of the one of the backup.
+func resourceLxdVolumeCreateFromFile(server lxd.ContainerServer, pool, name, backup, iso string, config map[string]string) error {
+	file := backup
+	if iso != "" {
+		file = iso
+	}
+
+	file, err := homedir.Expand(file)
+	if err != nil {
+		return fmt.Errorf("unable to determine file path: %s", err)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("Unable to open %s: %s", file, err)
+	}
+	defer f.Close()
+
+	args := lxd.StoragePoolVolumeBackupArgs{
+		BackupFile: f,
+		Name:       name,
+	}
+
+	log.Printf("[DEBUG] Importing %s as volume %s of pool %s", file, name, pool)
+	var op lxd.Operation
+	if iso != "" {
+		op, err = server.CreateStoragePoolVolumeFromISO(pool, args)
+	} else {
+		op, err = server.CreateStoragePoolVolumeFromBackup(pool, args)
+	}
+	if err != nil {
+		return err
+	}
+	if err := op.Wait(); err != nil {
+		return fmt.Errorf("Unable to import %s as volume (%s) of pool %s: %s", file, name, pool, err)
+	}
+
+	if len(config) == 0 {
+		return nil
+	}
+
+	volume, etag, err := server.GetStoragePoolVolume(pool, "custom", name)
+	if err != nil {
+		return err
+	}
+
+	newVolume := volume.Writable()
+	for k, v := range config {
+		newVolume.Config[k] = v
+	}
+
+	if err := server.UpdateStoragePoolVolume(pool, "custom", name, newVolume, etag); err != nil {
+		return fmt.Errorf("Unable to update volume (%s) of pool %s: %s", name, pool, err)
+	}
+
+	return nil
+}
+
+// resourceLxdVolumeExtractTarball extracts a tarball, compressed with gzip
+// or not, at the root of a filesystem custom volume, through the SFTP
+// access LXD gives to the volumes.
+func resourceLxdVolumeExtractTarball(server lxd.ContainerServer, pool, name, tarball string) error {
+	file, err := homedir.Expand(tarball)
+	if err != nil {
+		return fmt.Errorf("unable to determine tarball path: %s", err)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("Unable to open tarball %s: %s", file, err)
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	var r io.Reader = br
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return fmt.Errorf("Unable to read tarball %s: %s", file, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	client, err := server.GetStoragePoolVolumeFileSFTP(pool, "custom", name)
+	if err != nil {
+		return fmt.Errorf("Unable to access volume (%s) of pool %s: %s", name, pool, err)
+	}
+	defer client.Close()
+
+	log.Printf("[DEBUG] Extracting tarball %s to volume %s of pool %s", file, name, pool)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("Unable to read tarball %s: %s", file, err)
+		}
+
+		target := path.Join("/", hdr.Name)
+		if err := volumeExtractTarEntry(client, tr, hdr, target); err != nil {
+			return fmt.Errorf("Unable to extract %s of tarball %s: %s", hdr.Name, file, err)
+		}
+	}
+}
+
+func volumeExtractTarEntry(client *sftp.Client, r io.Reader, hdr *tar.Header, target string) error {
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		if err := client.MkdirAll(target); err != nil {
+			return err
+		}
+	case tar.TypeReg:
+		if err := client.MkdirAll(path.Dir(target)); err != nil {
+			return err
+		}
+
+		f, err := client.Create(target)
+		if err != nil {
+			return err
+		}
+
+		_, err = io.Copy(f, r)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	case tar.TypeSymlink:
+		return client.Symlink(hdr.Linkname, target)
+	case tar.TypeLink:
+		return client.Link(path.Join("/", hdr.Linkname), target)
+	default:
+		log.Printf("[DEBUG] Skipping %s of type %c", hdr.Name, hdr.Typeflag)
+		return nil
+	}
+
+	if err := client.Chown(target, hdr.Uid, hdr.Gid); err != nil {
+		return err
+	}
+
+	return client.Chmod(target, os.FileMode(hdr.Mode).Perm())
+}
+
+// resourceLxdVolumeCustomizeDiff checks the volume can be seeded from the
+// source it is given.
+func resourceLxdVolumeCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() != "" || d.Get("type").(string) == "custom" {
+		return nil
+	}
+
+	for _, k := range []string{"source_backup", "source_iso", "source_tarball"} {
+		if d.NewValueKnown(k) && d.Get(k).(string) != "" {
+			return fmt.Errorf("%s is only supported by custom volumes", k)
+		}
+	}
+
+	return nil
+}
+
 func resourceLxdVolumeRead(d *schema.ResourceData, meta interface{}) error {
 	p := meta.(*lxdProvider)
-	server, err := p.GetContainerServer(p.selectRemote(d))
+	server, err := p.selectServer(d)
 	if err != nil {
 		return err
 	}
@@ -99,23 +326,33 @@ func resourceLxdVolumeRead(d *schema.ResourceData, meta interface{}) error {
 
 	log.Printf("[DEBUG] Retrieved volume %s: %#v", v.name, volume)
 
-	// remove volatile entries from Config map
-	newConfig := map[string]string{}
+	// Only the keys set through config are tracked. The other ones, such
+	// as the ones LXD sets itself or the ones of a restored backup, are
+	// only part of expanded_config.
+	declared := d.Get("config").(map[string]interface{})
+	config := make(map[string]string)
+	expandedConfig := make(map[string]string)
 	for k, v := range volume.Config {
-		if !strings.Contains(k, "volatile") {
-			newConfig[k] = v
+		if strings.HasPrefix(k, "volatile.") {
+			continue
+		}
+
+		expandedConfig[k] = v
+		if _, ok := declared[k]; ok {
+			config[k] = v
 		}
 	}
 
-	d.Set("config", newConfig)
-	d.Set("expanded_config", volume.Config)
+	d.Set("config", config)
+	d.Set("expanded_config", expandedConfig)
+	d.Set("used_by", volume.UsedBy)
 
 	return nil
 }
 
 func resourceLxdVolumeUpdate(d *schema.ResourceData, meta interface{}) error {
 	p := meta.(*lxdProvider)
-	server, err := p.GetContainerServer(p.selectRemote(d))
+	server, err := p.selectServer(d)
 	if err != nil {
 		return err
 	}
@@ -127,13 +364,24 @@ func resourceLxdVolumeUpdate(d *schema.ResourceData, meta interface{}) error {
 			return err
 		}
 
-		config := resourceLxdConfigMap(d.Get("config"))
-		volume.Config = config
+		// Keys no longer set are removed, the ones that were never set
+		// through config are left as they are.
+		post := volume.Writable()
+		if post.Config == nil {
+			post.Config = map[string]string{}
+		}
+
+		oldConfig, newConfig := d.GetChange("config")
+		for k := range resourceLxdConfigMap(oldConfig) {
+			delete(post.Config, k)
+		}
+
+		for k, v := range resourceLxdConfigMap(newConfig) {
+			post.Config[k] = v
+		}
 
-		log.Printf("[DEBUG] Updated volume config: %#v", volume)
+		log.Printf("[DEBUG] Updated volume config: %#v", post.Config)
 
-		post := api.StorageVolumePut{}
-		post.Config = config
 		if err := server.UpdateStoragePoolVolume(v.pool, v.volType, v.name, post, etag); err != nil {
 			return err
 		}
@@ -144,7 +392,7 @@ func resourceLxdVolumeUpdate(d *schema.ResourceData, meta interface{}) error {
 
 func resourceLxdVolumeDelete(d *schema.ResourceData, meta interface{}) (err error) {
 	p := meta.(*lxdProvider)
-	server, err := p.GetContainerServer(p.selectRemote(d))
+	server, err := p.selectServer(d)
 	if err != nil {
 		return err
 	}
@@ -156,7 +404,7 @@ func resourceLxdVolumeDelete(d *schema.ResourceData, meta interface{}) (err erro
 
 func resourceLxdVolumeExists(d *schema.ResourceData, meta interface{}) (exists bool, err error) {
 	p := meta.(*lxdProvider)
-	server, err := p.GetContainerServer(p.selectRemote(d))
+	server, err := p.selectServer(d)
 	if err != nil {
 		return false, err
 	}
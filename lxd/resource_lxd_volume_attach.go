@@ -0,0 +1,266 @@
+package lxd
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	lxd "github.com/lxc/lxd/client"
+)
+
+func resourceLxdVolumeAttach() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceLxdVolumeAttachCreate,
+		Delete: resourceLxdVolumeAttachDelete,
+		Exists: resourceLxdVolumeAttachExists,
+		Read:   resourceLxdVolumeAttachRead,
+
+		Schema: map[string]*schema.Schema{
+			"pool": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"volume": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"instance": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"path": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"device_name": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+
+			"readonly": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				ForceNew: true,
+			},
+
+			"shift": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				ForceNew: true,
+			},
+
+			"remote": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Default:  "",
+			},
+
+			"project": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+		},
+	}
+}
+
+func resourceLxdVolumeAttachCreate(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	pool := d.Get("pool").(string)
+	volumeName := d.Get("volume").(string)
+	instanceName := d.Get("instance").(string)
+	path := d.Get("path").(string)
+
+	devName := volumeName
+	if v := d.Get("device_name").(string); v != "" {
+		devName = v
+	}
+
+	volume, _, err := server.GetStoragePoolVolume(pool, "custom", volumeName)
+	if err != nil {
+		return fmt.Errorf("Unable to get volume (%s) of pool %s: %s", volumeName, pool, err)
+	}
+
+	var users []string
+	for _, user := range volumeInstances(server, volume.UsedBy) {
+		users = append(users, user.name)
+	}
+
+	// Filesystem volumes are mounted at a path, block ones are passed
+	// to virtual machines as disks.
+	if volume.ContentType == "block" {
+		if path != "" {
+			return fmt.Errorf("Volume (%s) of pool %s is a block volume, it can't be mounted at a path", volumeName, pool)
+		}
+
+		if len(users) > 0 && volume.Config["security.shared"] != "true" {
+			return fmt.Errorf("Block volume (%s) of pool %s is still used by: %s, it needs security.shared to be attached to more instances",
+				volumeName, pool, strings.Join(users, ", "))
+		}
+	} else {
+		if path == "" {
+			return fmt.Errorf("Volume (%s) of pool %s is a filesystem volume, it needs a path", volumeName, pool)
+		}
+
+		// Instances with different idmaps, e.g. isolated containers,
+		// only see the files of the others right with security.shifted.
+		if len(users) > 0 && volume.Config["security.shifted"] != "true" {
+			return fmt.Errorf("Filesystem volume (%s) of pool %s is still used by: %s, it needs security.shifted to be attached to more instances",
+				volumeName, pool, strings.Join(users, ", "))
+		}
+	}
+
+	device := map[string]string{
+		"type":   "disk",
+		"pool":   pool,
+		"source": volumeName,
+	}
+	if path != "" {
+		device["path"] = path
+	}
+	if d.Get("readonly").(bool) {
+		device["readonly"] = "true"
+	}
+	if d.Get("shift").(bool) {
+		device["shift"] = "true"
+	}
+
+	log.Printf("[DEBUG] Attaching volume %s of pool %s to instance %s: %#v", volumeName, pool, instanceName, device)
+	mutex.Lock()
+	err = resourceLxdVolumeAttachUpdateDevice(server, instanceName, devName, device)
+	mutex.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("Unable to attach volume (%s) to instance %s: %s", volumeName, instanceName, err)
+	}
+
+	d.SetId(newVolumeAttachmentID(pool, volumeName, instanceName).String())
+	d.Set("device_name", devName)
+
+	return resourceLxdVolumeAttachRead(d, meta)
+}
+
+func resourceLxdVolumeAttachRead(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	v := newVolumeAttachmentIDFromResourceID(d.Id())
+
+	instance, _, err := server.GetInstance(v.attachedName)
+	if err != nil {
+		return err
+	}
+
+	devName := d.Get("device_name").(string)
+	device, ok := instance.Devices[devName]
+	if !ok || device["pool"] != v.pool || device["source"] != v.volumeName {
+		return fmt.Errorf("Volume (%s) of pool %s isn't attached to instance %s as %s", v.volumeName, v.pool, v.attachedName, devName)
+	}
+
+	log.Printf("[DEBUG] Retrieved device %s of instance %s: %#v", devName, v.attachedName, device)
+
+	d.Set("pool", v.pool)
+	d.Set("volume", v.volumeName)
+	d.Set("instance", v.attachedName)
+	d.Set("path", device["path"])
+	d.Set("readonly", device["readonly"] == "true")
+	d.Set("shift", device["shift"] == "true")
+
+	return nil
+}
+
+func resourceLxdVolumeAttachDelete(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	instanceName := d.Get("instance").(string)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	err = resourceLxdVolumeAttachUpdateDevice(server, instanceName, d.Get("device_name").(string), nil)
+	if err != nil && err.Error() != "not found" {
+		return fmt.Errorf("Unable to detach volume (%s) from instance %s: %s", d.Get("volume").(string), instanceName, err)
+	}
+
+	return nil
+}
+
+func resourceLxdVolumeAttachExists(d *schema.ResourceData, meta interface{}) (exists bool, err error) {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return false, err
+	}
+
+	exists = false
+
+	v := newVolumeAttachmentIDFromResourceID(d.Id())
+	instance, _, err := server.GetInstance(v.attachedName)
+	if err == nil {
+		device, ok := instance.Devices[d.Get("device_name").(string)]
+		exists = ok && device["pool"] == v.pool && device["source"] == v.volumeName
+	}
+
+	return exists, nil
+}
+
+// isAttachedVolume tells whether a device is a custom volume, as attached
+// by lxd_volume_attach. Root disks and other pool disks have no source.
+func isAttachedVolume(device map[string]string) bool {
+	return device["type"] == "disk" && device["pool"] != "" && device["source"] != "" && device["path"] != "/"
+}
+
+// resourceLxdVolumeAttachUpdateDevice sets a device of an instance, or
+// removes it when device is nil, leaving the other devices as they are.
+func resourceLxdVolumeAttachUpdateDevice(server lxd.ContainerServer, instanceName, devName string, device map[string]string) error {
+	instance, etag, err := server.GetInstance(instanceName)
+	if err != nil {
+		return err
+	}
+
+	newInstance := instance.Writable()
+	if device == nil {
+		if _, ok := newInstance.Devices[devName]; !ok {
+			return nil
+		}
+		delete(newInstance.Devices, devName)
+	} else {
+		if _, ok := newInstance.Devices[devName]; ok {
+			return fmt.Errorf("Instance already has a device named %s", devName)
+		}
+		newInstance.Devices[devName] = device
+	}
+
+	op, err := server.UpdateInstance(instanceName, newInstance, etag)
+	if err != nil {
+		return err
+	}
+
+	return op.Wait()
+}
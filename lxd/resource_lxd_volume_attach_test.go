@@ -0,0 +1,210 @@
+package lxd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/dustinkirkland/golang-petname"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/lxc/lxd/shared/api"
+)
+
+func TestAccVolumeAttach_shared(t *testing.T) {
+	var instance1, instance2 api.Instance
+	poolName := strings.ToLower(petname.Generate(2, "-"))
+	volumeName := strings.ToLower(petname.Generate(2, "-"))
+	instanceName1 := strings.ToLower(petname.Generate(2, "-"))
+	instanceName2 := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccVolumeAttach_shared(poolName, volumeName, instanceName1, instanceName2, true),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("lxd_volume_attach.attach1", "device_name", volumeName),
+					resource.TestCheckResourceAttr("lxd_volume_attach.attach2", "device_name", "shared"),
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance1),
+					testAccInstanceRunning(t, "lxd_instance.instance2", &instance2),
+					testAccInstanceDevice(&instance1, volumeName, "path", "/mnt/shared"),
+					testAccInstanceDevice(&instance2, "shared", "readonly", "true"),
+				),
+			},
+			resource.TestStep{
+				// Detaching the volume from an instance leaves the
+				// instance and the other attachment as they are.
+				Config: testAccVolumeAttach_shared(poolName, volumeName, instanceName1, instanceName2, false),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance1),
+					testAccInstanceRunning(t, "lxd_instance.instance2", &instance2),
+					testAccInstanceDevice(&instance1, volumeName, "path", "/mnt/shared"),
+					testAccInstanceNoDevice(&instance2, "shared"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccVolumeAttach_validation(t *testing.T) {
+	poolName := strings.ToLower(petname.Generate(2, "-"))
+	volumeName := strings.ToLower(petname.Generate(2, "-"))
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccVolumeAttach_noPath(poolName, volumeName, instanceName),
+				ExpectError: regexp.MustCompile("is a filesystem volume, it needs a path"),
+			},
+		},
+	})
+}
+
+func TestAccVolumeAttach_notShifted(t *testing.T) {
+	poolName := strings.ToLower(petname.Generate(2, "-"))
+	volumeName := strings.ToLower(petname.Generate(2, "-"))
+	instanceName1 := strings.ToLower(petname.Generate(2, "-"))
+	instanceName2 := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccVolumeAttach_notShifted(poolName, volumeName, instanceName1, instanceName2),
+				ExpectError: regexp.MustCompile("it needs security.shifted to be attached to more instances"),
+			},
+		},
+	})
+}
+
+func testAccVolumeAttach_instances(poolName, volumeName, instanceName1, instanceName2 string) string {
+	return fmt.Sprintf(`
+resource "lxd_storage_pool" "pool1" {
+  name   = "%s"
+  driver = "dir"
+  config {
+    source = "/tmp/foo"
+  }
+}
+
+resource "lxd_volume" "volume1" {
+  name = "%s"
+  pool = "${lxd_storage_pool.pool1.name}"
+  config {
+    security.shifted = "true"
+  }
+}
+
+resource "lxd_instance" "instance1" {
+  name  = "%s"
+  image = "images:alpine/3.9/amd64"
+}
+
+resource "lxd_instance" "instance2" {
+  name  = "%s"
+  image = "images:alpine/3.9/amd64"
+}
+`, poolName, volumeName, instanceName1, instanceName2)
+}
+
+func testAccVolumeAttach_shared(poolName, volumeName, instanceName1, instanceName2 string, both bool) string {
+	config := fmt.Sprintf(`%s
+resource "lxd_volume_attach" "attach1" {
+  pool     = "${lxd_volume.volume1.pool}"
+  volume   = "${lxd_volume.volume1.name}"
+  instance = "${lxd_instance.instance1.name}"
+  path     = "/mnt/shared"
+}
+`, testAccVolumeAttach_instances(poolName, volumeName, instanceName1, instanceName2))
+
+	if both {
+		config += `
+resource "lxd_volume_attach" "attach2" {
+  pool        = "${lxd_volume.volume1.pool}"
+  volume      = "${lxd_volume.volume1.name}"
+  instance    = "${lxd_instance.instance2.name}"
+  path        = "/mnt/shared"
+  device_name = "shared"
+  readonly    = true
+}
+`
+	}
+
+	return config
+}
+
+func testAccVolumeAttach_noPath(poolName, volumeName, instanceName string) string {
+	return fmt.Sprintf(`
+resource "lxd_storage_pool" "pool1" {
+  name   = "%s"
+  driver = "dir"
+  config {
+    source = "/tmp/foo"
+  }
+}
+
+resource "lxd_volume" "volume1" {
+  name = "%s"
+  pool = "${lxd_storage_pool.pool1.name}"
+}
+
+resource "lxd_instance" "instance1" {
+  name  = "%s"
+  image = "images:alpine/3.9/amd64"
+}
+
+resource "lxd_volume_attach" "attach1" {
+  pool     = "${lxd_volume.volume1.pool}"
+  volume   = "${lxd_volume.volume1.name}"
+  instance = "${lxd_instance.instance1.name}"
+}
+`, poolName, volumeName, instanceName)
+}
+
+func testAccVolumeAttach_notShifted(poolName, volumeName, instanceName1, instanceName2 string) string {
+	return fmt.Sprintf(`
+resource "lxd_storage_pool" "pool1" {
+  name   = "%s"
+  driver = "dir"
+  config {
+    source = "/tmp/foo"
+  }
+}
+
+resource "lxd_volume" "volume1" {
+  name = "%s"
+  pool = "${lxd_storage_pool.pool1.name}"
+}
+
+resource "lxd_instance" "instance1" {
+  name  = "%s"
+  image = "images:alpine/3.9/amd64"
+}
+
+resource "lxd_instance" "instance2" {
+  name  = "%s"
+  image = "images:alpine/3.9/amd64"
+}
+
+resource "lxd_volume_attach" "attach1" {
+  pool     = "${lxd_volume.volume1.pool}"
+  volume   = "${lxd_volume.volume1.name}"
+  instance = "${lxd_instance.instance1.name}"
+  path     = "/mnt/shared"
+}
+
+resource "lxd_volume_attach" "attach2" {
+  pool       = "${lxd_volume.volume1.pool}"
+  volume     = "${lxd_volume.volume1.name}"
+  instance   = "${lxd_instance.instance2.name}"
+  path       = "/mnt/shared"
+  depends_on = ["lxd_volume_attach.attach1"]
+}
+`, poolName, volumeName, instanceName1, instanceName2)
+}
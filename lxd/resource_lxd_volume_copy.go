@@ -0,0 +1,261 @@
+package lxd
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	lxd "github.com/lxc/lxd/client"
+)
+
+func resourceLxdVolumeCopy() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceLxdVolumeCopyCreate,
+		Delete: resourceLxdVolumeCopyDelete,
+		Exists: resourceLxdVolumeCopyExists,
+		Read:   resourceLxdVolumeCopyRead,
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"pool": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"source_pool": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"source_volume": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"source_remote": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"source_project": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"target": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"volume_only": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				ForceNew: true,
+			},
+
+			"move": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				ForceNew: true,
+			},
+
+			"mode": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "pull",
+				ForceNew:     true,
+				ValidateFunc: resourceLxdValidateCopyMode,
+			},
+
+			"remote": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Default:  "",
+			},
+
+			"project": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"expanded_config": {
+				Type:     schema.TypeMap,
+				Computed: true,
+			},
+
+			"location": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceLxdVolumeCopyCreate(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	remote := p.selectRemote(d)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	name := d.Get("name").(string)
+	pool := d.Get("pool").(string)
+	srcPool := d.Get("source_pool").(string)
+	srcName := d.Get("source_volume").(string)
+
+	srcServer, err := resourceLxdVolumeCopySourceServer(d, p, remote)
+	if err != nil {
+		return err
+	}
+
+	srcVolume, _, err := srcServer.GetStoragePoolVolume(srcPool, "custom", srcName)
+	if err != nil {
+		return fmt.Errorf("Unable to get source volume (%s) of pool %s: %s", srcName, srcPool, err)
+	}
+
+	// A volume in use can't be moved from under its users.
+	move := d.Get("move").(bool)
+	if move && len(srcVolume.UsedBy) > 0 {
+		return fmt.Errorf("Source volume (%s) of pool %s can't be moved, it is still used by: %s",
+			srcName, srcPool, strings.Join(srcVolume.UsedBy, ", "))
+	}
+
+	dstServer := resourceLxdVolumeCopyServer(d, server)
+
+	args := lxd.StoragePoolVolumeCopyArgs{
+		Name:       name,
+		Mode:       d.Get("mode").(string),
+		VolumeOnly: d.Get("volume_only").(bool),
+	}
+
+	// Moves are left to LXD, which renames the volume into the other pool
+	// when it can, and copies it then deletes the source otherwise.
+	mutex.Lock()
+	var op lxd.RemoteOperation
+	if move {
+		log.Printf("[DEBUG] Moving volume %s of pool %s to %s of pool %s", srcName, srcPool, name, pool)
+		op, err = dstServer.MoveStoragePoolVolume(pool, srcServer, srcPool, *srcVolume, &lxd.StoragePoolVolumeMoveArgs{StoragePoolVolumeCopyArgs: args})
+	} else {
+		log.Printf("[DEBUG] Copying volume %s of pool %s to %s of pool %s", srcName, srcPool, name, pool)
+		op, err = dstServer.CopyStoragePoolVolume(pool, srcServer, srcPool, *srcVolume, &args)
+	}
+	mutex.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("Unable to copy volume (%s) of pool %s: %s", srcName, srcPool, err)
+	}
+
+	if err := op.Wait(); err != nil {
+		return fmt.Errorf("Error waiting for volume (%s) of pool %s to be copied: %s", srcName, srcPool, err)
+	}
+
+	d.SetId(newVolumeID(pool, name, "custom").String())
+
+	return resourceLxdVolumeCopyRead(d, meta)
+}
+
+func resourceLxdVolumeCopyRead(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	v := newVolumeIDFromResourceID(d.Id())
+	volume, _, err := resourceLxdVolumeCopyServer(d, server).GetStoragePoolVolume(v.pool, v.volType, v.name)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[DEBUG] Retrieved volume %s: %#v", v.name, volume)
+
+	d.Set("expanded_config", volume.Config)
+	d.Set("location", volume.Location)
+
+	return nil
+}
+
+// resourceLxdVolumeCopyDelete deletes the copy of the volume, the source
+// volume is left as it is.
+func resourceLxdVolumeCopyDelete(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	v := newVolumeIDFromResourceID(d.Id())
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	return resourceLxdVolumeCopyServer(d, server).DeleteStoragePoolVolume(v.pool, v.volType, v.name)
+}
+
+func resourceLxdVolumeCopyExists(d *schema.ResourceData, meta interface{}) (exists bool, err error) {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return false, err
+	}
+
+	exists = false
+
+	v := newVolumeIDFromResourceID(d.Id())
+	if _, _, err := resourceLxdVolumeCopyServer(d, server).GetStoragePoolVolume(v.pool, v.volType, v.name); err == nil {
+		exists = true
+	}
+
+	return
+}
+
+// resourceLxdVolumeCopyServer returns the client of the cluster member
+// the copy of the volume is on, when one is targeted.
+func resourceLxdVolumeCopyServer(d *schema.ResourceData, server lxd.ContainerServer) lxd.ContainerServer {
+	if target := d.Get("target").(string); target != "" {
+		return server.UseTarget(target)
+	}
+
+	return server
+}
+
+// resourceLxdVolumeCopySourceServer returns the client of the source
+// volume. Sources on the same remote are looked up in the same project,
+// unless told otherwise.
+func resourceLxdVolumeCopySourceServer(d *schema.ResourceData, p *lxdProvider, remote string) (lxd.ContainerServer, error) {
+	srcRemote := d.Get("source_remote").(string)
+	if srcRemote == "" {
+		srcRemote = remote
+	}
+
+	srcServer, err := p.GetContainerServer(srcRemote)
+	if err != nil {
+		return nil, err
+	}
+
+	project := d.Get("project").(string)
+	if srcProject := d.Get("source_project").(string); srcProject != "" {
+		srcServer = srcServer.UseProject(srcProject)
+	} else if project != "" && srcRemote == remote {
+		srcServer = srcServer.UseProject(project)
+	}
+
+	return srcServer, nil
+}
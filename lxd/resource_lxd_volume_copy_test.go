@@ -0,0 +1,144 @@
+package lxd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dustinkirkland/golang-petname"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/lxc/lxd/shared/api"
+)
+
+func TestAccVolumeCopy_basic(t *testing.T) {
+	var volume api.StorageVolume
+	poolName := strings.ToLower(petname.Generate(2, "-"))
+	volumeName := strings.ToLower(petname.Generate(2, "-"))
+	copyPoolName := strings.ToLower(petname.Generate(2, "-"))
+	copyName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccVolumeCopy_basic(poolName, volumeName, copyPoolName, copyName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccVolumeExists(t, "lxd_volume.volume1", &volume),
+					testAccVolumeExists(t, "lxd_volume_copy.copy1", &volume),
+					resource.TestCheckResourceAttr("lxd_volume_copy.copy1", "name", copyName),
+					resource.TestCheckResourceAttr("lxd_volume_copy.copy1", "pool", copyPoolName),
+				),
+			},
+		},
+	})
+}
+
+func TestAccVolumeCopy_move(t *testing.T) {
+	var volume api.StorageVolume
+	poolName := strings.ToLower(petname.Generate(2, "-"))
+	volumeName := strings.ToLower(petname.Generate(2, "-"))
+	copyPoolName := strings.ToLower(petname.Generate(2, "-"))
+	copyName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				// The moved volume isn't managed by Terraform.
+				Config: testAccVolumeCopy_pools(poolName, copyPoolName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccVolumeCopyCreateSource(t, poolName, volumeName),
+				),
+			},
+			resource.TestStep{
+				Config: testAccVolumeCopy_move(poolName, volumeName, copyPoolName, copyName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccVolumeExists(t, "lxd_volume_copy.move1", &volume),
+					testAccVolumeCopyGone(t, poolName, volumeName),
+				),
+			},
+		},
+	})
+}
+
+// testAccVolumeCopyCreateSource creates a volume outside of Terraform, for
+// a later step to move.
+func testAccVolumeCopyCreateSource(t *testing.T, pool, name string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		client, err := testAccProvider.Meta().(*lxdProvider).GetContainerServer("")
+		if err != nil {
+			return err
+		}
+
+		return client.CreateStoragePoolVolume(pool, api.StorageVolumesPost{Name: name, Type: "custom"})
+	}
+}
+
+func testAccVolumeCopyGone(t *testing.T, pool, name string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		client, err := testAccProvider.Meta().(*lxdProvider).GetContainerServer("")
+		if err != nil {
+			return err
+		}
+
+		if _, _, err := client.GetStoragePoolVolume(pool, "custom", name); err == nil {
+			return fmt.Errorf("Volume %s of pool %s still exists", name, pool)
+		}
+
+		return nil
+	}
+}
+
+func testAccVolumeCopy_basic(poolName, volumeName, copyPoolName, copyName string) string {
+	return fmt.Sprintf(`%s
+resource "lxd_storage_pool" "pool2" {
+  name   = "%s"
+  driver = "dir"
+  config {
+    source = "/tmp/bar"
+  }
+}
+
+resource "lxd_volume_copy" "copy1" {
+  name          = "%s"
+  pool          = "${lxd_storage_pool.pool2.name}"
+  source_pool   = "${lxd_storage_pool.pool1.name}"
+  source_volume = "${lxd_volume.volume1.name}"
+}
+`, testAccVolume_basic(poolName, volumeName), copyPoolName, copyName)
+}
+
+func testAccVolumeCopy_pools(poolName, copyPoolName string) string {
+	return fmt.Sprintf(`
+resource "lxd_storage_pool" "pool1" {
+  name   = "%s"
+  driver = "dir"
+  config {
+    source = "/tmp/foo"
+  }
+}
+
+resource "lxd_storage_pool" "pool2" {
+  name   = "%s"
+  driver = "dir"
+  config {
+    source = "/tmp/bar"
+  }
+}
+`, poolName, copyPoolName)
+}
+
+func testAccVolumeCopy_move(poolName, volumeName, copyPoolName, copyName string) string {
+	return fmt.Sprintf(`%s
+resource "lxd_volume_copy" "move1" {
+  name          = "%s"
+  pool          = "${lxd_storage_pool.pool2.name}"
+  source_pool   = "${lxd_storage_pool.pool1.name}"
+  source_volume = "%s"
+  move          = true
+}
+`, testAccVolumeCopy_pools(poolName, copyPoolName), copyName, volumeName)
+}
@@ -0,0 +1,199 @@
+package lxd
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	lxd "github.com/lxc/lxd/client"
+)
+
+func resourceLxdVolumeRestore() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceLxdVolumeRestoreCreate,
+		Delete: resourceLxdVolumeRestoreDelete,
+		Read:   resourceLxdVolumeRestoreRead,
+
+		Schema: map[string]*schema.Schema{
+			"pool": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"volume": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"snapshot": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"triggers": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"remote": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Default:  "",
+			},
+
+			"project": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"restored_at": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceLxdVolumeRestoreCreate(d *schema.ResourceData, meta interface{}) (err error) {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	pool := d.Get("pool").(string)
+	name := d.Get("volume").(string)
+	snapshot := d.Get("snapshot").(string)
+
+	volume, etag, err := server.GetStoragePoolVolume(pool, "custom", name)
+	if err != nil {
+		return err
+	}
+
+	// LXD doesn't restore a volume the running instances it is attached
+	// to are using. They are stopped for the restore, and started again
+	// afterwards whatever happens, along with why they failed to.
+	var stopped []volumeInstance
+	defer func() {
+		var failed []string
+		for _, instance := range stopped {
+			if startErr := resourceLxdInstanceSetState(instance.server, instance.name, "start", false, p.RefreshInterval); startErr != nil {
+				failed = append(failed, fmt.Sprintf("%s: %s", instance.name, startErr))
+			}
+		}
+
+		if len(failed) == 0 {
+			return
+		}
+
+		startErr := fmt.Errorf("Error restarting instances using volume (%s) of pool %s: %s", name, pool, strings.Join(failed, "; "))
+		if err != nil {
+			err = fmt.Errorf("%s (%s)", err, startErr)
+		} else {
+			err = startErr
+		}
+	}()
+
+	for _, instance := range volumeInstances(server, volume.UsedBy) {
+		st, _, err := instance.server.GetInstanceState(instance.name)
+		if err != nil {
+			return err
+		}
+
+		if st.Status != "Running" {
+			continue
+		}
+
+		if err := resourceLxdInstanceSetState(instance.server, instance.name, "stop", false, p.RefreshInterval); err != nil {
+			return err
+		}
+		stopped = append(stopped, instance)
+	}
+
+	req := volume.Writable()
+	req.Restore = snapshot
+
+	log.Printf("[DEBUG] Restoring volume %s of pool %s from snapshot %s", name, pool, snapshot)
+	mutex.Lock()
+	err = server.UpdateStoragePoolVolume(pool, "custom", name, req, etag)
+	mutex.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("Error restoring volume (%s) of pool %s from snapshot %s: %s", name, pool, snapshot, err)
+	}
+
+	d.SetId(fmt.Sprintf("%s/%s/%s", pool, name, snapshot))
+	d.Set("restored_at", time.Now().UTC().Format(time.RFC3339))
+
+	return resourceLxdVolumeRestoreRead(d, meta)
+}
+
+// resourceLxdVolumeRestoreRead only checks that the volume still
+// exists. The restore is done, even once the snapshot is deleted.
+func resourceLxdVolumeRestoreRead(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	if _, _, err := server.GetStoragePoolVolume(d.Get("pool").(string), "custom", d.Get("volume").(string)); err != nil {
+		if err.Error() == "not found" {
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
+
+// resourceLxdVolumeRestoreDelete only removes the restore from the
+// state, the volume is left as it is.
+func resourceLxdVolumeRestoreDelete(d *schema.ResourceData, meta interface{}) error {
+	d.SetId("")
+	return nil
+}
+
+// volumeInstance is an instance a volume is attached to, along with the
+// client of its project.
+type volumeInstance struct {
+	server lxd.ContainerServer
+	name   string
+}
+
+// volumeInstances returns the instances among the users of a volume, as
+// listed by its used_by.
+func volumeInstances(server lxd.ContainerServer, usedBy []string) []volumeInstance {
+	var instances []volumeInstance
+	for _, entry := range usedBy {
+		u, err := url.Parse(entry)
+		if err != nil {
+			continue
+		}
+
+		switch path.Base(path.Dir(u.Path)) {
+		case "instances", "containers", "virtual-machines":
+		default:
+			continue
+		}
+
+		instanceServer := server
+		if project := u.Query().Get("project"); project != "" {
+			instanceServer = server.UseProject(project)
+		}
+		instances = append(instances, volumeInstance{server: instanceServer, name: path.Base(u.Path)})
+	}
+
+	return instances
+}
@@ -0,0 +1,78 @@
+package lxd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dustinkirkland/golang-petname"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/lxc/lxd/shared/api"
+)
+
+func TestAccVolumeRestore_basic(t *testing.T) {
+	var container api.Container
+	poolName := strings.ToLower(petname.Generate(2, "-"))
+	volumeName := strings.ToLower(petname.Generate(2, "-"))
+	containerName := strings.ToLower(petname.Generate(2, "-"))
+	snapshotName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccVolume_containerAttach(poolName, volumeName, containerName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccContainerRunning(t, "lxd_container.container1", &container),
+					testAccVolumeSnapshot(t, "lxd_volume.volume1", snapshotName),
+				),
+			},
+			resource.TestStep{
+				// The container using the volume is stopped for the
+				// restore, and started again afterwards.
+				Config: testAccVolumeRestore_basic(poolName, volumeName, containerName, snapshotName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccContainerRunning(t, "lxd_container.container1", &container),
+					resource.TestCheckResourceAttr("lxd_volume_restore.restore1", "snapshot", snapshotName),
+					resource.TestCheckResourceAttrSet("lxd_volume_restore.restore1", "restored_at"),
+				),
+			},
+		},
+	})
+}
+
+// testAccVolumeSnapshot takes a snapshot of a volume, for a later step
+// to restore.
+func testAccVolumeSnapshot(t *testing.T, n, snapshot string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		client, err := testAccProvider.Meta().(*lxdProvider).GetContainerServer("")
+		if err != nil {
+			return err
+		}
+
+		v := newVolumeIDFromResourceID(rs.Primary.ID)
+		op, err := client.CreateStoragePoolVolumeSnapshot(v.pool, v.volType, v.name, api.StorageVolumeSnapshotsPost{Name: snapshot})
+		if err != nil {
+			return err
+		}
+
+		return op.Wait()
+	}
+}
+
+func testAccVolumeRestore_basic(poolName, volumeName, containerName, snapshotName string) string {
+	return fmt.Sprintf(`%s
+resource "lxd_volume_restore" "restore1" {
+  pool     = "${lxd_storage_pool.pool1.name}"
+  volume   = "${lxd_volume.volume1.name}"
+  snapshot = "%s"
+}
+`, testAccVolume_containerAttach(poolName, volumeName, containerName), snapshotName)
+}
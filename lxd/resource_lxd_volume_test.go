@@ -1,7 +1,12 @@
 package lxd
 
 import (
+	"archive/tar"
+	"compress/gzip"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
 	"strings"
 	"testing"
 
@@ -10,6 +15,7 @@ import (
 	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/terraform"
 
+	lxd "github.com/lxc/lxd/client"
 	"github.com/lxc/lxd/shared/api"
 )
 
@@ -54,6 +60,245 @@ func TestAccVolume_containerAttach(t *testing.T) {
 	})
 }
 
+func TestAccVolume_project(t *testing.T) {
+	poolName := strings.ToLower(petname.Generate(2, "-"))
+	volumeName := strings.ToLower(petname.Generate(2, "-"))
+
+	// The project must have its own volumes.
+	project := os.Getenv("LXD_TEST_PROJECT")
+	if project == "" {
+		t.Skip("LXD_TEST_PROJECT must name a project with features.storage.volumes")
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccVolume_project(poolName, volumeName, project),
+				Check: resource.ComposeTestCheckFunc(
+					testAccVolumeInProject("lxd_volume.volume1", project),
+					resource.TestCheckResourceAttr("lxd_volume.volume1", "project", project),
+				),
+			},
+		},
+	})
+}
+
+func TestAccVolume_sourceTarball(t *testing.T) {
+	var instance api.Instance
+	poolName := strings.ToLower(petname.Generate(2, "-"))
+	volumeName := strings.ToLower(petname.Generate(2, "-"))
+	instanceName := strings.ToLower(petname.Generate(2, "-"))
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "lxd-volume-tarball")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	path := tmpDir + "/" + volumeName + ".tar.gz"
+	if err := testAccVolumeWriteTarball(path, "data/hello.txt", "Hello, World!\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccVolume_sourceTarball(poolName, volumeName, instanceName, path),
+				Check: resource.ComposeTestCheckFunc(
+					testAccInstanceRunning(t, "lxd_instance.instance1", &instance),
+					testAccInstanceFileContent(&instance, "/mnt/volume/data/hello.txt", "Hello, World!\n"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccVolume_sourceBackup(t *testing.T) {
+	var volume api.StorageVolume
+	poolName := strings.ToLower(petname.Generate(2, "-"))
+	volumeName := strings.ToLower(petname.Generate(2, "-"))
+	restoredName := strings.ToLower(petname.Generate(2, "-"))
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "lxd-volume-backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	path := tmpDir + "/" + volumeName + ".tar.gz"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccVolume_backedUp(poolName, volumeName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccVolumeExportBackup(t, "lxd_volume.volume1", path),
+				),
+			},
+			resource.TestStep{
+				// The keys of the backup are kept, without showing in
+				// config nor in the plan.
+				Config: testAccVolume_sourceBackup(poolName, volumeName, restoredName, path),
+				Check: resource.ComposeTestCheckFunc(
+					testAccVolumeExists(t, "lxd_volume.volume2", &volume),
+					testAccVolumeConfig(&volume, "user.origin", "backup"),
+					testAccVolumeConfig(&volume, "user.restored", "true"),
+					resource.TestCheckResourceAttr("lxd_volume.volume2", "config.%", "1"),
+					resource.TestCheckResourceAttr("lxd_volume.volume2", "expanded_config.user.origin", "backup"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccVolume_sourceISO(t *testing.T) {
+	var volume api.StorageVolume
+	poolName := strings.ToLower(petname.Generate(2, "-"))
+	volumeName := strings.ToLower(petname.Generate(2, "-"))
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "lxd-volume-iso")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// LXD stores the image as is, its content doesn't matter.
+	path := tmpDir + "/" + volumeName + ".iso"
+	if err := ioutil.WriteFile(path, make([]byte, 1024*1024), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccVolume_sourceISO(poolName, volumeName, path),
+				Check: resource.ComposeTestCheckFunc(
+					testAccVolumeExists(t, "lxd_volume.volume1", &volume),
+					testAccVolumeContentType(&volume, "iso"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccVolume_sourceValidation(t *testing.T) {
+	poolName := strings.ToLower(petname.Generate(2, "-"))
+	volumeName := strings.ToLower(petname.Generate(2, "-"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccVolume_sourceType(poolName, volumeName),
+				ExpectError: regexp.MustCompile("source_tarball is only supported by custom volumes"),
+			},
+		},
+	})
+}
+
+// testAccVolumeWriteTarball writes a gzipped tarball holding a single
+// file, along with its directory.
+func testAccVolumeWriteTarball(path, name, content string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	dir := name[:strings.LastIndex(name, "/")+1]
+	if err := tw.WriteHeader(&tar.Header{Name: dir, Typeflag: tar.TypeDir, Mode: 0755}); err != nil {
+		return err
+	}
+
+	if err := tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(content))}); err != nil {
+		return err
+	}
+	if _, err := tw.Write([]byte(content)); err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	return gz.Close()
+}
+
+// testAccVolumeExportBackup exports a backup of a volume to a file, for
+// a later step to restore.
+func testAccVolumeExportBackup(t *testing.T, n, path string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		client, err := testAccProvider.Meta().(*lxdProvider).GetContainerServer("")
+		if err != nil {
+			return err
+		}
+
+		v := newVolumeIDFromResourceID(rs.Primary.ID)
+		op, err := client.CreateStoragePoolVolumeBackup(v.pool, v.name, api.StoragePoolVolumeBackupsPost{Name: "export"})
+		if err != nil {
+			return err
+		}
+		if err := op.Wait(); err != nil {
+			return err
+		}
+
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = client.GetStoragePoolVolumeBackupFile(v.pool, v.name, "export", &lxd.BackupFileRequest{BackupFile: f})
+		return err
+	}
+}
+
+func testAccVolumeContentType(volume *api.StorageVolume, contentType string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		if volume.ContentType != contentType {
+			return fmt.Errorf("Bad content type: %s", volume.ContentType)
+		}
+
+		return nil
+	}
+}
+
+func testAccVolumeInProject(n, project string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		v := newVolumeIDFromResourceID(rs.Primary.ID)
+		client, err := testAccProvider.Meta().(*lxdProvider).GetContainerServer("")
+		if err != nil {
+			return err
+		}
+
+		if _, _, err := client.UseProject(project).GetStoragePoolVolume(v.pool, v.volType, v.name); err != nil {
+			return fmt.Errorf("Volume %s not found in project %s: %s", v.name, project, err)
+		}
+
+		return nil
+	}
+}
+
 func testAccVolumeExists(t *testing.T, n string, volume *api.StorageVolume) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rs, ok := s.RootModule().Resources[n]
@@ -152,3 +397,125 @@ resource "lxd_container" "container1" {
 }
 	`, poolName, volumeName, containerName)
 }
+
+func testAccVolume_project(poolName, volumeName, project string) string {
+	return fmt.Sprintf(`
+resource "lxd_storage_pool" "pool1" {
+  name   = "%s"
+  driver = "dir"
+
+  config {
+    source = "/tmp/foo"
+  }
+}
+
+resource "lxd_volume" "volume1" {
+  name    = "%s"
+  pool    = "${lxd_storage_pool.pool1.name}"
+  project = "%s"
+}
+	`, poolName, volumeName, project)
+}
+
+func testAccVolume_sourceTarball(poolName, volumeName, instanceName, tarball string) string {
+	return fmt.Sprintf(`
+resource "lxd_storage_pool" "pool1" {
+  name   = "%s"
+  driver = "dir"
+  config {
+    source = "/tmp/foo"
+  }
+}
+
+resource "lxd_volume" "volume1" {
+  name           = "%s"
+  pool           = "${lxd_storage_pool.pool1.name}"
+  source_tarball = "%s"
+}
+
+resource "lxd_instance" "instance1" {
+  name  = "%s"
+  image = "images:alpine/3.9/amd64"
+
+  device {
+    name = "volume1"
+    type = "disk"
+    properties {
+      path   = "/mnt/volume"
+      source = "${lxd_volume.volume1.name}"
+      pool   = "${lxd_storage_pool.pool1.name}"
+    }
+  }
+}
+`, poolName, volumeName, tarball, instanceName)
+}
+
+func testAccVolume_sourceType(poolName, volumeName string) string {
+	return fmt.Sprintf(`
+resource "lxd_storage_pool" "pool1" {
+  name   = "%s"
+  driver = "dir"
+  config {
+    source = "/tmp/foo"
+  }
+}
+
+resource "lxd_volume" "volume1" {
+  name           = "%s"
+  pool           = "${lxd_storage_pool.pool1.name}"
+  type           = "virtual-machine"
+  source_tarball = "/tmp/volume.tar"
+}
+`, poolName, volumeName)
+}
+
+func testAccVolume_backedUp(poolName, volumeName string) string {
+	return fmt.Sprintf(`
+resource "lxd_storage_pool" "pool1" {
+  name   = "%s"
+  driver = "dir"
+  config {
+    source = "/tmp/foo"
+  }
+}
+
+resource "lxd_volume" "volume1" {
+  name = "%s"
+  pool = "${lxd_storage_pool.pool1.name}"
+  config {
+    user.origin = "backup"
+  }
+}
+`, poolName, volumeName)
+}
+
+func testAccVolume_sourceBackup(poolName, volumeName, restoredName, backup string) string {
+	return fmt.Sprintf(`%s
+resource "lxd_volume" "volume2" {
+  name          = "%s"
+  pool          = "${lxd_storage_pool.pool1.name}"
+  source_backup = "%s"
+  config {
+    user.restored = "true"
+  }
+}
+`, testAccVolume_backedUp(poolName, volumeName), restoredName, backup)
+}
+
+func testAccVolume_sourceISO(poolName, volumeName, iso string) string {
+	return fmt.Sprintf(`
+resource "lxd_storage_pool" "pool1" {
+  name   = "%s"
+  driver = "dir"
+  config {
+    source = "/tmp/foo"
+  }
+}
+
+resource "lxd_volume" "volume1" {
+  name       = "%s"
+  pool       = "${lxd_storage_pool.pool1.name}"
+  source_iso = "%s"
+}
+`, poolName, volumeName, iso)
+}
@@ -1,16 +1,24 @@
 package lxd
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
+	"net"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/terraform/helper/schema"
 	lxd "github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/osarch"
 	"github.com/mitchellh/go-homedir"
 )
 
@@ -141,9 +149,862 @@ func resourceLxdDevices(d interface{}) map[string]map[string]string {
 	return devices
 }
 
+// resourceLxdImageSetAlias points an image alias at the given
+// fingerprint with the given description, creating the alias if it
+// doesn't exist yet.
+func resourceLxdImageSetAlias(server lxd.ContainerServer, alias, fingerprint, description string) error {
+	_, etag, err := server.GetImageAlias(alias)
+	if err == nil {
+		put := api.ImageAliasesEntryPut{
+			Description: description,
+			Target:      fingerprint,
+		}
+
+		log.Printf("[DEBUG] Re-pointing image alias %s to %s", alias, fingerprint)
+		return server.UpdateImageAlias(alias, put, etag)
+	}
+
+	req := api.ImageAliasesPost{}
+	req.Name = alias
+	req.Description = description
+	req.Target = fingerprint
+
+	return server.CreateImageAlias(req)
+}
+
+// resourceLxdImageProfiles returns the profiles configured for an image.
+// LXD assigns the default profile when none are set.
+func resourceLxdImageProfiles(d *schema.ResourceData) []string {
+	var profiles []string
+	for _, v := range d.Get("profiles").([]interface{}) {
+		profiles = append(profiles, v.(string))
+	}
+	return profiles
+}
+
+// resourceLxdImageExpiresAt returns the configured expiry date of an image.
+// The zero time is returned when none is configured.
+func resourceLxdImageExpiresAt(d *schema.ResourceData) time.Time {
+	// The value has been validated by resourceLxdValidateTimestamp.
+	t, _ := time.Parse(time.RFC3339, d.Get("expires_at").(string))
+	return t
+}
+
+// resourceLxdImageFormatTime formats an image timestamp for the state.
+// LXD reports unset timestamps as the zero time.
+func resourceLxdImageFormatTime(t time.Time) string {
+	if t.IsZero() || t.Unix() <= 0 {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// resourceLxdImageUpdate applies a change to the writable
+// attributes of an existing image.
+func resourceLxdImageUpdate(server lxd.ContainerServer, fingerprint string, update func(*api.ImagePut)) error {
+	img, etag, err := server.GetImage(fingerprint)
+	if err != nil {
+		return err
+	}
+
+	put := img.Writable()
+	update(&put)
+
+	log.Printf("[DEBUG] Updating image %s: %#v", fingerprint, put)
+	return server.UpdateImage(fingerprint, put, etag)
+}
+
+// resourceLxdImageNewAliases returns the aliases of an image about to be
+// imported, making sure none of them is already taken on the server.
+func resourceLxdImageNewAliases(d *schema.ResourceData, server lxd.ContainerServer) ([]api.ImageAlias, error) {
+	aliases := make([]api.ImageAlias, 0)
+	if v, ok := d.GetOk("aliases"); ok {
+		for _, alias := range v.([]interface{}) {
+			// Check image alias doesn't already exist on destination
+			dstAliasTarget, _, _ := server.GetImageAlias(alias.(string))
+			if dstAliasTarget != nil {
+				return nil, fmt.Errorf("Image alias already exists on destination: %s", alias.(string))
+			}
+
+			ia := api.ImageAlias{
+				Name: alias.(string),
+			}
+
+			aliases = append(aliases, ia)
+		}
+	}
+
+	return aliases, nil
+}
+
+// resourceLxdLocalImageRead reads an image that was imported to or
+// published on a remote, as the image resources other than
+// lxd_cached_image track them.
+func resourceLxdLocalImageRead(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	img, _, err := server.GetImage(d.Id())
+	if err != nil {
+		if err.Error() == "not found" {
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	log.Printf("[DEBUG] Retrieved image %s: %#v", d.Id(), img)
+
+	d.Set("fingerprint", img.Fingerprint)
+	d.Set("architecture", img.Architecture)
+	d.Set("created_at", img.CreatedAt.Unix())
+	d.Set("public", img.Public)
+	d.Set("profiles", img.Profiles)
+	d.Set("expires_at", resourceLxdImageFormatTime(img.ExpiresAt))
+
+	// Image metadata, and LXD when publishing, add properties of their
+	// own, so only track the properties that were configured.
+	properties := make(map[string]string)
+	for k := range d.Get("properties").(map[string]interface{}) {
+		if v, ok := img.Properties[k]; ok {
+			properties[k] = v
+		}
+	}
+	d.Set("properties", properties)
+
+	var aliases []string
+	for _, a := range img.Aliases {
+		aliases = append(aliases, a.Name)
+	}
+	d.Set("aliases", aliases)
+
+	return nil
+}
+
+func resourceLxdLocalImageUpdate(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	fingerprint := d.Id()
+
+	if d.HasChange("aliases") {
+		old, new := d.GetChange("aliases")
+		oldSet := schema.NewSet(schema.HashString, old.([]interface{}))
+		newSet := schema.NewSet(schema.HashString, new.([]interface{}))
+
+		for _, a := range oldSet.Difference(newSet).List() {
+			if err := server.DeleteImageAlias(a.(string)); err != nil {
+				return err
+			}
+		}
+
+		for _, a := range newSet.Difference(oldSet).List() {
+			if err := resourceLxdImageSetAlias(server, a.(string), fingerprint, ""); err != nil {
+				return err
+			}
+		}
+	}
+
+	if d.HasChange("public") || d.HasChange("properties") || d.HasChange("profiles") || d.HasChange("expires_at") {
+		err := resourceLxdImageUpdate(server, fingerprint, func(put *api.ImagePut) {
+			put.Public = d.Get("public").(bool)
+			put.Profiles = resourceLxdImageProfiles(d)
+			put.ExpiresAt = resourceLxdImageExpiresAt(d)
+
+			if put.Properties == nil {
+				put.Properties = map[string]string{}
+			}
+
+			old, new := d.GetChange("properties")
+			for k := range old.(map[string]interface{}) {
+				delete(put.Properties, k)
+			}
+			for k, v := range new.(map[string]interface{}) {
+				put.Properties[k] = v.(string)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return resourceLxdLocalImageRead(d, meta)
+}
+
+func resourceLxdLocalImageDelete(d *schema.ResourceData, meta interface{}) error {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return err
+	}
+
+	op, err := server.DeleteImage(d.Id())
+	if err != nil {
+		return err
+	}
+
+	return op.Wait()
+}
+
+func resourceLxdLocalImageExists(d *schema.ResourceData, meta interface{}) (bool, error) {
+	p := meta.(*lxdProvider)
+	server, err := p.selectServer(d)
+	if err != nil {
+		return false, err
+	}
+
+	_, _, err = server.GetImage(d.Id())
+	if err != nil {
+		if err.Error() == "not found" {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// resourceLxdCheckUnused fetches the users of an object about to be deleted
+// and fails naming them, rather than leaving LXD's bare refusal. gone is true
+// when the object no longer exists, in which case there is nothing to delete.
+func resourceLxdCheckUnused(kind, name string, usedBy func() ([]string, error)) (gone bool, err error) {
+	users, err := usedBy()
+	if err != nil {
+		if err.Error() == "not found" {
+			return true, nil
+		}
+		return false, err
+	}
+
+	if len(users) > 0 {
+		return false, fmt.Errorf("%s (%s) is still used by: %s", kind, name, strings.Join(users, ", "))
+	}
+
+	return false, nil
+}
+
+// resourceLxdValidateRegexp validates a regular expression.
+func resourceLxdValidateRegexp(v interface{}, k string) (ws []string, errors []error) {
+	if _, err := regexp.Compile(v.(string)); err != nil {
+		errors = append(errors, fmt.Errorf("%s is not a valid regular expression: %s", k, err))
+	}
+
+	return
+}
+
+// resourceLxdValidateImageType validates the type of an image.
+func resourceLxdValidateImageType(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	if value != "container" && value != "virtual-machine" {
+		errors = append(errors, fmt.Errorf(
+			"Only container and virtual-machine are supported values for '%s'", k))
+	}
+
+	return
+}
+
+// resourceLxdValidateArchitecture validates an architecture name.
+// Both LXD names (x86_64) and common aliases (amd64) are accepted.
+func resourceLxdValidateArchitecture(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	if _, err := osarch.ArchitectureId(value); err != nil {
+		errors = append(errors, fmt.Errorf("Unknown architecture for '%s': %s", k, value))
+	}
+
+	return
+}
+
+// normalizeArchitecture returns the LXD name of an architecture,
+// e.g. x86_64 for amd64. Unknown names are returned as-is.
+func normalizeArchitecture(arch string) string {
+	id, err := osarch.ArchitectureId(arch)
+	if err != nil {
+		return arch
+	}
+
+	name, err := osarch.ArchitectureName(id)
+	if err != nil {
+		return arch
+	}
+
+	return name
+}
+
+// Suppress Diff between aliases of the same architecture
+func suppressArchitectureDifferences(k, old, new string, d *schema.ResourceData) bool {
+	return normalizeArchitecture(old) == normalizeArchitecture(new)
+}
+
+// resourceLxdValidateTimestamp validates an RFC 3339 timestamp.
+func resourceLxdValidateTimestamp(v interface{}, k string) (ws []string, errors []error) {
+	if _, err := time.Parse(time.RFC3339, v.(string)); err != nil {
+		errors = append(errors, fmt.Errorf("%s must be an RFC 3339 timestamp: %s", k, err))
+	}
+	return
+}
+
+// resourceLxdValidateDuration validates a duration, e.g. 30s or 5m.
+func resourceLxdValidateDuration(v interface{}, k string) (ws []string, errors []error) {
+	if _, err := time.ParseDuration(v.(string)); err != nil {
+		errors = append(errors, fmt.Errorf("%s must be a duration such as 30s or 5m: %s", k, err))
+	}
+	return
+}
+
+// suppressTimestampDifferences ignores differences in how
+// the same point in time is written, e.g. time zones.
+func suppressTimestampDifferences(k, old, new string, d *schema.ResourceData) bool {
+	o, err := time.Parse(time.RFC3339, old)
+	if err != nil {
+		return false
+	}
+
+	n, err := time.Parse(time.RFC3339, new)
+	if err != nil {
+		return false
+	}
+
+	return o.Equal(n)
+}
+
+// resourceLxdValidateMAC validates a MAC address.
+func resourceLxdValidateMAC(v interface{}, k string) (ws []string, errors []error) {
+	if _, err := net.ParseMAC(v.(string)); err != nil {
+		errors = append(errors, fmt.Errorf("%s must be a MAC address, got %q", k, v.(string)))
+	}
+	return
+}
+
+// resourceLxdValidateIP validates an IPv4 or IPv6 address.
+func resourceLxdValidateIP(v interface{}, k string) (ws []string, errors []error) {
+	if net.ParseIP(v.(string)) == nil {
+		errors = append(errors, fmt.Errorf("%s must be an IP address, got %q", k, v.(string)))
+	}
+	return
+}
+
+// resourceLxdValidateInterfaceName validates the name of a network
+// interface of a host.
+func resourceLxdValidateInterfaceName(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	if value == "" || len(value) > 15 || strings.ContainsAny(value, ", /") {
+		errors = append(errors, fmt.Errorf("%s must be the name of a network interface, got %q", k, value))
+	}
+	return
+}
+
+// resourceLxdValidateURL validates an http or https URL.
+func resourceLxdValidateURL(v interface{}, k string) (ws []string, errors []error) {
+	u, err := url.Parse(v.(string))
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		errors = append(errors, fmt.Errorf("%s must be an http or https URL, got %q", k, v.(string)))
+	}
+	return
+}
+
+// resourceLxdValidateFingerprint accepts a full or partial image fingerprint.
+func resourceLxdValidateFingerprint(v interface{}, k string) (ws []string, errors []error) {
+	value := strings.ToLower(v.(string))
+	if value == "" || len(value) > 64 || strings.Trim(value, "0123456789abcdef") != "" {
+		errors = append(errors, fmt.Errorf("%s must be a full or partial sha256 fingerprint, got %q", k, v.(string)))
+	}
+	return
+}
+
+// propertyPattern is the format of a property, along
+// with a description of it for error messages.
+type propertyPattern struct {
+	pattern *regexp.Regexp
+	format  string
+}
+
+// deviceRequiredProperties lists the properties each type of device
+// needs. Each entry is a group of properties of which one must be set.
+var deviceRequiredProperties = map[string][][]string{
+	"disk":       {{"source", "pool"}},
+	"nic":        {{"nictype", "network"}},
+	"infiniband": {{"nictype"}, {"parent"}},
+	"proxy":      {{"listen"}, {"connect"}},
+	"unix-char":  {{"source", "path"}},
+	"unix-block": {{"source", "path"}},
+	"usb":        {{"vendorid"}},
+}
+
+// deviceAllowedValues lists the valid values of properties
+// that only take a fixed set of values, per device type.
+var deviceAllowedValues = map[string]map[string][]string{
+	"nic": {
+		"nictype": {"bridged", "macvlan", "p2p", "physical", "sriov", "ipvlan", "routed"},
+	},
+	"infiniband": {
+		"nictype": {"physical", "sriov"},
+	},
+	"gpu": {
+		"gputype": {"physical", "mdev", "mig", "sriov"},
+	},
+	"disk": {
+		"readonly": {"true", "false"},
+		"required": {"true", "false"},
+	},
+	"usb": {
+		"required": {"true", "false"},
+	},
+	"unix-char": {
+		"required": {"true", "false"},
+	},
+	"unix-block": {
+		"required": {"true", "false"},
+	},
+	"proxy": {
+		"bind":           {"host", "instance", "container"},
+		"nat":            {"true", "false"},
+		"proxy_protocol": {"true", "false"},
+	},
+}
+
+// proxyAddressPattern matches the listen and connect addresses of proxy
+// devices, e.g. tcp:0.0.0.0:80, udp:[::]:53-55 or unix:/run/app.sock.
+var proxyAddressPattern = regexp.MustCompile(`^((tcp|udp):(\[[0-9a-fA-F:.]+\]|[^:\[\]]+):\d+(-\d+)?(,\d+(-\d+)?)*|unix:@?.+)$`)
+
+// pciAddressPattern matches PCI addresses, e.g. 0000:01:00.0.
+var pciAddressPattern = regexp.MustCompile(`^[0-9a-fA-F]{4}:[0-9a-fA-F]{2}:[0-9a-fA-F]{2}\.[0-7]$`)
+
+// hexIDPattern matches the vendor and product IDs of PCI and USB devices.
+var hexIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{4}$`)
+
+// sizePattern matches sizes in bytes, with an optional unit.
+var sizePattern = regexp.MustCompile(`^\d+(\.\d+)?([kMGTPE]i?B|B)?$`)
+
+// macAddressPattern matches MAC addresses, e.g. 00:16:3e:01:02:03.
+var macAddressPattern = regexp.MustCompile(`^([0-9a-fA-F]{2}:){5}[0-9a-fA-F]{2}$`)
+
+// deviceInstanceTypes lists, per device type, the properties
+// that only apply to one type of instance, and the value which
+// does when it matters.
+var deviceInstanceTypes = map[string]map[string]string{
+	"gpu": {
+		"gputype=mdev": "virtual-machine",
+		"gputype=mig":  "container",
+	},
+	"tpm": {
+		"path":   "container",
+		"pathrm": "container",
+	},
+}
+
+// bootPriorityPattern matches the boot priorities of devices.
+var bootPriorityPattern = regexp.MustCompile(`^\d+$`)
+
+// unixDevicePatterns lists the formats of the
+// properties of unix-char and unix-block devices.
+var unixDevicePatterns = map[string]propertyPattern{
+	"major": {regexp.MustCompile(`^\d+$`), "a device major number"},
+	"minor": {regexp.MustCompile(`^\d+$`), "a device minor number"},
+	"mode":  {regexp.MustCompile(`^0?[0-7]{3}$`), "an octal mode, e.g. 0660"},
+	"uid":   {regexp.MustCompile(`^\d+$`), "a user ID"},
+	"gid":   {regexp.MustCompile(`^\d+$`), "a group ID"},
+}
+
+// devicePropertyPatterns lists the formats of device properties,
+// per device type.
+var devicePropertyPatterns = map[string]map[string]propertyPattern{
+	"gpu": {
+		"pci":       {pciAddressPattern, "a PCI address, e.g. 0000:01:00.0"},
+		"vendorid":  {hexIDPattern, "a 4 digit hexadecimal ID, e.g. 10de"},
+		"productid": {hexIDPattern, "a 4 digit hexadecimal ID, e.g. 1eb8"},
+	},
+	"disk": {
+		"boot.priority": {bootPriorityPattern, "a boot priority, e.g. 10"},
+		"size":          {sizePattern, "a size, e.g. 10GiB"},
+		"size.state":    {sizePattern, "a size, e.g. 1GiB"},
+	},
+	"nic": {
+		"boot.priority": {bootPriorityPattern, "a boot priority, e.g. 10"},
+		"hwaddr":        {macAddressPattern, "a MAC address, e.g. 00:16:3e:01:02:03"},
+	},
+	"unix-char":  unixDevicePatterns,
+	"unix-block": unixDevicePatterns,
+	"usb": {
+		"vendorid":  {hexIDPattern, "a 4 digit hexadecimal ID, e.g. 0403"},
+		"productid": {hexIDPattern, "a 4 digit hexadecimal ID, e.g. 6001"},
+	},
+	"proxy": {
+		"listen":       {proxyAddressPattern, "<tcp|udp>:<address>:<port>[-<port>] or unix:<path>"},
+		"connect":      {proxyAddressPattern, "<tcp|udp>:<address>:<port>[-<port>] or unix:<path>"},
+		"security.uid": {regexp.MustCompile(`^\d+$`), "a user ID"},
+		"security.gid": {regexp.MustCompile(`^\d+$`), "a group ID"},
+	},
+}
+
+// resourceLxdValidateDevice checks the properties of a device against
+// the requirements of its type, and of the type of the instance using it
+// when known.
+func resourceLxdValidateDevice(name string, device map[string]string, instType string) error {
+	devType := device["type"]
+
+	for _, group := range deviceRequiredProperties[devType] {
+		found := false
+		for _, k := range group {
+			if device[k] != "" {
+				found = true
+			}
+		}
+
+		if !found {
+			return fmt.Errorf("Device %s of type %s must have one of these properties set: %s",
+				name, devType, strings.Join(group, ", "))
+		}
+	}
+
+	for k, allowed := range deviceAllowedValues[devType] {
+		v, ok := device[k]
+		if !ok {
+			continue
+		}
+
+		valid := false
+		for _, a := range allowed {
+			if v == a {
+				valid = true
+			}
+		}
+
+		if !valid {
+			return fmt.Errorf("Device %s has an invalid %s %q, must be one of: %s",
+				name, k, v, strings.Join(allowed, ", "))
+		}
+	}
+
+	for k, p := range devicePropertyPatterns[devType] {
+		if v, ok := device[k]; ok && !p.pattern.MatchString(v) {
+			return fmt.Errorf("Device %s has an invalid %s %q, must be %s", name, k, v, p.format)
+		}
+	}
+
+	for prop, t := range deviceInstanceTypes[devType] {
+		k, v := prop, ""
+		if parts := strings.SplitN(prop, "=", 2); len(parts) == 2 {
+			k, v = parts[0], parts[1]
+		}
+
+		if cur, ok := device[k]; ok && (v == "" || cur == v) && instType != "" && instType != t {
+			return fmt.Errorf("Device %s with %s can only be used by instances of type %s", name, prop, t)
+		}
+	}
+
+	switch {
+	// Disks of virtual machines can be block devices, such as ISO images,
+	// which aren't mounted anywhere.
+	case devType == "disk" && instType != "virtual-machine" && device["path"] == "":
+		return fmt.Errorf("Device %s of type %s must have one of these properties set: path", name, devType)
+	case devType == "tpm" && instType == "container" && device["path"] == "":
+		return fmt.Errorf("Device %s must have the path of the TPM in the container set", name)
+	case devType == "gpu" && device["gputype"] == "mdev" && device["mdev"] == "":
+		return fmt.Errorf("Device %s must have the mdev profile to use set", name)
+	case devType == "gpu" && device["gputype"] == "mig" && device["mig.uuid"] == "" &&
+		(device["mig.ci"] == "" || device["mig.gi"] == ""):
+		return fmt.Errorf("Device %s must have mig.uuid, or mig.ci and mig.gi set", name)
+	}
+
+	if devType == "nic" {
+		if err := resourceLxdValidateNicAddresses(name, device); err != nil {
+			return err
+		}
+	}
+
+	// NAT proxies are implemented with firewall rules, which only
+	// forward TCP and UDP, to the address of the instance.
+	if devType == "proxy" && device["nat"] == "true" {
+		for _, k := range []string{"listen", "connect"} {
+			if strings.HasPrefix(device[k], "unix:") {
+				return fmt.Errorf("Device %s can't use a unix socket as %s in nat mode", name, k)
+			}
+		}
+
+		if device["proxy_protocol"] == "true" {
+			return fmt.Errorf("Device %s can't use proxy_protocol in nat mode", name)
+		}
+
+		if b := device["bind"]; b != "" && b != "host" {
+			return fmt.Errorf("Device %s must be bound to the host in nat mode", name)
+		}
+	}
+
+	return nil
+}
+
+// resourceLxdValidateNicAddresses checks the static addresses of a NIC.
+// Bridged NICs, including the ones attached to managed networks, get a
+// single address per family from the DHCP server of the bridge, while
+// routed and ipvlan NICs can be given lists of addresses.
+func resourceLxdValidateNicAddresses(name string, device map[string]string) error {
+	multiple := false
+	switch device["nictype"] {
+	case "", "bridged":
+	case "routed", "ipvlan":
+		multiple = true
+	default:
+		for _, k := range []string{"ipv4.address", "ipv6.address"} {
+			if device[k] != "" {
+				return fmt.Errorf("Device %s of nictype %s can't have a static %s", name, device["nictype"], k)
+			}
+		}
+		return nil
+	}
+
+	for _, family := range []string{"ipv4", "ipv6"} {
+		k := family + ".address"
+		if device[k] == "" {
+			continue
+		}
+
+		addrs := strings.Split(device[k], ",")
+		if len(addrs) > 1 && !multiple {
+			return fmt.Errorf("Device %s can only have one %s", name, k)
+		}
+
+		for _, addr := range addrs {
+			ip := net.ParseIP(strings.TrimSpace(addr))
+			if ip == nil || (ip.To4() != nil) != (family == "ipv4") {
+				return fmt.Errorf("Device %s has an invalid %s %q, must be an %s address", name, k, addr, family)
+			}
+		}
+	}
+
+	return nil
+}
+
+// resourceLxdCheckNicNetworks checks that the static addresses of NICs
+// attached to managed networks, either through their network or as the
+// parent of bridged NICs, are within the subnets of the networks, and
+// aren't the addresses of the networks themselves. Networks which can't
+// be read, or have no subnet of a family, are skipped.
+func resourceLxdCheckNicNetworks(server lxd.ContainerServer, devices map[string]map[string]string) error {
+	for name, device := range devices {
+		if device["type"] != "nic" {
+			continue
+		}
+
+		networkName := device["network"]
+		if networkName == "" && device["nictype"] == "bridged" {
+			networkName = device["parent"]
+		}
+		if networkName == "" {
+			continue
+		}
+
+		network, _, err := server.GetNetwork(networkName)
+		if err != nil {
+			continue
+		}
+
+		for _, family := range []string{"ipv4", "ipv6"} {
+			k := family + ".address"
+			addr := net.ParseIP(device[k])
+			gateway, subnet, err := net.ParseCIDR(network.Config[k])
+			if addr == nil || err != nil {
+				continue
+			}
+
+			if !subnet.Contains(addr) {
+				return fmt.Errorf("The %s %s of device %s is outside of the subnet %s of network %s",
+					k, addr, name, subnet, network.Name)
+			}
+
+			if addr.Equal(gateway) {
+				return fmt.Errorf("The %s %s of device %s is the address of network %s",
+					k, addr, name, network.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// resourceLxdValidateDevicesDiff validates the devices of a resource at
+// plan time. Devices depending on values not known yet are skipped.
+func resourceLxdValidateDevicesDiff(d *schema.ResourceDiff) error {
+	if !d.NewValueKnown("device") {
+		return nil
+	}
+
+	// Not all resources with devices have a type.
+	instType, _ := d.Get("type").(string)
+
+	for name, device := range resourceLxdDevices(d.Get("device")) {
+		if err := resourceLxdValidateDevice(name, device, instType); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// limitPatterns lists the formats of the common instance limits, keyed
+// by their name without the limits. prefix.
+var limitPatterns = map[string]propertyPattern{
+	"cpu":                  {regexp.MustCompile(`^\d+(-\d+)?(,\d+(-\d+)?)*$`), "a number of CPUs or a list of CPU ranges, e.g. 2 or 0-1,4"},
+	"cpu.allowance":        {regexp.MustCompile(`^(\d+%|\d+ms/\d+ms)$`), "a percentage or a time quota, e.g. 50% or 25ms/100ms"},
+	"cpu.priority":         {regexp.MustCompile(`^([0-9]|10)$`), "a priority between 0 and 10"},
+	"disk.priority":        {regexp.MustCompile(`^([0-9]|10)$`), "a priority between 0 and 10"},
+	"network.priority":     {regexp.MustCompile(`^([0-9]|10)$`), "a priority between 0 and 10"},
+	"memory":               {regexp.MustCompile(`^(\d+(\.\d+)?([kMGTPE]i?B|B)?|\d+%)$`), "a size or a percentage, e.g. 512MiB, 2GB or 50%"},
+	"memory.enforce":       {regexp.MustCompile(`^(hard|soft)$`), "hard or soft"},
+	"memory.swap":          {regexp.MustCompile(`^(true|false)$`), "true or false"},
+	"memory.swap.priority": {regexp.MustCompile(`^([0-9]|10)$`), "a priority between 0 and 10"},
+	"processes":            {regexp.MustCompile(`^\d+$`), "a number of processes"},
+}
+
+// resourceLxdValidateLimits validates the values of the common instance
+// limits. Other limits are passed to LXD as they are.
+func resourceLxdValidateLimits(v interface{}, k string) (ws []string, errors []error) {
+	for name, value := range v.(map[string]interface{}) {
+		limit, ok := limitPatterns[name]
+		if !ok {
+			continue
+		}
+
+		// Values that aren't known yet can't be checked.
+		s, ok := value.(string)
+		if !ok || strings.Contains(s, "${") {
+			continue
+		}
+
+		if !limit.pattern.MatchString(s) {
+			errors = append(errors, fmt.Errorf("%s.%s must be %s, got %q", k, name, limit.format, s))
+		}
+	}
+
+	return
+}
+
+// resourceLxdValidateLimit returns a validator
+// of the value of one of the common instance limits.
+func resourceLxdValidateLimit(name string) schema.SchemaValidateFunc {
+	limit := limitPatterns[name]
+	return func(v interface{}, k string) (ws []string, errors []error) {
+		if s := v.(string); !limit.pattern.MatchString(s) {
+			errors = append(errors, fmt.Errorf("%s must be %s, got %q", k, limit.format, s))
+		}
+
+		return
+	}
+}
+
+// resourceLxdStatefulError explains the errors of stateful operations,
+// such as stateful snapshots, failing because CRIU isn't available.
+func resourceLxdStatefulError(err error) error {
+	if err != nil && strings.Contains(strings.ToLower(err.Error()), "criu") &&
+		(strings.Contains(err.Error(), "isn't installed") || strings.Contains(err.Error(), "not found")) {
+		return fmt.Errorf("%s: saving the runtime state of containers needs CRIU on the LXD server, "+
+			"e.g. enabled with `snap set lxd criu.enable=true`", err)
+	}
+	return err
+}
+
+// cronFieldPattern matches a field of a cron expression,
+// e.g. *, */15, 1-5 or MON,WED.
+var cronFieldPattern = regexp.MustCompile(`^(\*|[0-9A-Za-z]+(-[0-9A-Za-z]+)?)(/\d+)?(,(\*|[0-9A-Za-z]+(-[0-9A-Za-z]+)?)(/\d+)?)*$`)
+
+// snapshotScheduleAliases lists the aliases snapshot schedules can use
+// instead of a cron expression.
+var snapshotScheduleAliases = []string{
+	"@hourly", "@daily", "@midnight", "@weekly", "@monthly", "@annually", "@yearly", "@startup",
+}
+
+// resourceLxdValidateSnapshotSchedule validates a snapshot schedule, either
+// a cron expression or a comma separated list of aliases such as @daily.
+func resourceLxdValidateSnapshotSchedule(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	if strings.HasPrefix(value, "@") {
+		for _, alias := range strings.Split(value, ",") {
+			valid := false
+			for _, a := range snapshotScheduleAliases {
+				if strings.TrimSpace(alias) == a {
+					valid = true
+				}
+			}
+
+			if !valid {
+				errors = append(errors, fmt.Errorf("%q is not a valid schedule alias for '%s', must be one of: %s",
+					alias, k, strings.Join(snapshotScheduleAliases, ", ")))
+			}
+		}
+		return
+	}
+
+	fields := strings.Fields(value)
+	if len(fields) != 5 {
+		errors = append(errors, fmt.Errorf(
+			"'%s' must be a cron expression of 5 fields, e.g. \"0 6 * * *\", or a schedule alias, e.g. @daily", k))
+		return
+	}
+
+	for _, field := range fields {
+		if !cronFieldPattern.MatchString(field) {
+			errors = append(errors, fmt.Errorf("'%s' has an invalid cron field %q", k, field))
+		}
+	}
+
+	return
+}
+
+// snapshotExpiryPattern matches snapshot expiries, e.g. 2w or 1d 12H,
+// in minutes (M), hours (H), days (d), weeks (w), months (m) or years (y).
+var snapshotExpiryPattern = regexp.MustCompile(`^\d+[MHdwmy]( \d+[MHdwmy])*$`)
+
+// resourceLxdValidateSnapshotExpiry validates a snapshot expiry.
+func resourceLxdValidateSnapshotExpiry(v interface{}, k string) (ws []string, errors []error) {
+	if !snapshotExpiryPattern.MatchString(v.(string)) {
+		errors = append(errors, fmt.Errorf(
+			"'%s' must be a list of durations with a unit among M, H, d, w, m and y, e.g. 2w or 1d 12H", k))
+	}
+
+	return
+}
+
+// snapshotExpiryTime returns the time at which an expiry, in the format
+// accepted by resourceLxdValidateSnapshotExpiry, is reached from t.
+func snapshotExpiryTime(t time.Time, expiry string) (time.Time, error) {
+	if !snapshotExpiryPattern.MatchString(expiry) {
+		return t, fmt.Errorf("Invalid expiry %q", expiry)
+	}
+
+	for _, field := range strings.Fields(expiry) {
+		n, err := strconv.Atoi(field[:len(field)-1])
+		if err != nil {
+			return t, fmt.Errorf("Invalid expiry %q: %s", expiry, err)
+		}
+
+		switch field[len(field)-1] {
+		case 'M':
+			t = t.Add(time.Duration(n) * time.Minute)
+		case 'H':
+			t = t.Add(time.Duration(n) * time.Hour)
+		case 'd':
+			t = t.AddDate(0, 0, n)
+		case 'w':
+			t = t.AddDate(0, 0, 7*n)
+		case 'm':
+			t = t.AddDate(0, n, 0)
+		case 'y':
+			t = t.AddDate(n, 0, 0)
+		}
+	}
+
+	return t, nil
+}
+
 func resourceLxdValidateDeviceType(v interface{}, k string) (ws []string, errors []error) {
 	validTypes := []string{
-		"none", "disk", "nic", "unix-char", "unix-block", "usb", "gpu", "infiniband", "proxy",
+		"none", "disk", "nic", "unix-char", "unix-block", "usb", "gpu", "infiniband", "proxy", "tpm",
 	}
 
 	value := v.(string)
@@ -264,6 +1125,103 @@ func containerDeleteFile(server lxd.ContainerServer, container string, targetFil
 	return nil
 }
 
+// instanceUploadFile will upload a file to an instance. Unlike
+// containerUploadFile, it works with virtual machines too.
+func instanceUploadFile(server lxd.ContainerServer, instance string, file File) error {
+	if file.Content != "" && file.Source != "" {
+		return fmt.Errorf("only one of content or source can be specified")
+	}
+
+	targetFile := file.TargetFile
+	if !path.IsAbs(targetFile) || strings.HasSuffix(targetFile, "/") {
+		return fmt.Errorf("Target must be an absolute path with filename")
+	}
+
+	m, err := strconv.ParseInt(file.Mode, 8, 0)
+	if file.Mode == "" {
+		m, err = 0755, nil
+	}
+	if err != nil {
+		return fmt.Errorf("Could not determine file mode %s", file.Mode)
+	}
+	mode := os.FileMode(m)
+
+	args := lxd.InstanceFileArgs{
+		Mode:      int(mode.Perm()),
+		UID:       int64(file.UID),
+		GID:       int64(file.GID),
+		Type:      "file",
+		WriteMode: "overwrite",
+		Content:   strings.NewReader(file.Content),
+	}
+
+	if file.Source != "" {
+		p, err := homedir.Expand(file.Source)
+		if err != nil {
+			return fmt.Errorf("unable to determine source file path: %s", err)
+		}
+
+		f, err := os.Open(p)
+		if err != nil {
+			return fmt.Errorf("unable to read source file: %s", err)
+		}
+		defer f.Close()
+
+		args.Content = f
+	}
+
+	log.Printf("[DEBUG] Attempting to upload file to %s with uid %d, gid %d, and mode %04o",
+		targetFile, file.UID, file.GID, mode)
+
+	if file.CreateDirectories {
+		dirArgs := lxd.InstanceFileArgs{
+			Mode: int((mode | 0111).Perm()),
+			UID:  int64(file.UID),
+			GID:  int64(file.GID),
+			Type: "directory",
+		}
+
+		// Create the missing parents, from the top.
+		var missing []string
+		for dir := path.Dir(targetFile); dir != "/"; dir = path.Dir(dir) {
+			if _, resp, err := server.GetInstanceFile(instance, dir); err == nil {
+				if resp.Type != "directory" {
+					return fmt.Errorf("%s is not a directory", dir)
+				}
+				break
+			}
+			missing = append([]string{dir}, missing...)
+		}
+
+		for _, dir := range missing {
+			if err := server.CreateInstanceFile(instance, dir, dirArgs); err != nil {
+				return fmt.Errorf("Could not upload file %s: %s", targetFile, err)
+			}
+		}
+	}
+
+	if err := server.CreateInstanceFile(instance, targetFile, args); err != nil {
+		return fmt.Errorf("Could not upload file %s: %s", targetFile, err)
+	}
+
+	log.Printf("[DEBUG] Successfully uploaded file %s", targetFile)
+
+	return nil
+}
+
+// instanceDeleteFile will delete a file on an instance.
+func instanceDeleteFile(server lxd.ContainerServer, instance string, targetFile string) error {
+	log.Printf("[DEBUG] Attempting to delete file %s", targetFile)
+
+	if err := server.DeleteInstanceFile(instance, targetFile); err != nil {
+		return fmt.Errorf("Could not delete file %s: %s", targetFile, err)
+	}
+
+	log.Printf("[DEBUG] Successfully deleted file %s", targetFile)
+
+	return nil
+}
+
 // recursiveMkdir was copied almost as-is from github.com/lxc/lxd/lxc/file.go
 func recursiveMkdir(d lxd.ContainerServer, container string, p string, mode os.FileMode, uid int64, gid int64) error {
 	/* special case, every container has a /, we don't need to do anything */
@@ -313,3 +1271,179 @@ func recursiveMkdir(d lxd.ContainerServer, container string, p string, mode os.F
 
 	return nil
 }
+
+// serverConfigKeys returns the config keys LXD documents for an entity,
+// such as instance, mapped to their type. It returns nil when the server
+// doesn't document its config keys.
+func serverConfigKeys(server lxd.ContainerServer, entity string) (map[string]string, error) {
+	if !server.HasExtension("metadata_configuration") {
+		return nil, nil
+	}
+
+	resp, _, err := server.RawQuery("GET", "/1.0/metadata/configuration", nil, "")
+	if err != nil {
+		return nil, fmt.Errorf("Unable to retrieve the config keys of the server: %s", err)
+	}
+
+	var metadata struct {
+		Configs map[string]map[string]struct {
+			Keys []map[string]struct {
+				Type string `json:"type"`
+			} `json:"keys"`
+		} `json:"configs"`
+	}
+	if err := json.Unmarshal(resp.Metadata, &metadata); err != nil {
+		return nil, fmt.Errorf("Unable to parse the config keys of the server: %s", err)
+	}
+
+	keys := make(map[string]string)
+	for _, group := range metadata.Configs[entity] {
+		for _, k := range group.Keys {
+			for name, key := range k {
+				keys[name] = key.Type
+			}
+		}
+	}
+
+	return keys, nil
+}
+
+// resourceLxdCheckConfigKeys checks the config keys planned for a
+// resource against the ones its remote documents for the entity.
+func resourceLxdCheckConfigKeys(d *schema.ResourceDiff, p *lxdProvider, entity string, config map[string]string) error {
+	remote := d.Get("remote").(string)
+	if remote == "" {
+		remote = p.LXDConfig.DefaultRemote
+	}
+
+	keys, err := p.getConfigKeys(remote, entity)
+	if err != nil {
+		return err
+	}
+
+	return resourceLxdValidateConfigKeys(keys, config)
+}
+
+// resourceLxdValidateConfigKeys checks config keys against the ones the
+// server documents, listing the known keys closest to an unknown one.
+// Keys documented as patterns, such as environment.*, match any key
+// with their prefix, and user keys are always valid.
+func resourceLxdValidateConfigKeys(keys map[string]string, config map[string]string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	var prefixes []string
+	for name := range keys {
+		if i := strings.IndexAny(name, "*<["); i >= 0 {
+			prefixes = append(prefixes, name[:i])
+		}
+	}
+
+	names := make([]string, 0, len(config))
+	for k := range config {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	for _, k := range names {
+		if strings.HasPrefix(k, "user.") || strings.HasPrefix(k, "volatile.") {
+			continue
+		}
+
+		keyType, ok := keys[k]
+		if !ok {
+			for _, prefix := range prefixes {
+				if strings.HasPrefix(k, prefix) {
+					ok = true
+					break
+				}
+			}
+		}
+
+		if !ok {
+			if similar := similarConfigKeys(keys, k); len(similar) > 0 {
+				return fmt.Errorf("Unknown config key %s, did you mean one of: %s", k, strings.Join(similar, ", "))
+			}
+			return fmt.Errorf("Unknown config key %s", k)
+		}
+
+		v := config[k]
+		switch keyType {
+		case "bool":
+			switch strings.ToLower(v) {
+			case "true", "false", "1", "0", "yes", "no", "on", "off":
+			default:
+				return fmt.Errorf("Config key %s must be a boolean, not %q", k, v)
+			}
+		case "integer":
+			if _, err := strconv.ParseInt(v, 10, 64); err != nil {
+				return fmt.Errorf("Config key %s must be an integer, not %q", k, v)
+			}
+		}
+	}
+
+	return nil
+}
+
+// similarConfigKeys returns the known keys at most two edits away from
+// a key, or else the ones in the same namespace.
+func similarConfigKeys(keys map[string]string, key string) []string {
+	var similar, namespace []string
+	ns := key
+	if i := strings.LastIndex(key, "."); i >= 0 {
+		ns = key[:i+1]
+	}
+
+	for name := range keys {
+		if editDistance(name, key) <= 2 {
+			similar = append(similar, name)
+		} else if strings.HasPrefix(name, ns) {
+			namespace = append(namespace, name)
+		}
+	}
+
+	if len(similar) == 0 {
+		similar = namespace
+	}
+	sort.Strings(similar)
+
+	if len(similar) > 5 {
+		similar = similar[:5]
+	}
+
+	return similar
+}
+
+// editDistance returns the Levenshtein distance between two strings.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}